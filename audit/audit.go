@@ -0,0 +1,127 @@
+// Package audit records Wake-on-LAN activity to pluggable sinks, so wake
+// activity can be reviewed later.
+//
+// A Logger implements wol.Observer. Set it as the Observer of a wol.Client,
+// wol.RawClient, or a server which accepts one, and it records each attempt
+// to send a magic packet to every Sink:
+//
+//	f, err := audit.OpenFile("/var/log/wol/audit.jsonl", &audit.FileConfig{
+//		MaxSize:    10 << 20,
+//		MaxBackups: 5,
+//	})
+//	if err != nil {
+//		// Handle error.
+//	}
+//	defer f.Close()
+//
+//	c.Observer = audit.New(f)
+package audit
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mdlayher/wol"
+)
+
+// A Record is a single entry in an audit log.
+type Record struct {
+	// Time is the time of the attempt to send a magic packet.
+	Time time.Time `json:"time"`
+
+	// Identity identifies who requested the attempt, if known.
+	Identity string `json:"identity,omitempty"`
+
+	// Target is the hardware address of the machine to wake or sleep.
+	Target string `json:"target"`
+
+	// Action is "wake" or "sleep".
+	Action string `json:"action"`
+
+	// Transport, Source, and Destination describe how the magic packet was
+	// sent. See wol.SendEvent for details.
+	Transport   string `json:"transport"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination"`
+
+	// Password reports whether the magic packet carried a password. The
+	// password itself is never recorded.
+	Password bool `json:"password"`
+
+	// Error is empty if the magic packet was sent successfully.
+	Error string `json:"error,omitempty"`
+}
+
+// NewRecord creates a Record from a wol.SendEvent.
+func NewRecord(e *wol.SendEvent) *Record {
+	r := &Record{
+		Time:        e.Time,
+		Identity:    e.Identity,
+		Target:      e.Target.String(),
+		Action:      "wake",
+		Transport:   e.Transport,
+		Source:      e.Source,
+		Destination: e.Destination,
+		Password:    e.Password,
+	}
+	if e.Sleep {
+		r.Action = "sleep"
+	}
+	if e.Err != nil {
+		r.Error = e.Err.Error()
+	}
+
+	return r
+}
+
+// A Sink stores Records. Sinks must be safe for concurrent use.
+type Sink interface {
+	Write(r *Record) error
+}
+
+// A SinkFunc is an adapter which allows an ordinary function to be used as
+// a Sink.
+type SinkFunc func(r *Record) error
+
+// Write implements Sink.
+func (fn SinkFunc) Write(r *Record) error {
+	return fn(r)
+}
+
+var _ wol.Observer = &Logger{}
+
+// A Logger records Records to one or more Sinks.
+type Logger struct {
+	// OnError is optionally invoked when a Sink fails to write a Record
+	// observed using ObserveSend. It must be set before the Logger is used.
+	OnError func(err error)
+
+	sinks []Sink
+}
+
+// New creates a Logger which writes Records to each of sinks.
+func New(sinks ...Sink) *Logger {
+	return &Logger{
+		sinks: sinks,
+	}
+}
+
+// ObserveSend implements wol.Observer.
+func (l *Logger) ObserveSend(e *wol.SendEvent) {
+	if err := l.Log(NewRecord(e)); err != nil && l.OnError != nil {
+		l.OnError(err)
+	}
+}
+
+// Log writes r to each of l's Sinks. Every Sink is written to, even if an
+// earlier Sink returns an error.
+func (l *Logger) Log(r *Record) error {
+	var errs []error
+	for _, s := range l.sinks {
+		if err := s.Write(r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,249 @@
+package audit_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/audit"
+	"github.com/mdlayher/wol/woltest"
+)
+
+var target = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+
+func TestLoggerClients(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		records []*audit.Record
+	)
+	l := audit.New(audit.SinkFunc(func(r *audit.Record) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		records = append(records, r)
+		return nil
+	}))
+
+	n := woltest.NewNetwork()
+	n.AddHost(target, nil)
+
+	pc, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	c := wol.NewClientConn(pc)
+	c.Observer = l
+	defer c.Close()
+
+	ifi, rc := n.ListenRaw()
+	rawc := wol.NewRawClientConn(ifi, rc)
+	rawc.Observer = l
+	defer rawc.Close()
+
+	if err := c.WakePassword("192.0.2.255:9", target, []byte{1, 2, 3, 4}); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+	if err := c.WakePassword("192.0.2.255:9", target, []byte{1, 2, 3}); err == nil {
+		t.Fatal("expected an error for invalid password, but none occurred")
+	}
+	if err := rawc.Sleep(target); err != nil {
+		t.Fatalf("failed to sleep: %v", err)
+	}
+
+	want := []*audit.Record{
+		{
+			Target:      target.String(),
+			Action:      "wake",
+			Transport:   wol.TransportUDP,
+			Source:      "192.0.2.2:49152",
+			Destination: "192.0.2.255:9",
+			Password:    true,
+		},
+		{
+			Target:      target.String(),
+			Action:      "wake",
+			Transport:   wol.TransportUDP,
+			Source:      "192.0.2.2:49152",
+			Destination: "192.0.2.255:9",
+			Password:    true,
+			Error:       "invalid password length",
+		},
+		{
+			Target:      target.String(),
+			Action:      "sleep",
+			Transport:   wol.TransportRaw,
+			Source:      ifi.Name,
+			Destination: target.String(),
+		},
+	}
+
+	for _, r := range records {
+		if r.Time.IsZero() {
+			t.Fatalf("record has no time: %+v", r)
+		}
+	}
+
+	if diff := cmp.Diff(want, records, cmpopts.IgnoreFields(audit.Record{}, "Time")); diff != "" {
+		t.Fatalf("unexpected records (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoggerErrors(t *testing.T) {
+	var (
+		errSink = errors.New("sink failed")
+		written int
+		onError error
+	)
+
+	l := audit.New(
+		audit.SinkFunc(func(_ *audit.Record) error { return errSink }),
+		audit.SinkFunc(func(_ *audit.Record) error {
+			written++
+			return nil
+		}),
+	)
+	l.OnError = func(err error) { onError = err }
+
+	l.ObserveSend(&wol.SendEvent{Target: target})
+
+	if !errors.Is(onError, errSink) {
+		t.Fatalf("unexpected error: %v", onError)
+	}
+	if written != 1 {
+		t.Fatalf("record was not written to all sinks: %d", written)
+	}
+}
+
+func TestFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	f, err := audit.OpenFile(path, &audit.FileConfig{
+		// Large enough for only one record per file.
+		MaxSize:    200,
+		MaxBackups: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := f.Write(&audit.Record{
+			Time:        time.Unix(int64(i), 0).UTC(),
+			Target:      target.String(),
+			Action:      "wake",
+			Transport:   wol.TransportUDP,
+			Destination: fmt.Sprintf("192.0.2.%d:9", i),
+		}); err != nil {
+			t.Fatalf("failed to write record %d: %v", i, err)
+		}
+	}
+
+	// Only the newest records remain, newest first.
+	for i, name := range []string{path, path + ".1", path + ".2"} {
+		rs := readRecords(t, name)
+		if len(rs) != 1 {
+			t.Fatalf("unexpected number of records in %q: %d", name, len(rs))
+		}
+
+		if want := fmt.Sprintf("192.0.2.%d:9", 4-i); rs[0].Destination != want {
+			t.Fatalf("unexpected record in %q: %s", name, rs[0].Destination)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected oldest backup to be removed: %v", err)
+	}
+}
+
+func TestFileRotationError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// A non-empty directory in place of the oldest backup cannot be removed,
+	// so rotation fails.
+	if err := os.MkdirAll(filepath.Join(path+".1", "busy"), 0o700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	f, err := audit.OpenFile(path, &audit.FileConfig{
+		MaxSize:    200,
+		MaxBackups: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	write := func(i int) error {
+		return f.Write(&audit.Record{
+			Time:        time.Unix(int64(i), 0).UTC(),
+			Target:      target.String(),
+			Action:      "wake",
+			Destination: fmt.Sprintf("192.0.2.%d:9", i),
+		})
+	}
+
+	if err := write(0); err != nil {
+		t.Fatalf("failed to write first record: %v", err)
+	}
+	if err := write(1); err == nil {
+		t.Fatal("expected a rotation error, but none occurred")
+	}
+
+	// Records are kept in the current file while rotation fails, and
+	// rotation succeeds once the obstruction is removed.
+	if rs := readRecords(t, path); len(rs) != 2 {
+		t.Fatalf("expected 2 records after failed rotation, but got %d", len(rs))
+	}
+
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatalf("failed to remove directory: %v", err)
+	}
+	if err := write(2); err != nil {
+		t.Fatalf("failed to write after rotation recovered: %v", err)
+	}
+
+	if rs := readRecords(t, path); len(rs) != 1 || rs[0].Destination != "192.0.2.2:9" {
+		t.Fatalf("unexpected records after rotation: %d", len(rs))
+	}
+	if rs := readRecords(t, path+".1"); len(rs) != 2 {
+		t.Fatalf("unexpected number of backup records: %d", len(rs))
+	}
+}
+
+func readRecords(t *testing.T, path string) []*audit.Record {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	defer f.Close()
+
+	var rs []*audit.Record
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var r audit.Record
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			t.Fatalf("failed to unmarshal record: %v", err)
+		}
+
+		rs = append(rs, &r)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+
+	return rs
+}
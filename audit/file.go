@@ -0,0 +1,177 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// FileConfig contains configuration for a File.
+type FileConfig struct {
+	// MaxSize is the size in bytes at which the file is rotated. If zero,
+	// the file is never rotated.
+	MaxSize int64
+
+	// MaxBackups is the number of rotated files to keep, named with numeric
+	// suffixes such as "audit.jsonl.1". Older files are removed. If zero,
+	// rotated files are removed immediately.
+	MaxBackups int
+
+	// Mode is the permission mode of new files. If zero, 0600 is used.
+	Mode fs.FileMode
+}
+
+var _ Sink = &File{}
+
+// A File is a Sink which appends Records to a file as JSON lines, with
+// optional size-based rotation.
+type File struct {
+	path string
+	cfg  FileConfig
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenFile opens or creates the file at path for appending Records. If cfg
+// is nil, a default configuration with no rotation is used.
+func OpenFile(path string, cfg *FileConfig) (*File, error) {
+	if cfg == nil {
+		cfg = &FileConfig{}
+	}
+
+	f := &File{
+		path: path,
+		cfg:  *cfg,
+	}
+	if f.cfg.Mode == 0 {
+		f.cfg.Mode = 0o600
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Write implements Sink.
+func (f *File) Write(r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return os.ErrClosed
+	}
+
+	// A failed rotation is reported, but the Record is still written to the
+	// current file if it could be reopened, so no Records are lost.
+	var rerr error
+	if f.cfg.MaxSize > 0 && f.size > 0 && f.size+int64(len(b)) > f.cfg.MaxSize {
+		if err := f.rotate(); err != nil {
+			rerr = fmt.Errorf("audit: failed to rotate %q: %w", f.path, err)
+			if f.f == nil {
+				return rerr
+			}
+		}
+	}
+
+	n, err := f.f.Write(b)
+	f.size += int64(n)
+	return errors.Join(rerr, err)
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return nil
+	}
+
+	err := f.f.Close()
+	f.f = nil
+	return err
+}
+
+// open opens the file at f.path for appending. f.mu must be held or f must
+// not yet be shared.
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, f.cfg.Mode)
+	if err != nil {
+		return err
+	}
+
+	fi, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.f = file
+	f.size = fi.Size()
+	return nil
+}
+
+// rotate shifts each backup to the next numeric suffix, moves the current
+// file to the first backup, and opens a new file. If rotation fails, the
+// current file is reopened for appending, so later Writes may succeed. f.mu
+// must be held.
+func (f *File) rotate() error {
+	err := f.f.Close()
+	f.f = nil
+	if err == nil {
+		err = f.shift()
+	}
+	if err != nil {
+		if oerr := f.open(); oerr != nil {
+			return errors.Join(err, oerr)
+		}
+
+		return err
+	}
+
+	return f.open()
+}
+
+// shift removes the current file if no backups are kept, and otherwise
+// shifts each backup and the current file to the next numeric suffix. The
+// current file must be closed.
+func (f *File) shift() error {
+	if f.cfg.MaxBackups == 0 {
+		return os.Remove(f.path)
+	}
+
+	// Remove the oldest backup, which would otherwise exceed MaxBackups.
+	if err := os.Remove(f.backup(f.cfg.MaxBackups)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for i := f.cfg.MaxBackups - 1; i >= 0; i-- {
+		if err := os.Rename(f.backup(i), f.backup(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backup returns the path of backup n. Backup 0 is the current file.
+func (f *File) backup(n int) string {
+	if n == 0 {
+		return f.path
+	}
+
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
@@ -0,0 +1,37 @@
+//go:build !windows && !plan9
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+var _ Sink = &Syslog{}
+
+// A Syslog is a Sink which writes Records to syslog as JSON. Records of
+// failed attempts are written with warning severity, and others with
+// informational severity.
+type Syslog struct {
+	w *syslog.Writer
+}
+
+// NewSyslog creates a Syslog which writes to w. Closing w is the caller's
+// responsibility.
+func NewSyslog(w *syslog.Writer) *Syslog {
+	return &Syslog{w: w}
+}
+
+// Write implements Sink.
+func (s *Syslog) Write(r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if r.Error != "" {
+		return s.w.Warning(string(b))
+	}
+
+	return s.w.Info(string(b))
+}
@@ -0,0 +1,66 @@
+//go:build !windows && !plan9
+
+package audit_test
+
+import (
+	"log/syslog"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdlayher/wol/audit"
+)
+
+func TestSyslog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skipf("skipping, cannot listen on Unix datagram socket: %v", err)
+	}
+	defer pc.Close()
+
+	w, err := syslog.Dial("unixgram", path, syslog.LOG_DAEMON, "wol")
+	if err != nil {
+		t.Fatalf("failed to dial syslog: %v", err)
+	}
+	defer w.Close()
+
+	s := audit.NewSyslog(w)
+
+	var tests = []struct {
+		name     string
+		r        *audit.Record
+		priority string
+	}{
+		{
+			name:     "success",
+			r:        &audit.Record{Target: target.String()},
+			priority: "<30>",
+		},
+		{
+			name:     "failure",
+			r:        &audit.Record{Target: target.String(), Error: "failed"},
+			priority: "<28>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Write(tt.r); err != nil {
+				t.Fatalf("failed to write: %v", err)
+			}
+
+			b := make([]byte, 1024)
+			n, _, err := pc.ReadFrom(b)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+
+			msg := string(b[:n])
+			if !strings.HasPrefix(msg, tt.priority) || !strings.Contains(msg, `"target":"`+target.String()+`"`) {
+				t.Fatalf("unexpected syslog message: %q", msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package wol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// defaultChainTimeout is the amount of time a Chain waits for a machine
+	// to become alive after a step when the step does not specify a timeout.
+	defaultChainTimeout = 30 * time.Second
+
+	// defaultChainInterval is the interval at which a Chain runs its Check
+	// when none is specified.
+	defaultChainInterval = time.Second
+)
+
+// ErrNotAlive is reported in a StepAttempt when a machine does not pass a
+// liveness Check within the step's timeout, as opposed to its Waker failing.
+var ErrNotAlive = errors.New("wol: machine did not become alive before timeout")
+
+// A WakerFunc is an adapter which allows an ordinary function to be used as
+// a Waker, such as one which power cycles a machine using a smart plug's
+// HTTP API.
+type WakerFunc func(ctx context.Context, target net.HardwareAddr) error
+
+// WakeContext implements Waker.
+func (fn WakerFunc) WakeContext(ctx context.Context, target net.HardwareAddr) error {
+	return fn(ctx, target)
+}
+
+// A Check reports whether a machine is alive. It returns nil if the machine
+// is alive, or an error describing why it is not.
+type Check func(ctx context.Context) error
+
+// DialCheck returns a Check which reports a machine alive when a connection
+// can be established to addr using network, such as "tcp" and "10.0.0.1:22".
+func DialCheck(network, addr string) Check {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		c, err := d.DialContext(ctx, network, addr)
+		if err != nil {
+			return err
+		}
+
+		return c.Close()
+	}
+}
+
+// A Step is a single method used by a Chain to attempt to wake a machine.
+type Step struct {
+	// Name identifies the step in a ChainResult, such as "udp" or "ipmi".
+	Name string
+
+	// Waker attempts to wake the machine.
+	Waker Waker
+
+	// Timeout specifies how long to wait for the machine to pass the
+	// Chain's Check after Waker is invoked, before advancing to the next
+	// step. If zero, a default of 30 seconds is used.
+	Timeout time.Duration
+}
+
+// A Chain attempts to wake a machine using an ordered list of Steps, such as
+// a UDP magic packet, then a raw Ethernet magic packet, then a BMC. Each step
+// is tried in turn, advancing only when the machine fails to pass a liveness
+// Check within the step's timeout.
+type Chain struct {
+	// Steps specifies the methods used to wake a machine, in order.
+	Steps []Step
+
+	// Check reports whether a machine is alive. Check must be set.
+	Check Check
+
+	// Interval specifies how often Check is invoked while waiting for a
+	// machine to become alive. If zero, a default of 1 second is used.
+	Interval time.Duration
+}
+
+// A ChainResult reports the outcome of a Chain's attempt to wake a machine.
+type ChainResult struct {
+	// Step is the name of the step after which the machine became alive.
+	// If the machine was already alive, Step is empty.
+	Step string
+
+	// Attempts contains an entry for each step which was tried, in order.
+	Attempts []StepAttempt
+}
+
+// A StepAttempt records the outcome of a single Step.
+type StepAttempt struct {
+	// Name is the name of the Step.
+	Name string
+
+	// Err is nil if the machine became alive after the Step. Otherwise, it
+	// reports why the Step failed: an error from its Waker, or ErrNotAlive
+	// if the machine did not pass the Chain's Check in time.
+	Err error
+}
+
+// Wake attempts to wake the machine with the specified hardware address. If
+// the machine is already alive, no steps are tried. ctx is passed to each
+// step's Waker.
+//
+// Wake returns a ChainResult describing each step which was tried. If no step
+// succeeds, a non-nil error is returned along with the ChainResult.
+func (c *Chain) Wake(ctx context.Context, target net.HardwareAddr) (*ChainResult, error) {
+	if c.Check == nil {
+		return nil, errors.New("wol: chain has no liveness check")
+	}
+
+	res := &ChainResult{}
+	if c.alive(ctx) {
+		return res, nil
+	}
+
+	for _, s := range c.Steps {
+		err := c.try(ctx, s, target)
+		res.Attempts = append(res.Attempts, StepAttempt{
+			Name: s.Name,
+			Err:  err,
+		})
+
+		if err == nil {
+			res.Step = s.Name
+			return res, nil
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return res, ctxErr
+		}
+	}
+
+	return res, fmt.Errorf("wol: all %d steps failed to wake %s", len(res.Attempts), target)
+}
+
+// try runs a single step and waits for the machine to become alive.
+func (c *Chain) try(ctx context.Context, s Step, target net.HardwareAddr) error {
+	if err := s.Waker.WakeContext(ctx, target); err != nil {
+		return err
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = defaultChainTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	t := time.NewTicker(c.interval())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ErrNotAlive
+		case <-t.C:
+		}
+
+		if c.alive(ctx) {
+			return nil
+		}
+	}
+}
+
+// alive runs c's Check, bounding its run time by c's interval so a single
+// slow check cannot consume a step's entire timeout.
+func (c *Chain) alive(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, c.interval())
+	defer cancel()
+
+	return c.Check(ctx) == nil
+}
+
+// interval returns the interval at which c runs its Check.
+func (c *Chain) interval() time.Duration {
+	if c.Interval == 0 {
+		return defaultChainInterval
+	}
+
+	return c.Interval
+}
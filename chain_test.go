@@ -0,0 +1,188 @@
+package wol
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestChainWake(t *testing.T) {
+	errWake := errors.New("wake failed")
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	var tests = []struct {
+		name  string
+		alive bool
+		// wakes reports whether the machine becomes alive after each step,
+		// and failing steps return errWake.
+		wakes []bool
+		fails []bool
+		want  *ChainResult
+		ok    bool
+	}{
+		{
+			name:  "already alive",
+			alive: true,
+			wakes: []bool{true, true},
+			fails: []bool{false, false},
+			want:  &ChainResult{},
+			ok:    true,
+		},
+		{
+			name:  "first step",
+			wakes: []bool{true, true},
+			fails: []bool{false, false},
+			want: &ChainResult{
+				Step:     "0",
+				Attempts: []StepAttempt{{Name: "0"}},
+			},
+			ok: true,
+		},
+		{
+			name:  "escalate",
+			wakes: []bool{false, false, true},
+			fails: []bool{true, false, false},
+			want: &ChainResult{
+				Step: "2",
+				Attempts: []StepAttempt{
+					{Name: "0", Err: errWake},
+					{Name: "1", Err: ErrNotAlive},
+					{Name: "2"},
+				},
+			},
+			ok: true,
+		},
+		{
+			name:  "all fail",
+			wakes: []bool{false, false},
+			fails: []bool{false, true},
+			want: &ChainResult{
+				Attempts: []StepAttempt{
+					{Name: "0", Err: ErrNotAlive},
+					{Name: "1", Err: errWake},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu    sync.Mutex
+				alive = tt.alive
+			)
+
+			c := &Chain{
+				Check: func(_ context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					if !alive {
+						return errors.New("not alive")
+					}
+					return nil
+				},
+				Interval: time.Millisecond,
+			}
+
+			for i := range tt.wakes {
+				i := i
+				c.Steps = append(c.Steps, Step{
+					Name:    string(rune('0' + i)),
+					Timeout: 20 * time.Millisecond,
+					Waker: WakerFunc(func(_ context.Context, got net.HardwareAddr) error {
+						if diff := cmp.Diff(target, got); diff != "" {
+							t.Fatalf("unexpected target (-want +got):\n%s", diff)
+						}
+						if tt.fails[i] {
+							return errWake
+						}
+
+						mu.Lock()
+						defer mu.Unlock()
+						alive = tt.wakes[i]
+						return nil
+					}),
+				})
+			}
+
+			res, err := c.Wake(context.Background(), target)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to wake: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if diff := cmp.Diff(tt.want, res, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChainWakeContext(t *testing.T) {
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "chain"))
+	defer cancel()
+
+	// The Waker receives the Chain's context, and canceling it stops the
+	// Chain rather than advancing to the next step.
+	var got []interface{}
+	c := &Chain{
+		Check:    func(_ context.Context) error { return errors.New("not alive") },
+		Interval: time.Millisecond,
+		Steps: []Step{
+			{
+				Name: "0",
+				Waker: WakerFunc(func(ctx context.Context, _ net.HardwareAddr) error {
+					got = append(got, ctx.Value(key{}))
+					cancel()
+					return nil
+				}),
+			},
+			{
+				Name: "1",
+				Waker: WakerFunc(func(ctx context.Context, _ net.HardwareAddr) error {
+					got = append(got, ctx.Value(key{}))
+					return nil
+				}),
+			},
+		},
+	}
+
+	res, err := c.Wake(ctx, net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff([]interface{}{"chain"}, got); diff != "" {
+		t.Fatalf("unexpected Waker contexts (-want +got):\n%s", diff)
+	}
+
+	if len(res.Attempts) != 1 || !errors.Is(res.Attempts[0].Err, ErrNotAlive) {
+		t.Fatalf("expected a single attempt which was not alive: %+v", res.Attempts)
+	}
+}
+
+func TestDialCheck(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	check := DialCheck("tcp", l.Addr().String())
+	if err := check(context.Background()); err != nil {
+		t.Fatalf("expected listener to be alive: %v", err)
+	}
+
+	_ = l.Close()
+	if err := check(context.Background()); err == nil {
+		t.Fatal("expected closed listener to not be alive")
+	}
+}
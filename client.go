@@ -1,13 +1,30 @@
 package wol
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net"
+	"time"
 )
 
 // A Client is a Wake-on-LAN client which utilizes a UDP socket.  It can be
 // used to send WoL magic packets to other machines using their network
 // address.
 type Client struct {
+	// Observer is optionally notified of each attempt to send a magic
+	// packet. It must be set before the Client is used.
+	Observer Observer
+
+	// Hooks are optionally called while sending each magic packet. They
+	// must be set before the Client is used.
+	Hooks *Hooks
+
+	// Logger optionally logs each attempt to send a magic packet: successes
+	// at debug level and failures at warning level. It must be set before
+	// the Client is used.
+	Logger *slog.Logger
+
 	p net.PacketConn
 }
 
@@ -20,9 +37,19 @@ func NewClient() (*Client, error) {
 		return nil, err
 	}
 
+	return NewClientConn(p), nil
+}
+
+// NewClientConn creates a new Client which sends Wake-on-LAN magic packets
+// using an existing net.PacketConn, such as a UDP socket bound to a specific
+// local address. NewClientConn is also useful for testing, in combination with
+// package woltest.
+//
+// Closing the Client closes p.
+func NewClientConn(p net.PacketConn) *Client {
 	return &Client{
 		p: p,
-	}, nil
+	}
 }
 
 // Close closes a Client's UDP socket.
@@ -48,6 +75,18 @@ func (c *Client) Close() error {
 //
 // If there is no IPv4 subnet available on your target VLAN, add a small dummy
 // subnet or use a RawClient for sending raw Ethernet frames.
+//
+// addr may be any of the following, optionally followed by a port such as
+// ":7". If no port is specified, port 9 is used.
+//   - an IP address, such as 10.0.0.255 or [ff02::1%eth0]
+//   - an IPv4 prefix, such as 10.0.0.0/24, which is sent to the subnet's
+//     directed broadcast address
+//   - a network interface name, such as eth0, which is sent to the broadcast
+//     address of each IPv4 subnet configured on the interface
+//   - a hostname, which is sent to every address it resolves to
+//
+// When addr refers to multiple addresses, a magic packet is sent to each and
+// any errors are joined.
 func (c *Client) Wake(addr string, target net.HardwareAddr) error {
 	return c.WakePassword(addr, target, nil)
 }
@@ -57,13 +96,80 @@ func (c *Client) Wake(addr string, target net.HardwareAddr) error {
 //
 // The password must be exactly 0 (empty), 4, or 6 bytes in length.
 func (c *Client) WakePassword(addr string, target net.HardwareAddr, password []byte) error {
-	return c.sendWake(addr, target, password)
+	return c.send(context.Background(), addr, target, password, false)
+}
+
+// WakeContext is like Wake, but does not send a magic packet if ctx is done.
+// ctx is also used to resolve hostnames, and is passed to c's Hooks.
+func (c *Client) WakeContext(ctx context.Context, addr string, target net.HardwareAddr) error {
+	return c.WakePasswordContext(ctx, addr, target, nil)
+}
+
+// WakePasswordContext is like WakePassword, but does not send a magic packet
+// if ctx is done. ctx is also used to resolve hostnames, and is passed to c's
+// Hooks.
+func (c *Client) WakePasswordContext(ctx context.Context, addr string, target net.HardwareAddr, password []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.send(ctx, addr, target, password, false)
+}
+
+// Sleep sends a Sleep-on-LAN packet to an IP address for the specified
+// hardware address.
+//
+// A Sleep-on-LAN packet is a magic packet whose target hardware address has
+// its bytes reversed. Machines must run an agent which listens for these
+// packets, such as the one provided by package sleep; Wake-on-LAN hardware
+// will ignore them.
+func (c *Client) Sleep(addr string, target net.HardwareAddr) error {
+	return c.SleepPassword(addr, target, nil)
+}
+
+// SleepPassword sends a Sleep-on-LAN packet to an IP address for the
+// specified hardware address, using the specified password.
+//
+// The password must be exactly 0 (empty), 4, or 6 bytes in length.
+func (c *Client) SleepPassword(addr string, target net.HardwareAddr, password []byte) error {
+	return c.send(context.Background(), addr, target, password, true)
+}
+
+// send sends a Wake-on-LAN or Sleep-on-LAN packet, calling c's hooks and
+// notifying its Logger and Observer, if any.
+func (c *Client) send(ctx context.Context, addr string, target net.HardwareAddr, password []byte, sleep bool) error {
+	mpTarget := target
+	if sleep {
+		mpTarget = SleepTarget(target)
+	}
+
+	s := sender{o: c.Observer, h: c.Hooks, l: c.Logger}
+	if !s.enabled() {
+		return c.sendWake(ctx, addr, mpTarget, password)
+	}
+
+	info := &SendInfo{
+		Start:       time.Now(),
+		Transport:   TransportUDP,
+		Destination: addr,
+		Target:      target,
+		Sleep:       sleep,
+		Password:    len(password) > 0,
+	}
+	if laddr := c.p.LocalAddr(); laddr != nil {
+		info.Source = laddr.String()
+	}
+
+	s.begin(ctx, info)
+	err := c.sendWake(ctx, addr, mpTarget, password)
+	s.end(ctx, info, err)
+	return err
 }
 
 // sendWake crafts a magic packet using the input parameters and sends the
 // packet over a UDP socket to attempt to wake a machine.
-func (c *Client) sendWake(addr string, target net.HardwareAddr, password []byte) error {
-	uaddr, err := net.ResolveUDPAddr("udp", addr)
+func (c *Client) sendWake(ctx context.Context, addr string, target net.HardwareAddr, password []byte) error {
+	uaddrs, err := resolveUDP(ctx, addr)
 	if err != nil {
 		return err
 	}
@@ -78,7 +184,13 @@ func (c *Client) sendWake(addr string, target net.HardwareAddr, password []byte)
 		return err
 	}
 
-	// Send magic packet to target over UDP socket.
-	_, err = c.p.WriteTo(mpb, uaddr)
-	return err
+	// Send magic packet to target over UDP socket, once per address.
+	var errs []error
+	for _, uaddr := range uaddrs {
+		if _, err := c.p.WriteTo(mpb, uaddr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
@@ -1,6 +1,7 @@
 package wol
 
 import (
+	"context"
 	"net"
 	"testing"
 
@@ -89,3 +90,26 @@ func TestClientWakePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestClientWaker(t *testing.T) {
+	p := &writeToPacketConn{}
+	c := &Client{
+		p: p,
+	}
+
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	var w Waker = c.Waker("127.0.0.1:9")
+	if err := w.WakeContext(context.Background(), target); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+
+	mp := new(MagicPacket)
+	if err := mp.UnmarshalBinary(p.b); err != nil {
+		t.Fatalf("failed to unmarshal MagicPacket: %v", err)
+	}
+
+	if diff := cmp.Diff(target, mp.Target); diff != "" {
+		t.Fatalf("unexpected target (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mdlayher/wol/inventory"
+	"github.com/mdlayher/wol/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// hostsEnv is an environment variable which overrides the default inventory
+// file path.
+const hostsEnv = "WOL_HOSTS"
+
+// jsonFlag registers the common -json flag on fs.
+func jsonFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("json", false, "produce machine-readable JSON output")
+}
+
+// hostsFlag registers the common -hosts flag on fs.
+func hostsFlag(fs *flag.FlagSet) *string {
+	return fs.String("hosts", "", "inventory file of named hosts (default $"+hostsEnv+" or "+inventory.DefaultPath+")")
+}
+
+// metricsFlag registers the common -metrics flag on fs.
+func metricsFlag(fs *flag.FlagSet) *string {
+	return fs.String("metrics", "", "optional address to serve Prometheus metrics on at /metrics, such as :9101")
+}
+
+// A listFlag is a flag which may be repeated, and whose values may also be
+// comma-separated.
+type listFlag []string
+
+func (f *listFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *listFlag) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+
+	return nil
+}
+
+// serveMetrics serves Prometheus metrics over HTTP on addr until ctx is
+// canceled. If addr is empty, serveMetrics returns nil Metrics, which record
+// nothing.
+func serveMetrics(ctx context.Context, addr string) (*metrics.Metrics, error) {
+	if addr == "" {
+		return nil, nil
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m := metrics.New(reg)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(reg))
+	srv := &http.Server{Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	go func() { _ = srv.Serve(l) }()
+
+	return m, nil
+}
+
+// loadInventory loads the inventory file at path. If path is empty, the
+// default path is used, and a missing default file produces an empty
+// inventory rather than an error.
+//
+// An explicit inventory file must be entirely valid. The default file is
+// shared with other programs which use ethers(5), so its invalid lines are
+// reported on stderr and skipped.
+func loadInventory(path string) (*inventory.Inventory, error) {
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(hostsEnv)
+		explicit = path != ""
+	}
+	if explicit {
+		return inventory.Open(path)
+	}
+
+	inv, skipped, err := inventory.OpenLenient(inventory.DefaultPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &inventory.Inventory{}, nil
+		}
+
+		return nil, err
+	}
+
+	for _, perr := range skipped {
+		fmt.Fprintf(os.Stderr, "wol: skipping invalid line %d of %s: %v\n", perr.Line, inventory.DefaultPath, perr.Err)
+	}
+
+	return inv, nil
+}
+
+// resolveHost resolves s as either a hardware address or the name of a host
+// in inv. Hardware addresses are matched against inv to find any options for
+// that host.
+func resolveHost(inv *inventory.Inventory, s string) (*inventory.Host, error) {
+	if mac, err := net.ParseMAC(s); err == nil {
+		if h, ok := inv.LookupMAC(mac); ok {
+			return h, nil
+		}
+
+		return &inventory.Host{MAC: mac}, nil
+	}
+
+	if h, ok := inv.Lookup(s); ok {
+		return h, nil
+	}
+
+	return nil, usagef("%q is neither a hardware address nor a known host", s)
+}
+
+// printJSON writes v to stdout as a single line of JSON.
+func printJSON(v interface{}) error {
+	return json.NewEncoder(os.Stdout).Encode(v)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var completionCommand = &command{
+	name:  "completion",
+	short: "generate a shell completion script for bash, zsh, or fish",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		fs.Usage = func() {
+			fmt.Fprintln(fs.Output(), "Usage: wol completion bash|zsh|fish")
+			fmt.Fprintln(fs.Output())
+			fmt.Fprintln(fs.Output(), "For example, add this to ~/.bashrc:")
+			fmt.Fprintln(fs.Output())
+			fmt.Fprintln(fs.Output(), `  eval "$(wol completion bash)"`)
+		}
+
+		return func(args []string) error {
+			if len(args) != 1 {
+				return usagef("must specify exactly one shell")
+			}
+
+			switch args[0] {
+			case "bash":
+				writeBash(os.Stdout, false)
+			case "zsh":
+				writeBash(os.Stdout, true)
+			case "fish":
+				writeFish(os.Stdout)
+			default:
+				return usagef("unsupported shell %q", args[0])
+			}
+
+			return nil
+		}
+	},
+}
+
+// commandFlags returns the flags registered by c.
+func commandFlags(c *command) []*flag.Flag {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	_ = c.setup(fs)
+
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+
+	return flags
+}
+
+// writeBash writes a bash completion script to w. zsh uses the same script
+// through its bash compatibility layer.
+func writeBash(w io.Writer, zsh bool) {
+	names := make([]string, 0, len(commands))
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+
+	if zsh {
+		fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+	}
+
+	fmt.Fprintln(w, "_wol() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `	if [ "$COMP_CWORD" -eq 1 ]; then`)
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, `	case "$prev" in`)
+	fmt.Fprintln(w, `	-t) COMPREPLY=($(compgen -W "$(wol hosts -q 2>/dev/null)" -- "$cur")); return ;;`)
+	fmt.Fprintln(w, `	-i) COMPREPLY=($(compgen -W "$(ls /sys/class/net 2>/dev/null)" -- "$cur")); return ;;`)
+	fmt.Fprintln(w, `	-hosts) COMPREPLY=($(compgen -f -- "$cur")); return ;;`)
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, `	case "${COMP_WORDS[1]}" in`)
+	for _, c := range commands {
+		var flags []string
+		for _, f := range commandFlags(c) {
+			flags = append(flags, "-"+f.Name)
+		}
+		if c.name == "completion" {
+			flags = append(flags, "bash", "zsh", "fish")
+		}
+
+		fmt.Fprintf(w, "\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", c.name, strings.Join(flags, " "))
+	}
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _wol wol")
+}
+
+// writeFish writes a fish completion script to w.
+func writeFish(w io.Writer) {
+	fmt.Fprintln(w, "complete -c wol -f")
+	for _, c := range commands {
+		fmt.Fprintf(w, "complete -c wol -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.short))
+	}
+
+	for _, c := range commands {
+		cond := "'__fish_seen_subcommand_from " + c.name + "'"
+		for _, f := range commandFlags(c) {
+			fmt.Fprintf(w, "complete -c wol -n %s -o %s -d %s\n", cond, f.Name, fishQuote(f.Usage))
+		}
+	}
+
+	fmt.Fprintln(w, `complete -c wol -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'`)
+	fmt.Fprintln(w, `complete -c wol -n '__fish_seen_subcommand_from send' -o t -x -a '(wol hosts -q 2>/dev/null)'`)
+}
+
+// fishQuote quotes s for use as a single fish argument.
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
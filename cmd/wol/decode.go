@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mdlayher/wol"
+)
+
+var decodeCommand = &command{
+	name:  "decode",
+	short: "decode a hex-encoded Wake-on-LAN magic packet",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		asJSON := jsonFlag(fs)
+
+		fs.Usage = func() {
+			fmt.Fprintln(fs.Output(), "Usage: wol decode [flags] [hex...]")
+			fmt.Fprintln(fs.Output())
+			fmt.Fprintln(fs.Output(), "Decodes a magic packet from hex arguments, or from standard input if none")
+			fmt.Fprintln(fs.Output(), "are given. Whitespace, ':', and '-' separators are ignored.")
+			fmt.Fprintln(fs.Output())
+			fs.PrintDefaults()
+		}
+
+		return func(args []string) error {
+			s := strings.Join(args, "")
+			if len(args) == 0 {
+				var sb strings.Builder
+				sc := bufio.NewScanner(os.Stdin)
+				for sc.Scan() {
+					sb.WriteString(sc.Text())
+				}
+				if err := sc.Err(); err != nil {
+					return err
+				}
+
+				s = sb.String()
+			}
+
+			b, err := decodeHex(s)
+			if err != nil {
+				return usagef("invalid hex input: %v", err)
+			}
+
+			r := &decodeResult{Length: len(b)}
+
+			p := new(wol.MagicPacket)
+			if err := p.UnmarshalBinary(b); err != nil {
+				r.Error = err.Error()
+			} else {
+				r.Target = p.Target.String()
+				if len(p.Password) > 0 {
+					r.Password = hex.EncodeToString(p.Password)
+				}
+			}
+
+			if *asJSON {
+				if err := printJSON(r); err != nil {
+					return err
+				}
+			} else if r.Error == "" {
+				fmt.Printf("target: %s\n", r.Target)
+				if r.Password != "" {
+					fmt.Printf("password: %s\n", r.Password)
+				}
+			}
+
+			switch {
+			case r.Error == "":
+				return nil
+			case *asJSON:
+				return errSilent
+			default:
+				return fmt.Errorf("invalid magic packet (%d bytes): %s", r.Length, r.Error)
+			}
+		}
+	},
+}
+
+// A decodeResult is the outcome of decoding a magic packet.
+type decodeResult struct {
+	Length   int    `json:"length"`
+	Target   string `json:"target,omitempty"`
+	Password string `json:"password,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// decodeHex decodes s as hex, ignoring whitespace and common separators.
+func decodeHex(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', ':', '-':
+			return -1
+		}
+
+		return r
+	}, strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+
+	return hex.DecodeString(s)
+}
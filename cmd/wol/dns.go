@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/mdlayher/wol/dnswake"
+	"github.com/mdlayher/wol/tracker"
+)
+
+var dnsCommand = &command{
+	name:  "dns",
+	short: "forward DNS queries, waking hosts when their names are looked up",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			listen   = fs.String("listen", ":53", "address to serve DNS on over UDP and TCP")
+			upstream = fs.String("upstream", "", "address of the upstream DNS resolver, such as 192.168.1.1:53")
+			domain   = fs.String("domain", "", "optional local domain removed from names before matching hosts, such as lan")
+			hosts    = hostsFlag(fs)
+		)
+
+		return func(_ []string) error {
+			if *upstream == "" {
+				return usagef("must set '-upstream' flag")
+			}
+
+			inv, err := loadInventory(*hosts)
+			if err != nil {
+				return err
+			}
+
+			pc, err := net.ListenPacket("udp", *listen)
+			if err != nil {
+				return err
+			}
+
+			// Clients retry truncated UDP responses over TCP.
+			ln, err := net.Listen("tcp", *listen)
+			if err != nil {
+				_ = pc.Close()
+				return err
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			f := &dnswake.Forwarder{
+				Upstream:  *upstream,
+				Inventory: inv,
+				Domain:    *domain,
+				Check:     tracker.DefaultProbe,
+				Logger:    slog.New(slog.NewTextHandler(os.Stderr, nil)),
+			}
+
+			// Stop serving on either transport if the other fails.
+			ctx, cancel = context.WithCancel(ctx)
+			defer cancel()
+
+			errC := make(chan error, 2)
+			go func() { errC <- f.Serve(ctx, pc) }()
+			go func() { errC <- f.ServeTCP(ctx, ln) }()
+
+			var errs []error
+			for i := 0; i < 2; i++ {
+				if err := <-errC; err != nil {
+					errs = append(errs, err)
+				}
+				cancel()
+			}
+
+			return errors.Join(errs...)
+		}
+	},
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/nic"
+)
+
+// Possible check statuses.
+const (
+	statusOK   = "ok"
+	statusWarn = "warn"
+	statusFail = "fail"
+)
+
+var doctorCommand = &command{
+	name:  "doctor",
+	short: "diagnose common Wake-on-LAN configuration problems",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			iface  = fs.String("i", "", "only check the specified network interface")
+			hosts  = hostsFlag(fs)
+			asJSON = jsonFlag(fs)
+		)
+
+		return func(_ []string) error {
+			checks := diagnose(*iface, *hosts)
+
+			if *asJSON {
+				if err := printJSON(checks); err != nil {
+					return err
+				}
+			} else {
+				tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				for _, c := range checks {
+					fmt.Fprintf(tw, "[%s]\t%s\t%s\n", c.Status, c.Name, c.Detail)
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+			}
+
+			for _, c := range checks {
+				if c.Status == statusFail {
+					return errSilent
+				}
+			}
+
+			return nil
+		}
+	},
+}
+
+// A checkResult is the outcome of a single diagnostic check.
+type checkResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// diagnose runs all diagnostic checks. If iface is set, only that network
+// interface is checked.
+func diagnose(iface, hosts string) []checkResult {
+	var checks []checkResult
+	add := func(name, status, format string, v ...interface{}) {
+		checks = append(checks, checkResult{
+			Name:   name,
+			Status: status,
+			Detail: fmt.Sprintf(format, v...),
+		})
+	}
+
+	if inv, err := loadInventory(hosts); err != nil {
+		add("inventory", statusFail, "%v", err)
+	} else {
+		add("inventory", statusOK, "%d hosts", len(inv.Hosts))
+	}
+
+	if c, err := wol.NewClient(); err != nil {
+		add("udp", statusFail, "cannot open UDP socket: %v", err)
+	} else {
+		_ = c.Close()
+		add("udp", statusOK, "UDP sockets available")
+	}
+
+	ifis, err := candidateInterfaces(iface)
+	if err != nil {
+		add("interfaces", statusFail, "%v", err)
+		return checks
+	}
+	if len(ifis) == 0 {
+		add("interfaces", statusFail, "no up, broadcast-capable Ethernet interfaces found")
+		return checks
+	}
+
+	names := make([]string, 0, len(ifis))
+	for _, ifi := range ifis {
+		names = append(names, ifi.Name)
+	}
+	add("interfaces", statusOK, "%s", strings.Join(names, ", "))
+
+	for _, ifi := range ifis {
+		// IPv4 directed broadcast is the most reliable way to send magic
+		// packets over UDP.
+		bcast, err := wol.InterfaceBroadcasts(ifi)
+		switch {
+		case err != nil:
+			add("broadcast/"+ifi.Name, statusWarn, "failed to list addresses: %v", err)
+		case len(bcast) == 0:
+			add("broadcast/"+ifi.Name, statusWarn, "no IPv4 subnets; UDP magic packets cannot be broadcast on this interface")
+		default:
+			strs := make([]string, 0, len(bcast))
+			for _, ip := range bcast {
+				strs = append(strs, ip.String())
+			}
+
+			add("broadcast/"+ifi.Name, statusOK, "%s", strings.Join(strs, ", "))
+		}
+	}
+
+	// Raw sockets require the same privileges on every interface, so only
+	// check the first.
+	if c, err := wol.NewRawClient(ifis[0]); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			add("raw", statusWarn, "raw Ethernet sockets require root or CAP_NET_RAW; use UDP instead")
+		} else {
+			add("raw", statusWarn, "cannot open raw Ethernet socket: %v", err)
+		}
+	} else {
+		_ = c.Close()
+		add("raw", statusOK, "raw Ethernet sockets available")
+	}
+
+	if runtime.GOOS != "linux" {
+		return checks
+	}
+
+	// Check whether the local NICs will wake this machine, so it can be
+	// woken after it goes to sleep.
+	c, err := nic.Dial()
+	if err != nil {
+		add("nic", statusWarn, "cannot query NIC Wake-on-LAN settings: %v", err)
+		return checks
+	}
+	defer c.Close()
+
+	for _, ifi := range ifis {
+		name := "nic/" + ifi.Name
+		w, err := c.WakeOnLAN(ifi)
+		switch {
+		case err != nil:
+			add(name, statusWarn, "cannot query Wake-on-LAN settings: %v", err)
+		case w.Supported&nic.ModeMagic == 0:
+			add(name, statusWarn, "NIC does not support waking on magic packets")
+		case w.Enabled&nic.ModeMagic == 0:
+			add(name, statusWarn, "waking on magic packets is disabled (enabled: %s); enable with: ethtool -s %s wol g", w.Enabled, ifi.Name)
+		default:
+			add(name, statusOK, "enabled: %s", w.Enabled)
+		}
+	}
+
+	return checks
+}
+
+// candidateInterfaces returns the network interfaces which can send magic
+// packets. If name is set, only that interface is returned.
+func candidateInterfaces(name string) ([]*net.Interface, error) {
+	if name != "" {
+		ifi, err := net.InterfaceByName(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*net.Interface{ifi}, nil
+	}
+
+	ifis, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*net.Interface
+	for i := range ifis {
+		ifi := &ifis[i]
+		if ifi.Flags&net.FlagUp == 0 || ifi.Flags&net.FlagBroadcast == 0 ||
+			ifi.Flags&net.FlagLoopback != 0 || len(ifi.HardwareAddr) != 6 {
+			continue
+		}
+
+		out = append(out, ifi)
+	}
+
+	return out, nil
+}
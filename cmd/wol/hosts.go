@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+var hostsCommand = &command{
+	name:  "hosts",
+	short: "list hosts in the inventory",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			hosts  = hostsFlag(fs)
+			names  = fs.Bool("q", false, "only print host names")
+			asJSON = jsonFlag(fs)
+		)
+
+		return func(_ []string) error {
+			inv, err := loadInventory(*hosts)
+			if err != nil {
+				return err
+			}
+
+			out := make([]hostInfo, 0, len(inv.Hosts))
+			for _, h := range inv.Hosts {
+				hi := hostInfo{
+					Name:      h.Name,
+					MAC:       h.MAC.String(),
+					Addr:      h.Addr,
+					Interface: h.Interface,
+					Dest:      h.Dest,
+					Password:  len(h.Password) > 0,
+					Probe:     h.Probe,
+				}
+				if h.IP != nil {
+					hi.IP = h.IP.String()
+				}
+
+				out = append(out, hi)
+			}
+
+			switch {
+			case *asJSON:
+				return printJSON(out)
+			case *names:
+				for _, h := range out {
+					fmt.Println(h.Name)
+				}
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tMAC\tADDR\tIFACE\tDEST\tIP\tPROBE\tPASSWORD")
+			for _, h := range out {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					h.Name, h.MAC, dash(h.Addr), dash(h.Interface), dash(h.Dest), dash(h.IP), dash(h.Probe), yesNo(h.Password))
+			}
+
+			return tw.Flush()
+		}
+	},
+}
+
+// hostInfo is the output format for a single inventory host.
+type hostInfo struct {
+	Name      string `json:"name"`
+	MAC       string `json:"mac"`
+	Addr      string `json:"addr,omitempty"`
+	Interface string `json:"iface,omitempty"`
+	Dest      string `json:"dest,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	Probe     string `json:"probe,omitempty"`
+	Password  bool   `json:"password"`
+}
+
+// dash returns s, or "-" if s is empty.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// yesNo returns "yes" or "no" for b.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/packet"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"github.com/mdlayher/wol/metrics"
+	"github.com/mdlayher/wol/sniff"
+)
+
+// defaultListenAddrs are the UDP addresses used by listen when neither an
+// address nor an interface is specified. Magic packets are conventionally
+// sent to the echo (7) or discard (9) ports. Port 0 is also used by some
+// senders, but cannot be bound using the sockets API; use -i to capture
+// magic packets sent to any port on an interface instead.
+const defaultListenAddrs = ":7,:9"
+
+var listenCommand = &command{
+	name:  "listen",
+	short: "print incoming Wake-on-LAN magic packets",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			addrs       = fs.String("a", "", "comma-separated UDP addresses to listen on (default "+defaultListenAddrs+" unless -i is set)")
+			iface       = fs.String("i", "", "network interface on which to capture magic packets sent over Ethernet or to any UDP port, including port 0")
+			target      = fs.String("t", "", "only print magic packets for this hardware address or inventory host name")
+			hosts       = hostsFlag(fs)
+			metricsAddr = metricsFlag(fs)
+			asJSON      = jsonFlag(fs)
+		)
+
+		return func(_ []string) error {
+			inv, err := loadInventory(*hosts)
+			if err != nil {
+				return err
+			}
+
+			var filter net.HardwareAddr
+			if *target != "" {
+				h, err := resolveHost(inv, *target)
+				if err != nil {
+					return err
+				}
+				filter = h.MAC
+			}
+
+			if *addrs == "" && *iface == "" {
+				*addrs = defaultListenAddrs
+			}
+
+			ls, err := openListeners(*addrs)
+			if err != nil {
+				return err
+			}
+
+			if *iface != "" {
+				l, err := openSniffer(*iface)
+				if err != nil {
+					for _, l := range ls {
+						_ = l.Close()
+					}
+					return err
+				}
+
+				ls = append(ls, l)
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			m, err := serveMetrics(ctx, *metricsAddr)
+			if err != nil {
+				for _, l := range ls {
+					_ = l.Close()
+				}
+				return err
+			}
+
+			var (
+				mu   sync.Mutex
+				werr error
+			)
+			emit := func(e *packetEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if werr != nil {
+					return
+				}
+
+				if *asJSON {
+					werr = printJSON(e)
+					return
+				}
+
+				_, werr = fmt.Println(e)
+			}
+
+			errC := make(chan error, len(ls))
+			var wg sync.WaitGroup
+			wg.Add(len(ls))
+			for _, l := range ls {
+				go func(l *transportListener) {
+					defer wg.Done()
+					errC <- l.serve(inv, filter, m, emit)
+				}(l)
+			}
+
+			// Stop on interrupt or the first listener failure.
+			select {
+			case <-ctx.Done():
+			case err = <-errC:
+			}
+
+			for _, l := range ls {
+				_ = l.Close()
+			}
+			wg.Wait()
+
+			if err != nil {
+				return err
+			}
+
+			return werr
+		}
+	},
+}
+
+// A transportListener is a wol.Listener, the transport it uses, a
+// description of its local address, and its network interface, if known.
+// Sniffing transportListeners capture magic packets using a sniff.Sniffer
+// instead of a wol.Listener.
+type transportListener struct {
+	*wol.Listener
+	sniffer   *sniff.Sniffer
+	transport string
+	local     string
+	ifi       string
+}
+
+// openSniffer opens a transportListener which captures magic packets sent
+// over Ethernet or to any UDP port on iface.
+func openSniffer(iface string) (*transportListener, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := sniff.Listen(ifi, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transportListener{
+		sniffer: s,
+		local:   ifi.Name,
+		ifi:     ifi.Name,
+	}, nil
+}
+
+// Close closes l's Listener or Sniffer.
+func (l *transportListener) Close() error {
+	if l.sniffer != nil {
+		return l.sniffer.Close()
+	}
+
+	return l.Listener.Close()
+}
+
+// receive receives a magic packet, along with the transport and source
+// address it was received with.
+func (l *transportListener) receive() (*wol.MagicPacket, string, net.Addr, error) {
+	if l.sniffer == nil {
+		p, src, err := l.Receive()
+		return p, l.transport, src, err
+	}
+
+	c, err := l.sniffer.Capture()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if c.UDPSource != nil {
+		return c.Packet, wol.TransportUDP, c.UDPSource, nil
+	}
+
+	return c.Packet, wol.TransportRaw, &packet.Addr{HardwareAddr: c.Source}, nil
+}
+
+// openListeners opens a Listener for each comma-separated UDP address in
+// addrs.
+func openListeners(addrs string) ([]*transportListener, error) {
+	if addrs == "" {
+		return nil, nil
+	}
+
+	var ls []*transportListener
+	for _, a := range strings.Split(addrs, ",") {
+		l, err := wol.Listen(strings.TrimSpace(a))
+		if err != nil {
+			for _, l := range ls {
+				_ = l.Close()
+			}
+			return nil, err
+		}
+
+		ls = append(ls, &transportListener{
+			Listener:  l,
+			transport: "udp",
+			local:     l.Addr().String(),
+		})
+	}
+
+	return ls, nil
+}
+
+// serve receives magic packets until l is closed, passing those which match
+// filter to fn and counting them in m. If filter is nil, all magic packets
+// are passed to fn.
+func (l *transportListener) serve(inv *inventory.Inventory, filter net.HardwareAddr, m *metrics.Metrics, fn func(e *packetEvent)) error {
+	for {
+		p, transport, src, err := l.receive()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		m.Received(transport, l.ifi)
+		if filter != nil && !bytes.Equal(p.Target, filter) {
+			m.Rejected(transport, l.ifi, "filtered")
+			continue
+		}
+
+		e := &packetEvent{
+			Time:      time.Now(),
+			Transport: transport,
+			Local:     l.local,
+			Source:    src.String(),
+			Target:    p.Target.String(),
+			Password:  len(p.Password) > 0,
+		}
+		if h, ok := inv.LookupMAC(p.Target); ok {
+			e.Name = h.Name
+		}
+
+		fn(e)
+	}
+}
+
+// A packetEvent describes a received magic packet.
+type packetEvent struct {
+	Time      time.Time `json:"time"`
+	Transport string    `json:"transport"`
+	Local     string    `json:"local"`
+	Source    string    `json:"source"`
+	Target    string    `json:"target"`
+	Name      string    `json:"name,omitempty"`
+	Password  bool      `json:"password"`
+}
+
+// String returns a human-readable description of e.
+func (e *packetEvent) String() string {
+	target := e.Target
+	if e.Name != "" {
+		target = fmt.Sprintf("%s (%s)", e.Name, e.Target)
+	}
+
+	s := fmt.Sprintf("%s %s %s: %s -> %s",
+		e.Time.Format(time.RFC3339), e.Transport, e.Local, e.Source, target)
+	if e.Password {
+		s += " with password"
+	}
+
+	return s
+}
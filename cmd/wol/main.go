@@ -1,79 +1,161 @@
-// Command wol is a simple Wake-on-LAN client.
+// Command wol is a Wake-on-LAN client and troubleshooting tool.
 package main
 
 import (
+	"errors"
 	"flag"
-	"log"
-	"net"
-
-	"github.com/mdlayher/wol"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
 )
 
-var (
-	addrFlag     = flag.String("a", "", "network address for Wake-on-LAN magic packet")
-	ifaceFlag    = flag.String("i", "", "network interface to use to send Wake-on-LAN magic packet")
-	targetFlag   = flag.String("t", "", "target for Wake-on-LAN magic packet")
-	passwordFlag = flag.String("p", "", "optional password for Wake-on-LAN magic packet")
+// Exit codes returned by wol.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
 )
 
+// A command is a wol subcommand.
+type command struct {
+	// name and short are the command's name and a one line description.
+	name  string
+	short string
+
+	// setup registers the command's flags on fs, and returns a function
+	// which runs the command with the remaining non-flag arguments.
+	setup func(fs *flag.FlagSet) func(args []string) error
+}
+
+// commands is the list of all subcommands, populated during initialization
+// to allow the completion command to refer to it.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		sendCommand,
+		listenCommand,
+		decodeCommand,
+		hostsCommand,
+		statusCommand,
+		trackCommand,
+		proxyCommand,
+		dnsCommand,
+		sleepProxyCommand,
+		vmwakeCommand,
+		doctorCommand,
+		mqttCommand,
+		completionCommand,
+	}
+}
+
+// A usageError indicates that a command was invoked incorrectly.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+
+// usagef creates a usageError with a formatted message.
+func usagef(format string, v ...interface{}) error {
+	return &usageError{err: fmt.Errorf(format, v...)}
+}
+
+// errSilent indicates that a command failed but has already reported why,
+// so wol should exit with a failure code without printing anything further.
+var errSilent = errors.New("command failed")
+
 func main() {
-	flag.Parse()
+	os.Exit(run(os.Args[1:]))
+}
 
-	target, err := net.ParseMAC(*targetFlag)
-	if err != nil {
-		log.Fatal(err)
+// run runs wol with the specified arguments and returns its exit code.
+func run(args []string) int {
+	// For compatibility, a leading flag implies the send command, as in
+	// "wol -a 192.168.1.255:9 -t 00:12:7f:eb:6b:40".
+	if len(args) > 0 && strings.HasPrefix(args[0], "-") && !isHelp(args[0]) {
+		args = append([]string{"send"}, args...)
 	}
 
-	// Set password if one is present.
-	var password []byte
-	if *passwordFlag != "" {
-		password = []byte(*passwordFlag)
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return exitUsage
+	}
+	if isHelp(args[0]) || args[0] == "help" {
+		usage(os.Stdout)
+		return exitOK
 	}
 
-	// Can only do raw or UDP mode, not both.
-	if *addrFlag != "" && *ifaceFlag != "" {
-		log.Fatalf("must set '-a' or '-i' flag exclusively")
+	c := lookupCommand(args[0])
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "wol: unknown command %q\n\n", args[0])
+		usage(os.Stderr)
+		return exitUsage
 	}
 
-	if *ifaceFlag != "" {
-		if err := wakeRaw(*ifaceFlag, target, password); err != nil {
-			log.Fatal(err)
+	fs := flag.NewFlagSet("wol "+c.name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fn := c.setup(fs)
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
 		}
 
-		log.Printf("sent raw Wake-on-LAN magic packet using %s to %s", *ifaceFlag, *targetFlag)
-		return
+		return exitUsage
 	}
 
-	if err := wakeUDP(*addrFlag, target, password); err != nil {
-		log.Fatal(err)
+	err := fn(fs.Args())
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errSilent):
+		return exitFailure
 	}
 
-	log.Printf("sent UDP Wake-on-LAN magic packet using %s to %s", *addrFlag, *targetFlag)
-}
+	fmt.Fprintf(os.Stderr, "wol %s: %v\n", c.name, err)
 
-func wakeRaw(iface string, target net.HardwareAddr, password []byte) error {
-	ifi, err := net.InterfaceByName(*ifaceFlag)
-	if err != nil {
-		return err
+	var uerr *usageError
+	if errors.As(err, &uerr) {
+		fs.Usage()
+		return exitUsage
 	}
 
-	c, err := wol.NewRawClient(ifi)
-	if err != nil {
-		return err
+	return exitFailure
+}
+
+// lookupCommand finds a command by name.
+func lookupCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
 	}
-	defer c.Close()
 
-	// Attempt to wake target machine.
-	return c.WakePassword(target, password)
+	return nil
 }
 
-func wakeUDP(addr string, target net.HardwareAddr, password []byte) error {
-	c, err := wol.NewClient()
-	if err != nil {
-		return err
+// isHelp reports whether s is a request for help.
+func isHelp(s string) bool {
+	return s == "-h" || s == "-help" || s == "--help"
+}
+
+// usage prints top-level usage information to w.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: wol <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.short)
 	}
-	defer c.Close()
+	_ = tw.Flush()
 
-	// Attempt to wake target machine.
-	return c.WakePassword(addr, target, password)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "wol <command> -h" for help with a command.`)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Exit codes: 0 on success, 1 on failure, and 2 on invalid usage.")
 }
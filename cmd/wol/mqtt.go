@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+
+	"github.com/mdlayher/wol/mqtt"
+)
+
+// mqttPasswordEnv is an environment variable which specifies the MQTT
+// password, to avoid exposing it in the process list.
+const mqttPasswordEnv = "WOL_MQTT_PASSWORD"
+
+var mqttCommand = &command{
+	name:  "mqtt",
+	short: "wake hosts in response to MQTT messages",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			broker      = fs.String("broker", "tcp://localhost:1883", "URL of the MQTT broker")
+			clientID    = fs.String("id", "wol-bridge", "MQTT client ID")
+			username    = fs.String("u", "", "optional MQTT username; the password is read from $"+mqttPasswordEnv)
+			prefix      = fs.String("prefix", "wol", "prefix for MQTT topics")
+			discovery   = fs.String("discovery", "", "optional Home Assistant discovery prefix, such as homeassistant")
+			hosts       = hostsFlag(fs)
+			metricsAddr = metricsFlag(fs)
+		)
+
+		return func(_ []string) error {
+			inv, err := loadInventory(*hosts)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			m, err := serveMetrics(ctx, *metricsAddr)
+			if err != nil {
+				return err
+			}
+
+			b := &mqtt.Bridge{
+				Broker:          *broker,
+				ClientID:        *clientID,
+				Username:        *username,
+				Password:        os.Getenv(mqttPasswordEnv),
+				Prefix:          *prefix,
+				DiscoveryPrefix: *discovery,
+				Inventory:       inv,
+				Metrics:         m,
+			}
+
+			return b.Run(ctx)
+		}
+	},
+}
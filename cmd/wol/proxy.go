@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"github.com/mdlayher/wol/proxy"
+)
+
+var proxyCommand = &command{
+	name:  "proxy",
+	short: "proxy TCP connections to a host, waking it on demand",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			listen   = fs.String("listen", "", "TCP address to accept connections on, such as :2222")
+			backend  = fs.String("backend", "", "TCP address of the backend; a bare :port uses the host's ip option")
+			target   = fs.String("t", "", "hardware address or inventory host name of the backend")
+			addr     = fs.String("a", "", "network address or destination URL for Wake-on-LAN magic packets (default "+inventory.DefaultAddr+")")
+			iface    = fs.String("i", "", "network interface, or destination IP address or subnet to select one by route, to use to send Wake-on-LAN magic packets")
+			password = fs.String("p", "", "optional password for Wake-on-LAN magic packets")
+			timeout  = fs.Duration("timeout", 2*time.Minute, "how long to hold connections while the backend wakes")
+			hosts    = hostsFlag(fs)
+		)
+
+		return func(_ []string) error {
+			switch {
+			case *listen == "":
+				return usagef("must set '-listen' flag")
+			case *backend == "":
+				return usagef("must set '-backend' flag")
+			case *target == "":
+				return usagef("must set '-t' flag")
+			case *addr != "" && *iface != "":
+				return usagef("must set '-a' or '-i' flag exclusively")
+			}
+
+			inv, err := loadInventory(*hosts)
+			if err != nil {
+				return err
+			}
+
+			h, err := resolveHost(inv, *target)
+			if err != nil {
+				return err
+			}
+
+			if strings.HasPrefix(*backend, ":") {
+				if h.IP == nil {
+					return usagef("backend %q has no host, and %s has no ip option", *backend, *target)
+				}
+
+				*backend = net.JoinHostPort(h.IP.String(), strings.TrimPrefix(*backend, ":"))
+			}
+
+			pass := h.Password
+			if *password != "" {
+				pass = []byte(*password)
+			}
+			transport, via := route(h, *addr, *iface)
+
+			l, err := net.Listen("tcp", *listen)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			p := &proxy.Proxy{
+				Backend:     *backend,
+				Target:      h.MAC,
+				WakeTimeout: *timeout,
+				Waker: wol.WakerFunc(func(_ context.Context, mac net.HardwareAddr) error {
+					return wake(transport, via, mac, pass)
+				}),
+				Logger: slog.New(slog.NewTextHandler(os.Stderr, nil)),
+			}
+
+			return p.Serve(ctx, l)
+		}
+	},
+}
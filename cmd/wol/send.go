@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+
+	// Register the relay destination URL scheme.
+	_ "github.com/mdlayher/wol/wolgrpc"
+)
+
+var sendCommand = &command{
+	name:  "send",
+	short: "send Wake-on-LAN magic packets",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			addrs, ifaces, targets listFlag
+
+			file     = fs.String("f", "", "file of target hardware addresses or inventory host names, one per line, or - for stdin")
+			password = fs.String("p", "", "optional password for Wake-on-LAN magic packets")
+			hosts    = hostsFlag(fs)
+			asJSON   = jsonFlag(fs)
+		)
+		fs.Var(&addrs, "a", "network address or destination URL, such as raw://eth0?vlan=20, for Wake-on-LAN magic packets; may be repeated or comma-separated (default "+inventory.DefaultAddr+")")
+		fs.Var(&ifaces, "i", "network interface, or destination IP address or subnet to select one by route, to use to send Wake-on-LAN magic packets; may be repeated or comma-separated. Without -a, UDP broadcasts are sent on the interface if raw sockets are not permitted")
+		fs.Var(&targets, "t", "target hardware address or inventory host name for Wake-on-LAN magic packets; may be repeated or comma-separated")
+
+		return func(_ []string) error {
+			if *file != "" {
+				ts, err := readTargets(*file)
+				if err != nil {
+					return err
+				}
+
+				targets = append(targets, ts...)
+			}
+			if len(targets) == 0 {
+				return usagef("must set '-t' or '-f' flag")
+			}
+
+			inv, err := loadInventory(*hosts)
+			if err != nil {
+				return err
+			}
+
+			// Resolve every target before sending anything, so a typo does
+			// not leave a run half finished.
+			hs := make([]*inventory.Host, 0, len(targets))
+			for _, t := range targets {
+				h, err := resolveHost(inv, t)
+				if err != nil {
+					return err
+				}
+
+				hs = append(hs, h)
+			}
+
+			var rs []*sendResult
+			for _, h := range hs {
+				pass := h.Password
+				if *password != "" {
+					pass = []byte(*password)
+				}
+
+				for _, r := range routes(h, addrs, ifaces) {
+					var err error
+					if r.Transport == transportAuto {
+						r.Transport, err = wakeAuto(r.Via, h.MAC, pass)
+					} else {
+						err = wake(r.Transport, r.Via, h.MAC, pass)
+					}
+					if err != nil {
+						r.Error = err.Error()
+					}
+
+					rs = append(rs, r)
+				}
+			}
+
+			var failed bool
+			for _, r := range rs {
+				if r.Error != "" {
+					failed = true
+				}
+			}
+
+			if *asJSON {
+				for _, r := range rs {
+					if err := printJSON(r); err != nil {
+						return err
+					}
+				}
+				if failed {
+					return errSilent
+				}
+
+				return nil
+			}
+
+			// A single magic packet is reported in a single line, as it
+			// always has been.
+			if len(rs) == 1 {
+				r := rs[0]
+				if r.Error != "" {
+					return errors.New(r.Error)
+				}
+
+				fmt.Printf("sent %s Wake-on-LAN magic packet using %s to %s\n", r.transportName(), r.Via, targets[0])
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "TARGET\tNAME\tTRANSPORT\tVIA\tSTATUS")
+			for _, r := range rs {
+				status := "sent"
+				if r.Error != "" {
+					status = "error: " + r.Error
+				}
+
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+					r.Target, dash(r.Name), r.transportName(), r.Via, status)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			if failed {
+				return errSilent
+			}
+
+			return nil
+		}
+	},
+}
+
+// A sendResult is the outcome of sending a magic packet.
+type sendResult struct {
+	Target    string `json:"target"`
+	Name      string `json:"name,omitempty"`
+	Transport string `json:"transport"`
+	Via       string `json:"via"`
+	Error     string `json:"error,omitempty"`
+}
+
+// readTargets reads targets from the file at path, or stdin if path is "-".
+// Targets are separated by whitespace, and a # begins a comment which runs to
+// the end of the line.
+func readTargets(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	var ts []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line, _, _ := strings.Cut(s.Text(), "#")
+		ts = append(ts, strings.Fields(line)...)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+// routes returns a sendResult for each way h should be woken. Every address
+// and interface is used if any are set, so UDP and raw delivery can be
+// combined; otherwise h is routed as by route.
+//
+// Interfaces given without addresses, or by h's inventory options, fall back
+// to UDP if raw sockets are not permitted, rather than making the user run
+// wol again.
+func routes(h *inventory.Host, addrs, ifaces []string) []*sendResult {
+	newResult := func(transport, via string) *sendResult {
+		return &sendResult{
+			Target:    h.MAC.String(),
+			Name:      h.Name,
+			Transport: transport,
+			Via:       via,
+		}
+	}
+
+	if len(addrs) == 0 && len(ifaces) == 0 {
+		transport, via := route(h, "", "")
+		if transport == "raw" && !isDestination(via) {
+			transport = transportAuto
+		}
+
+		return []*sendResult{newResult(transport, via)}
+	}
+
+	rs := make([]*sendResult, 0, len(addrs)+len(ifaces))
+	for _, a := range addrs {
+		rs = append(rs, newResult(route(h, a, "")))
+	}
+	for _, i := range ifaces {
+		transport, via := route(h, "", i)
+		if len(addrs) == 0 {
+			transport = transportAuto
+		}
+
+		rs = append(rs, newResult(transport, via))
+	}
+
+	return rs
+}
+
+// transportAuto is a transport which is resolved to raw or UDP when the
+// magic packet is sent, by wakeAuto.
+const transportAuto = "auto"
+
+// route returns the transport and UDP address, network interface, or
+// destination URL used to wake h. The addr and iface flags take precedence
+// over h's inventory.Host.Method.
+func route(h *inventory.Host, addr, iface string) (transport, via string) {
+	switch {
+	case isDestination(addr):
+		return destScheme(addr), addr
+	case addr != "":
+		return "udp", addr
+	case iface != "":
+		return "raw", iface
+	}
+
+	switch m, via := h.Method(); m {
+	case inventory.MethodDest:
+		return destScheme(via), via
+	case inventory.MethodInterface:
+		return "raw", via
+	default:
+		return "udp", via
+	}
+}
+
+// wake sends a magic packet to target using transport and via, as returned
+// by route.
+func wake(transport, via string, target net.HardwareAddr, password []byte) error {
+	switch {
+	case isDestination(via):
+		return wakeDest(via, target, password)
+	case transport == "raw":
+		return wakeRaw(via, target, password)
+	default:
+		return wakeUDP(via, target, password)
+	}
+}
+
+// transportName returns a human-readable transport name.
+func (r *sendResult) transportName() string {
+	switch r.Transport {
+	case "raw":
+		return "raw"
+	case "udp", "udp6":
+		return "UDP"
+	default:
+		return r.Transport
+	}
+}
+
+// isDestination reports whether s is a destination URL rather than a UDP
+// address or network interface name.
+func isDestination(s string) bool {
+	return strings.Contains(s, "://")
+}
+
+// destScheme returns the scheme of the destination URL dest, which is used as
+// its transport name.
+func destScheme(dest string) string {
+	u, err := wol.ParseDestination(dest)
+	if err != nil {
+		// wakeDest reports the error.
+		return "url"
+	}
+
+	return u.Scheme
+}
+
+func wakeDest(dest string, target net.HardwareAddr, password []byte) error {
+	d, err := wol.OpenDestination(dest)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Attempt to wake target machine.
+	return d.WakePasswordContext(context.Background(), target, password)
+}
+
+// lookupInterface returns the network interface named iface. An IP address or
+// subnet, such as 192.168.1.0/24, selects the interface using the routing
+// table.
+func lookupInterface(iface string) (*net.Interface, error) {
+	if p, err := netip.ParsePrefix(iface); err == nil {
+		return wol.RouteInterface(p.Addr().Unmap().AsSlice())
+	}
+	if ip := net.ParseIP(iface); ip != nil {
+		return wol.RouteInterface(ip)
+	}
+
+	return net.InterfaceByName(iface)
+}
+
+// wakeAuto sends a magic packet on iface using raw sockets if permitted, and
+// UDP broadcasts otherwise, and returns the transport it used.
+func wakeAuto(iface string, target net.HardwareAddr, password []byte) (string, error) {
+	ifi, err := lookupInterface(iface)
+	if err != nil {
+		return wol.TransportRaw, err
+	}
+
+	d, transport, err := wol.NewInterfaceClient(ifi)
+	if err != nil {
+		return wol.TransportRaw, err
+	}
+	defer d.Close()
+
+	// Attempt to wake target machine.
+	return transport, d.WakePasswordContext(context.Background(), target, password)
+}
+
+func wakeRaw(iface string, target net.HardwareAddr, password []byte) error {
+	ifi, err := lookupInterface(iface)
+	if err != nil {
+		return err
+	}
+
+	c, err := wol.NewRawClient(ifi)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// Attempt to wake target machine.
+	return c.WakePassword(target, password)
+}
+
+func wakeUDP(addr string, target net.HardwareAddr, password []byte) error {
+	c, err := wol.NewClient()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// Attempt to wake target machine.
+	return c.WakePassword(addr, target, password)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/mdlayher/wol/sleepproxy"
+)
+
+var sleepProxyCommand = &command{
+	name:  "sleepproxy",
+	short: "answer ARP for sleeping hosts, waking them when clients connect",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			iface = fs.String("i", "", "network interface shared with the sleeping hosts")
+			ports = fs.String("ports", "", "comma-separated TCP ports which wake a host (default any port)")
+			hosts = hostsFlag(fs)
+		)
+
+		return func(args []string) error {
+			switch {
+			case *iface == "":
+				return usagef("must set '-i' flag")
+			case len(args) == 0:
+				return usagef("must specify one or more sleeping hosts")
+			}
+
+			var wake []int
+			if *ports != "" {
+				for _, s := range strings.Split(*ports, ",") {
+					port, err := strconv.Atoi(strings.TrimSpace(s))
+					if err != nil || port < 1 || port > 65535 {
+						return usagef("invalid TCP port %q", s)
+					}
+
+					wake = append(wake, port)
+				}
+			}
+
+			inv, err := loadInventory(*hosts)
+			if err != nil {
+				return err
+			}
+
+			var shs []*sleepproxy.Host
+			for _, arg := range args {
+				h, err := resolveHost(inv, arg)
+				if err != nil {
+					return err
+				}
+				if h.IP == nil {
+					return usagef("host %s has no ip option", arg)
+				}
+
+				shs = append(shs, &sleepproxy.Host{
+					IP:       h.IP,
+					MAC:      h.MAC,
+					Ports:    wake,
+					Password: h.Password,
+				})
+			}
+
+			ifi, err := net.InterfaceByName(*iface)
+			if err != nil {
+				return err
+			}
+
+			p, err := sleepproxy.Listen(ifi)
+			if err != nil {
+				return err
+			}
+			p.Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+			for _, h := range shs {
+				if err := p.Register(h); err != nil {
+					_ = p.Close()
+					return err
+				}
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			// On exit, hand any hosts which are still asleep back to their
+			// own hardware addresses.
+			go func() {
+				<-ctx.Done()
+				for _, h := range p.Hosts() {
+					_ = p.Unregister(h.IP)
+				}
+				_ = p.Close()
+			}()
+
+			return p.Serve()
+		}
+	},
+}
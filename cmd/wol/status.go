@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mdlayher/wol/tracker"
+)
+
+// defaultTrackAddr is the address on which track serves its API by default.
+const defaultTrackAddr = "localhost:9102"
+
+var statusCommand = &command{
+	name:  "status",
+	short: "show the power state of hosts in the inventory",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			server  = fs.String("server", "", "optional URL of a running \"wol track\" API, such as http://"+defaultTrackAddr)
+			timeout = fs.Duration("timeout", 5*time.Second, "timeout for probing each host when -server is not set")
+			hosts   = hostsFlag(fs)
+			asJSON  = jsonFlag(fs)
+		)
+
+		return func(_ []string) error {
+			var (
+				ss  []tracker.HostStatus
+				err error
+			)
+			if *server != "" {
+				ss, err = fetchStatus(*server)
+			} else {
+				ss, err = probeStatus(*hosts, *timeout)
+			}
+			if err != nil {
+				return err
+			}
+
+			if *asJSON {
+				return printJSON(ss)
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tMAC\tSTATE\tSINCE\tLEASES\tERROR")
+			for _, s := range ss {
+				since := "-"
+				if !s.Since.IsZero() {
+					since = s.Since.Format(time.RFC3339)
+				}
+
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
+					s.Name, s.MAC, s.State, since, s.Leases, dash(s.Error))
+			}
+
+			return tw.Flush()
+		}
+	},
+}
+
+var trackCommand = &command{
+	name:  "track",
+	short: "track the power state of hosts and keep leased hosts awake",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			addr        = fs.String("listen", defaultTrackAddr, "address to serve the status and lease API on")
+			interval    = fs.Duration("interval", 10*time.Second, "how often to probe each host")
+			hosts       = hostsFlag(fs)
+			metricsAddr = metricsFlag(fs)
+		)
+
+		return func(_ []string) error {
+			inv, err := loadInventory(*hosts)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			m, err := serveMetrics(ctx, *metricsAddr)
+			if err != nil {
+				return err
+			}
+
+			t, err := tracker.New(&tracker.Config{
+				Inventory: inv,
+				Interval:  *interval,
+				Metrics:   m,
+			})
+			if err != nil {
+				return err
+			}
+
+			l, err := net.Listen("tcp", *addr)
+			if err != nil {
+				return err
+			}
+
+			srv := &http.Server{Handler: t.Handler()}
+			go func() {
+				<-ctx.Done()
+				_ = srv.Close()
+			}()
+			go func() { _ = srv.Serve(l) }()
+
+			return t.Run(ctx)
+		}
+	},
+}
+
+// fetchStatus fetches the status of each host from a tracker API at server.
+func fetchStatus(server string) ([]tracker.HostStatus, error) {
+	res, err := http.Get(strings.TrimSuffix(server, "/") + "/status")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch status from %s: %s", server, res.Status)
+	}
+
+	var ss []tracker.HostStatus
+	if err := json.NewDecoder(res.Body).Decode(&ss); err != nil {
+		return nil, err
+	}
+
+	return ss, nil
+}
+
+// probeStatus probes each host in the inventory at path once.
+func probeStatus(path string, timeout time.Duration) ([]tracker.HostStatus, error) {
+	inv, err := loadInventory(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(inv.Hosts) == 0 {
+		return nil, errors.New("no hosts in inventory")
+	}
+
+	t, err := tracker.New(&tracker.Config{
+		Inventory:    inv,
+		ProbeTimeout: timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.Probe(context.Background())
+	return t.Status(), nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/vmwake"
+)
+
+var vmwakeCommand = &command{
+	name:  "vmwake",
+	short: "start virtual machines and containers when magic packets target them",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		var (
+			addrs   = fs.String("a", "", "comma-separated UDP addresses to listen on (default "+defaultListenAddrs+" unless -i is set)")
+			iface   = fs.String("i", "", "network interface to listen on for raw Ethernet magic packets")
+			backend = fs.String("backend", "libvirt", "backend used to start workloads: libvirt, docker, or exec")
+			uri     = fs.String("uri", "", "libvirt connection URI, such as qemu:///system")
+			socket  = fs.String("socket", vmwake.DefaultDockerSocket, "Docker or Podman API socket")
+			cmd     = fs.String("command", "", "command run with the workload name appended, for the exec backend")
+			hosts   = hostsFlag(fs)
+		)
+
+		return func(args []string) error {
+			if len(args) == 0 {
+				return usagef("must specify one or more workloads as HOST or HOST=NAME")
+			}
+
+			var b vmwake.Backend
+			switch *backend {
+			case "libvirt":
+				b = vmwake.Libvirt(*uri)
+			case "docker":
+				b = vmwake.Docker(*socket)
+			case "exec":
+				fields := strings.Fields(*cmd)
+				if len(fields) == 0 {
+					return usagef("must set '-command' flag for exec backend")
+				}
+
+				b = vmwake.Command(fields[0], fields[1:]...)
+			default:
+				return usagef("unknown backend %q", *backend)
+			}
+
+			inv, err := loadInventory(*hosts)
+			if err != nil {
+				return err
+			}
+
+			// Each workload is named by an inventory host or hardware
+			// address, and optionally a workload name which otherwise
+			// defaults to the inventory host's name.
+			var ws []*vmwake.Workload
+			for _, arg := range args {
+				host, name, _ := strings.Cut(arg, "=")
+
+				h, err := resolveHost(inv, host)
+				if err != nil {
+					return err
+				}
+				if name == "" {
+					name = h.Name
+				}
+				if name == "" {
+					return usagef("workload %s must be specified as HOST=NAME", arg)
+				}
+
+				ws = append(ws, &vmwake.Workload{
+					Name:     name,
+					MAC:      h.MAC,
+					Password: h.Password,
+				})
+			}
+
+			if *addrs == "" && *iface == "" {
+				*addrs = defaultListenAddrs
+			}
+
+			tls, err := openListeners(*addrs)
+			if err != nil {
+				return err
+			}
+
+			ls := make([]*wol.Listener, 0, len(tls)+1)
+			for _, l := range tls {
+				ls = append(ls, l.Listener)
+			}
+
+			if *iface != "" {
+				l, err := listenRaw(*iface)
+				if err != nil {
+					for _, l := range ls {
+						_ = l.Close()
+					}
+					return err
+				}
+
+				ls = append(ls, l)
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			br := &vmwake.Bridge{
+				Workloads: ws,
+				Backend:   b,
+				Logger:    slog.New(slog.NewTextHandler(os.Stderr, nil)),
+			}
+
+			return br.Serve(ctx, ls...)
+		}
+	},
+}
+
+// listenRaw opens a Listener for magic packets sent in Ethernet frames on
+// iface.
+func listenRaw(iface string) (*wol.Listener, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	return wol.ListenRaw(ifi)
+}
@@ -0,0 +1,279 @@
+package wol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A Destination sends magic packets to machines on a network, as described by
+// a destination URL passed to OpenDestination.
+type Destination interface {
+	Waker
+	WakePasswordContext(ctx context.Context, target net.HardwareAddr, password []byte) error
+	io.Closer
+}
+
+var _ Destination = &RawClient{}
+
+// An OpenFunc opens a Destination for a destination URL whose scheme was
+// registered using RegisterScheme.
+type OpenFunc func(u *url.URL) (Destination, error)
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = map[string]OpenFunc{
+		"udp":  openUDP,
+		"udp6": openUDP,
+		"raw":  openRaw,
+	}
+)
+
+// RegisterScheme makes a destination URL scheme available to OpenDestination,
+// such as to wake machines using a relay or a vendor's management API.
+// RegisterScheme is typically called from an init function, and panics if
+// open is nil or scheme is already registered.
+//
+// The built-in schemes are:
+//
+//   - udp://host[:port][/bits]: a Client sends magic packets to host in any
+//     of the forms accepted by Client.Wake, such as udp://192.168.1.255:9,
+//     udp://192.168.1.0/24, or udp://eth0. The port defaults to 9.
+//   - udp6://[addr%zone][:port]: as udp, but using an IPv6 socket, such as
+//     udp6://[ff02::1%eth0]:9.
+//   - raw://iface[?vlan=id]: a RawClient sends Ethernet frames on a network
+//     interface, such as raw://eth0, optionally tagged with an IEEE 802.1Q
+//     VLAN ID. An IP address or prefix in place of iface selects the
+//     interface which routes to it, as with NewRawClientPrefix.
+//
+// Package wolgrpc registers the relay scheme.
+func RegisterScheme(scheme string, open OpenFunc) {
+	if open == nil {
+		panic("wol: RegisterScheme open function is nil")
+	}
+
+	scheme = strings.ToLower(scheme)
+
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+
+	if _, ok := schemes[scheme]; ok {
+		panic(fmt.Sprintf("wol: RegisterScheme called twice for scheme %q", scheme))
+	}
+	schemes[scheme] = open
+}
+
+// Schemes returns a sorted list of the registered destination URL schemes.
+func Schemes() []string {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+
+	ss := make([]string, 0, len(schemes))
+	for s := range schemes {
+		ss = append(ss, s)
+	}
+	sort.Strings(ss)
+
+	return ss
+}
+
+// ParseDestination parses a destination URL, such as "udp://10.0.0.255:9" or
+// "raw://eth0". It checks only the URL's syntax, so it may be used to
+// validate configuration before the scheme is registered.
+//
+// IPv6 zones may be written without escaping the '%' character, as in
+// "udp6://[ff02::1%eth0]:9". The URL's path may only be a prefix length, as
+// in "udp://192.168.1.0/24".
+func ParseDestination(dest string) (*url.URL, error) {
+	u, err := url.Parse(escapeZone(dest))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("wol: destination %q must be a URL such as udp://host:port or raw://iface", dest)
+	}
+	if u.User != nil || (u.Path != "" && u.Path != "/" && !isPrefixLen(u.Path)) || u.Fragment != "" {
+		return nil, fmt.Errorf("wol: destination %q may only specify a scheme, host, and query", dest)
+	}
+
+	return u, nil
+}
+
+// OpenDestination parses a destination URL using ParseDestination, and opens
+// a Destination using the OpenFunc registered for its scheme. The caller must
+// close the Destination when it is no longer needed.
+func OpenDestination(dest string) (Destination, error) {
+	u, err := ParseDestination(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	schemesMu.RLock()
+	open, ok := schemes[u.Scheme]
+	schemesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("wol: unknown destination scheme %q", u.Scheme)
+	}
+
+	return open(u)
+}
+
+// isPrefixLen reports whether path is a prefix length, such as "/24".
+func isPrefixLen(path string) bool {
+	bits, ok := strings.CutPrefix(path, "/")
+	if !ok || bits == "" || len(bits) > 3 {
+		return false
+	}
+
+	_, err := strconv.ParseUint(bits, 10, 8)
+	return err == nil
+}
+
+// urlHost returns the host of u, including its prefix length, if any.
+func urlHost(u *url.URL) string {
+	if isPrefixLen(u.Path) {
+		return u.Hostname() + u.Path
+	}
+
+	return u.Hostname()
+}
+
+// escapeZone escapes the zone delimiter of an IPv6 literal in dest, which
+// RFC 6874 requires but users rarely write.
+func escapeZone(dest string) string {
+	i := strings.IndexByte(dest, '[')
+	if i == -1 {
+		return dest
+	}
+	j := strings.IndexByte(dest[i:], ']')
+	if j == -1 {
+		return dest
+	}
+
+	k := strings.IndexByte(dest[i:i+j], '%')
+	if k == -1 || strings.HasPrefix(dest[i+k:], "%25") {
+		return dest
+	}
+
+	return dest[:i+k] + "%25" + dest[i+k+1:]
+}
+
+// checkQuery returns an error if u's query contains parameters other than
+// those in allowed.
+func checkQuery(u *url.URL, allowed ...string) error {
+	for k := range u.Query() {
+		ok := false
+		for _, a := range allowed {
+			if k == a {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("wol: unknown parameter %q for scheme %q", k, u.Scheme)
+		}
+	}
+
+	return nil
+}
+
+// openUDP opens a Destination for the udp and udp6 schemes.
+func openUDP(u *url.URL) (Destination, error) {
+	if err := checkQuery(u); err != nil {
+		return nil, err
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "9"
+	}
+
+	// Check the address as the Client will resolve it when sending.
+	addr := net.JoinHostPort(urlHost(u), port)
+	if _, err := resolveUDP(context.Background(), addr); err != nil {
+		return nil, err
+	}
+
+	p, err := net.ListenPacket(u.Scheme, ":0")
+	if err != nil {
+		return nil, err
+	}
+
+	return &udpDestination{
+		c:    NewClientConn(p),
+		addr: addr,
+	}, nil
+}
+
+// A udpDestination binds a Client to a fixed network address, and closes
+// the Client when it is closed.
+type udpDestination struct {
+	c    *Client
+	addr string
+}
+
+func (d *udpDestination) WakeContext(ctx context.Context, target net.HardwareAddr) error {
+	return d.WakePasswordContext(ctx, target, nil)
+}
+
+func (d *udpDestination) WakePasswordContext(ctx context.Context, target net.HardwareAddr, password []byte) error {
+	return d.c.WakePasswordContext(ctx, d.addr, target, password)
+}
+
+func (d *udpDestination) Close() error { return d.c.Close() }
+
+// openRaw opens a Destination for the raw scheme.
+func openRaw(u *url.URL) (Destination, error) {
+	if err := checkQuery(u, "vlan"); err != nil {
+		return nil, err
+	}
+	if u.Port() != "" {
+		return nil, fmt.Errorf("wol: raw destination %q must not specify a port", u.Host)
+	}
+
+	var vlan uint16
+	if s := u.Query().Get("vlan"); s != "" {
+		v, err := strconv.ParseUint(s, 10, 12)
+		if err != nil || v == 0 || v == 4095 {
+			return nil, fmt.Errorf("wol: invalid VLAN ID %q", s)
+		}
+		vlan = uint16(v)
+	}
+
+	var (
+		c   *RawClient
+		err error
+	)
+	switch host := urlHost(u); {
+	case strings.Contains(host, "/"):
+		p, perr := netip.ParsePrefix(host)
+		if perr != nil {
+			return nil, perr
+		}
+
+		c, err = NewRawClientPrefix(p)
+	case net.ParseIP(host) != nil:
+		c, err = NewRawClientRoute(net.ParseIP(host))
+	default:
+		var ifi *net.Interface
+		ifi, err = net.InterfaceByName(host)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err = NewRawClient(ifi)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.VLAN = vlan
+	return c, nil
+}
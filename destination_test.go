@@ -0,0 +1,232 @@
+package wol
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseDestination(t *testing.T) {
+	var tests = []struct {
+		name string
+		dest string
+		want *url.URL
+	}{
+		{
+			name: "no scheme",
+			dest: "10.0.0.255:9",
+		},
+		{
+			name: "no host",
+			dest: "udp:///foo",
+		},
+		{
+			name: "user",
+			dest: "udp://user@10.0.0.255:9",
+		},
+		{
+			name: "path",
+			dest: "raw://eth0/foo",
+		},
+		{
+			name: "path prefix length",
+			dest: "udp://10.0.0.0/24/32",
+		},
+		{
+			name: "prefix",
+			dest: "udp://10.0.0.0:7/24",
+			want: &url.URL{Scheme: "udp", Host: "10.0.0.0:7", Path: "/24"},
+		},
+		{
+			name: "UDP",
+			dest: "udp://10.0.0.255:9",
+			want: &url.URL{Scheme: "udp", Host: "10.0.0.255:9"},
+		},
+		{
+			name: "raw VLAN",
+			dest: "RAW://eth0?vlan=20",
+			want: &url.URL{Scheme: "raw", Host: "eth0", RawQuery: "vlan=20"},
+		},
+		{
+			name: "UDP6 zone",
+			dest: "udp6://[ff02::1%eth0]:9",
+			want: &url.URL{Scheme: "udp6", Host: "[ff02::1%eth0]:9"},
+		},
+		{
+			name: "UDP6 escaped zone",
+			dest: "udp6://[ff02::1%25eth0]:9",
+			want: &url.URL{Scheme: "udp6", Host: "[ff02::1%eth0]:9"},
+		},
+		{
+			name: "relay",
+			dest: "relay://agent:4000?transport=raw",
+			want: &url.URL{Scheme: "relay", Host: "agent:4000", RawQuery: "transport=raw"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := ParseDestination(tt.dest)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("expected an error, but parsed: %s", u)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, u); diff != "" {
+				t.Fatalf("unexpected URL (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOpenDestinationUDP(t *testing.T) {
+	l, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	d, err := OpenDestination("udp://" + l.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to open destination: %v", err)
+	}
+	defer d.Close()
+
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	if err := d.WakePasswordContext(context.Background(), target, []byte{1, 2, 3, 4}); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+
+	p, _, err := l.Receive()
+	if err != nil {
+		t.Fatalf("failed to receive: %v", err)
+	}
+
+	want := &MagicPacket{Target: target, Password: []byte{1, 2, 3, 4}}
+	if diff := cmp.Diff(want, p); diff != "" {
+		t.Fatalf("unexpected magic packet (-want +got):\n%s", diff)
+	}
+}
+
+func TestOpenDestinationUDPAddresses(t *testing.T) {
+	// Every form accepted by Client.Wake is accepted in a udp URL.
+	for _, dest := range []string{
+		"udp://192.0.2.255",
+		"udp://192.0.2.0/24",
+		"udp://192.0.2.0:7/24",
+		"udp://" + loopbackInterface(t).Name + ":7",
+	} {
+		t.Run(dest, func(t *testing.T) {
+			d, err := OpenDestination(dest)
+			if err != nil {
+				t.Fatalf("failed to open destination: %v", err)
+			}
+			_ = d.Close()
+		})
+	}
+}
+
+func TestOpenDestinationErrors(t *testing.T) {
+	for _, dest := range []string{
+		"foo://bar",
+		"udp://10.0.0.255:9?vlan=20",
+		"udp://10.0.0.255:http-alt-typo",
+		"udp://10.0.0.0/33",
+		"udp6://[2001:db8::]/64",
+		"raw://eth0:9",
+		"raw://eth0?vlan=4095",
+		"raw://eth0?vlan=foo",
+		"raw://eth0?priority=1",
+		"raw://wol-does-not-exist0",
+		"raw://wol-does-not-exist0/24",
+	} {
+		t.Run(dest, func(t *testing.T) {
+			if d, err := OpenDestination(dest); err == nil {
+				_ = d.Close()
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+}
+
+func TestRegisterScheme(t *testing.T) {
+	// Schemes can only be registered once per process, even if the test
+	// runs again.
+	var got []net.HardwareAddr
+	registerOnce.Do(func() {
+		RegisterScheme("wol-test", func(u *url.URL) (Destination, error) {
+			return &fakeDestination{
+				wake: func(target net.HardwareAddr) {
+					*woken = append(*woken, target)
+				},
+			}, nil
+		})
+	})
+	woken = &got
+
+	if !contains(Schemes(), "wol-test") {
+		t.Fatalf("scheme not registered: %v", Schemes())
+	}
+
+	d, err := OpenDestination("wol-test://anything")
+	if err != nil {
+		t.Fatalf("failed to open destination: %v", err)
+	}
+	defer d.Close()
+
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	if err := d.WakeContext(context.Background(), target); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+
+	if diff := cmp.Diff([]net.HardwareAddr{target}, got); diff != "" {
+		t.Fatalf("unexpected woken targets (-want +got):\n%s", diff)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected a panic registering a duplicate scheme")
+		}
+	}()
+	RegisterScheme("udp", openUDP)
+}
+
+var (
+	registerOnce sync.Once
+	woken        *[]net.HardwareAddr
+)
+
+// A fakeDestination is a Destination which calls wake for each target.
+type fakeDestination struct {
+	wake func(target net.HardwareAddr)
+}
+
+func (d *fakeDestination) WakeContext(ctx context.Context, target net.HardwareAddr) error {
+	return d.WakePasswordContext(ctx, target, nil)
+}
+
+func (d *fakeDestination) WakePasswordContext(_ context.Context, target net.HardwareAddr, _ []byte) error {
+	d.wake(target)
+	return nil
+}
+
+func (d *fakeDestination) Close() error { return nil }
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
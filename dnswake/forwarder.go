@@ -0,0 +1,467 @@
+// Package dnswake implements a DNS forwarder which wakes machines when their
+// names are looked up.
+//
+// A Forwarder forwards DNS queries to an upstream resolver. When a query
+// names a host in the inventory, the Forwarder first sends the host a
+// Wake-on-LAN magic packet, so users can simply connect to a sleeping machine
+// by name and it will be powered on by the time it is needed. An optional
+// liveness check skips hosts which are already awake.
+//
+// A Forwarder serves queries over UDP using Serve, and over TCP using
+// ServeTCP. Clients retry over TCP when a UDP response is truncated, so both
+// should normally be served on the same address.
+package dnswake
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Defaults used when a Forwarder's fields are unset.
+const (
+	defaultTimeout      = 5 * time.Second
+	defaultCooldown     = 30 * time.Second
+	defaultCheckTimeout = time.Second
+	defaultConcurrency  = 128
+)
+
+// maxMessageSize is the maximum size of a DNS message over UDP, including
+// EDNS(0) extensions.
+const maxMessageSize = 65535
+
+// tcpIdleTimeout is the time after which an idle TCP connection is closed.
+const tcpIdleTimeout = 10 * time.Second
+
+// A WakeFunc wakes a single host.
+type WakeFunc func(h *inventory.Host) error
+
+// A CheckFunc returns a wol.Check which reports whether a host is alive. It
+// returns nil if the host's liveness cannot be checked.
+type CheckFunc func(h *inventory.Host) wol.Check
+
+// A Forwarder is a DNS forwarder which wakes inventory hosts when their names
+// are looked up.
+type Forwarder struct {
+	// Upstream is the UDP address of the upstream DNS resolver, such as
+	// "192.168.1.1:53".
+	Upstream string
+
+	// Inventory specifies the hosts which are woken when their names are
+	// looked up.
+	Inventory *inventory.Inventory
+
+	// Domain optionally specifies a domain, such as "lan", which is removed
+	// from queried names before they are matched against host names, so a
+	// query for "nas.lan" wakes the host "nas".
+	Domain string
+
+	// Wake optionally wakes a host. If nil, inventory.Host.Wake is used.
+	Wake WakeFunc
+
+	// Observer is optionally notified of each magic packet sent when Wake
+	// is nil, such as for an audit log.
+	Observer wol.Observer
+
+	// Check optionally specifies how to check whether a host is alive, such
+	// as tracker.DefaultProbe. A host which is alive is not sent a magic
+	// packet. If nil, or if Check returns nil for a host, the host is woken
+	// without being checked.
+	Check CheckFunc
+
+	// CheckTimeout bounds each liveness check, which delays the response to
+	// the query naming the host. If zero, a default of 1 second is used.
+	CheckTimeout time.Duration
+
+	// Cooldown specifies the minimum time between attempts to check and wake
+	// a single host, since a single connection attempt often performs many
+	// lookups. If zero, a default of 30 seconds is used.
+	Cooldown time.Duration
+
+	// Concurrency specifies the maximum number of queries, or TCP
+	// connections, handled at once by each call to Serve or ServeTCP.
+	// Further queries wait until one completes. If zero, a default of 128 is
+	// used.
+	Concurrency int
+
+	// Timeout bounds each upstream query. If zero, a default of 5 seconds
+	// is used.
+	Timeout time.Duration
+
+	// Logger optionally logs wakes and upstream failures.
+	Logger *slog.Logger
+
+	mu    sync.Mutex
+	woken map[*inventory.Host]time.Time
+}
+
+// Serve serves DNS queries received over UDP on pc until ctx is canceled.
+// Queries are forwarded to the upstream resolver over UDP. Serve closes pc
+// before it returns.
+func (f *Forwarder) Serve(ctx context.Context, pc net.PacketConn) error {
+	if f.Upstream == "" {
+		return errors.New("dnswake: forwarder has no upstream")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		_ = pc.Close()
+	}()
+
+	sem := f.semaphore()
+
+	b := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := pc.ReadFrom(b)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		query := make([]byte, n)
+		copy(query, b[:n])
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			res, err := f.handle(ctx, "udp", query)
+			if err != nil {
+				// Malformed queries are dropped, as resolvers do.
+				return
+			}
+
+			_, _ = pc.WriteTo(res, addr)
+		}()
+	}
+}
+
+// ServeTCP serves DNS queries received over TCP connections accepted by ln
+// until ctx is canceled. Queries are forwarded to the upstream resolver over
+// TCP. ServeTCP closes ln and any open connections before it returns.
+func (f *Forwarder) ServeTCP(ctx context.Context, ln net.Listener) error {
+	if f.Upstream == "" {
+		return errors.New("dnswake: forwarder has no upstream")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	sem := f.semaphore()
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			f.serveConn(ctx, c)
+		}()
+	}
+}
+
+// serveConn serves DNS queries received on the TCP connection c until it is
+// idle, the client closes it, or ctx is canceled.
+func (f *Forwarder) serveConn(ctx context.Context, c net.Conn) {
+	defer c.Close()
+	stop := context.AfterFunc(ctx, func() { _ = c.Close() })
+	defer stop()
+
+	for {
+		if err := c.SetReadDeadline(time.Now().Add(tcpIdleTimeout)); err != nil {
+			return
+		}
+
+		query, err := readTCP(c)
+		if err != nil {
+			return
+		}
+
+		res, err := f.handle(ctx, "tcp", query)
+		if err != nil {
+			// Malformed queries close the connection, as resolvers do.
+			return
+		}
+
+		if err := writeTCP(c, res); err != nil {
+			return
+		}
+	}
+}
+
+// semaphore returns a channel which limits the number of queries or
+// connections handled at once.
+func (f *Forwarder) semaphore() chan struct{} {
+	n := f.Concurrency
+	if n == 0 {
+		n = defaultConcurrency
+	}
+
+	return make(chan struct{}, n)
+}
+
+// handle wakes the host named by query, if any, and returns the upstream
+// response to query, which is forwarded using network.
+func (f *Forwarder) handle(ctx context.Context, network string, query []byte) ([]byte, error) {
+	var p dnsmessage.Parser
+	hdr, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Response {
+		return nil, errors.New("dnswake: message is not a query")
+	}
+
+	q, err := p.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	if h, ok := f.lookup(q.Name.String()); ok {
+		f.wake(ctx, h)
+	}
+
+	res, err := f.forward(ctx, network, query)
+	if err != nil {
+		f.log(slog.LevelWarn, "failed to forward query", "name", q.Name.String(), "err", err)
+		return serverFailure(hdr, q)
+	}
+
+	return res, nil
+}
+
+// lookup finds the inventory host named by the DNS name name.
+func (f *Forwarder) lookup(name string) (*inventory.Host, bool) {
+	if f.Inventory == nil {
+		return nil, false
+	}
+
+	name = strings.TrimSuffix(name, ".")
+	if d := strings.Trim(f.Domain, "."); d != "" {
+		if s := strings.TrimSuffix(strings.ToLower(name), "."+strings.ToLower(d)); len(s) < len(name) {
+			name = s
+		}
+	}
+
+	return f.Inventory.Lookup(name)
+}
+
+// wake wakes h unless it was checked or woken recently, or it is alive.
+func (f *Forwarder) wake(ctx context.Context, h *inventory.Host) {
+	cooldown := f.Cooldown
+	if cooldown == 0 {
+		cooldown = defaultCooldown
+	}
+
+	now := time.Now()
+
+	f.mu.Lock()
+	if t, ok := f.woken[h]; ok && now.Sub(t) < cooldown {
+		f.mu.Unlock()
+		return
+	}
+	if f.woken == nil {
+		f.woken = make(map[*inventory.Host]time.Time)
+	}
+	f.woken[h] = now
+	f.mu.Unlock()
+
+	if f.alive(ctx, h) {
+		f.log(slog.LevelDebug, "host is alive", "host", h.Name)
+		return
+	}
+
+	wake := f.Wake
+	if wake == nil {
+		wake = func(h *inventory.Host) error {
+			return h.Wake(ctx, f.Observer)
+		}
+	}
+
+	if err := wake(h); err != nil {
+		f.log(slog.LevelWarn, "failed to wake host", "host", h.Name, "err", err)
+
+		// Allow the next query to try again.
+		f.mu.Lock()
+		delete(f.woken, h)
+		f.mu.Unlock()
+		return
+	}
+
+	f.log(slog.LevelInfo, "woke host", "host", h.Name, "mac", h.MAC.String())
+}
+
+// alive reports whether f's Check reports h alive.
+func (f *Forwarder) alive(ctx context.Context, h *inventory.Host) bool {
+	if f.Check == nil {
+		return false
+	}
+	check := f.Check(h)
+	if check == nil {
+		return false
+	}
+
+	timeout := f.CheckTimeout
+	if timeout == 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return check(ctx) == nil
+}
+
+// forward sends query to the upstream resolver using network, "udp" or "tcp",
+// and returns its response.
+func (f *Forwarder) forward(ctx context.Context, network string, query []byte) ([]byte, error) {
+	timeout := f.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	c, err := d.DialContext(ctx, network, f.Upstream)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if network == "tcp" {
+		if err := writeTCP(c, query); err != nil {
+			return nil, err
+		}
+
+		return readTCP(c)
+	}
+
+	if _, err := c.Write(query); err != nil {
+		return nil, err
+	}
+
+	// The query ID is preserved, so discard any stray responses which do
+	// not match it.
+	b := make([]byte, maxMessageSize)
+	for {
+		n, err := c.Read(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if n >= 2 && b[0] == query[0] && b[1] == query[1] {
+			return b[:n], nil
+		}
+	}
+}
+
+// readTCP reads a single length-prefixed DNS message from a TCP connection.
+func readTCP(r io.Reader) ([]byte, error) {
+	var n uint16
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// writeTCP writes a single length-prefixed DNS message to a TCP connection.
+func writeTCP(w io.Writer, msg []byte) error {
+	b := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(b[:2], uint16(len(msg)))
+	copy(b[2:], msg)
+
+	_, err := w.Write(b)
+	return err
+}
+
+// log logs a message if f has a Logger.
+func (f *Forwarder) log(level slog.Level, msg string, args ...any) {
+	if f.Logger == nil {
+		return
+	}
+
+	f.Logger.Log(context.Background(), level, msg, args...)
+}
+
+// serverFailure builds a SERVFAIL response to the query with header hdr and
+// question q.
+func serverFailure(hdr dnsmessage.Header, q dnsmessage.Question) ([]byte, error) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 hdr.ID,
+		Response:           true,
+		OpCode:             hdr.OpCode,
+		RecursionDesired:   hdr.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              dnsmessage.RCodeServerFailure,
+	})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+
+	res, err := b.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("dnswake: failed to build response: %w", err)
+	}
+
+	return res, nil
+}
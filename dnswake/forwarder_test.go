@@ -0,0 +1,363 @@
+package dnswake_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/dnswake"
+	"github.com/mdlayher/wol/inventory"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestForwarder(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader(strings.Join([]string{
+		"00:12:7f:eb:6b:40 nas ip=192.0.2.10",
+		"00:12:7f:eb:6b:41 desktop",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("failed to parse inventory: %v", err)
+	}
+
+	var (
+		mu    sync.Mutex
+		woken []string
+	)
+	addr := testForwarder(t, &dnswake.Forwarder{
+		Upstream:  upstream(t),
+		Inventory: inv,
+		Domain:    "lan.",
+		Wake: func(h *inventory.Host) error {
+			mu.Lock()
+			defer mu.Unlock()
+			woken = append(woken, h.Name)
+			return nil
+		},
+	})
+
+	// Hosts are woken by name, with or without the domain, but only once
+	// within the cooldown.
+	for _, name := range []string{
+		"NAS.lan.",
+		"nas.",
+		"desktop.",
+		"example.com.",
+		"desktop.example.com.",
+	} {
+		rcode, ip := query(t, "udp", addr, name)
+		if rcode != dnsmessage.RCodeSuccess || !ip.Equal(net.IPv4(192, 0, 2, 53)) {
+			t.Fatalf("unexpected response for %q: %v, %v", name, rcode, ip)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if diff := cmp.Diff([]string{"nas", "desktop"}, woken); diff != "" {
+		t.Fatalf("unexpected woken hosts (-want +got):\n%s", diff)
+	}
+}
+
+func TestForwarderCheck(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader(strings.Join([]string{
+		"00:12:7f:eb:6b:40 nas ip=192.0.2.10",
+		"00:12:7f:eb:6b:41 desktop ip=192.0.2.11",
+		"00:12:7f:eb:6b:42 build",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("failed to parse inventory: %v", err)
+	}
+
+	var (
+		mu    sync.Mutex
+		woken []string
+	)
+	addr := testForwarder(t, &dnswake.Forwarder{
+		Upstream:  upstream(t),
+		Inventory: inv,
+		Wake: func(h *inventory.Host) error {
+			mu.Lock()
+			defer mu.Unlock()
+			woken = append(woken, h.Name)
+			return nil
+		},
+		// nas is alive, desktop is asleep, and build cannot be checked.
+		Check: func(h *inventory.Host) wol.Check {
+			if h.IP == nil {
+				return nil
+			}
+
+			return func(_ context.Context) error {
+				if h.Name == "nas" {
+					return nil
+				}
+
+				return errors.New("no route to host")
+			}
+		},
+	})
+
+	for _, name := range []string{"nas.", "desktop.", "build."} {
+		if rcode, _ := query(t, "udp", addr, name); rcode != dnsmessage.RCodeSuccess {
+			t.Fatalf("unexpected response for %q: %v", name, rcode)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if diff := cmp.Diff([]string{"desktop", "build"}, woken); diff != "" {
+		t.Fatalf("unexpected woken hosts (-want +got):\n%s", diff)
+	}
+}
+
+func TestForwarderTCP(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader("00:12:7f:eb:6b:40 nas"))
+	if err != nil {
+		t.Fatalf("failed to parse inventory: %v", err)
+	}
+
+	var woken []string
+	addr := testForwarder(t, &dnswake.Forwarder{
+		Upstream:  upstream(t),
+		Inventory: inv,
+		Wake: func(h *inventory.Host) error {
+			woken = append(woken, h.Name)
+			return nil
+		},
+	})
+
+	rcode, ip := query(t, "tcp", addr, "nas.")
+	if rcode != dnsmessage.RCodeSuccess || !ip.Equal(net.IPv4(192, 0, 2, 53)) {
+		t.Fatalf("unexpected response: %v, %v", rcode, ip)
+	}
+
+	if diff := cmp.Diff([]string{"nas"}, woken); diff != "" {
+		t.Fatalf("unexpected woken hosts (-want +got):\n%s", diff)
+	}
+}
+
+func TestForwarderUpstreamFailure(t *testing.T) {
+	// Nothing answers on the upstream address.
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	addr := testForwarder(t, &dnswake.Forwarder{
+		Upstream: pc.LocalAddr().String(),
+		Timeout:  50 * time.Millisecond,
+	})
+
+	if rcode, _ := query(t, "udp", addr, "example.com."); rcode != dnsmessage.RCodeServerFailure {
+		t.Fatalf("expected SERVFAIL, but got: %v", rcode)
+	}
+}
+
+// testForwarder starts f on a local UDP socket and a TCP listener with the
+// same address, and returns the address.
+func testForwarder(t *testing.T, f *dnswake.Forwarder) string {
+	t.Helper()
+
+	pc, ln := listen(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 2)
+	go func() { done <- f.Serve(ctx, pc) }()
+	go func() { done <- f.ServeTCP(ctx, ln) }()
+
+	t.Cleanup(func() {
+		cancel()
+		for i := 0; i < 2; i++ {
+			if err := <-done; err != nil {
+				t.Errorf("failed to serve: %v", err)
+			}
+		}
+	})
+
+	return pc.LocalAddr().String()
+}
+
+// listen opens a local UDP socket and a TCP listener with the same address.
+// The TCP port may already be in use, so listen retries with new ports.
+func listen(t *testing.T) (net.PacketConn, net.Listener) {
+	t.Helper()
+
+	var err error
+	for i := 0; i < 10; i++ {
+		var pc net.PacketConn
+		pc, err = net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen on UDP: %v", err)
+		}
+
+		var ln net.Listener
+		ln, err = net.Listen("tcp", pc.LocalAddr().String())
+		if err == nil {
+			return pc, ln
+		}
+
+		_ = pc.Close()
+	}
+
+	t.Fatalf("failed to listen on TCP: %v", err)
+	return nil, nil
+}
+
+// upstream starts an upstream DNS server on UDP and TCP which answers every A
+// query with 192.0.2.53, and returns its address.
+func upstream(t *testing.T) string {
+	t.Helper()
+
+	pc, ln := listen(t)
+	t.Cleanup(func() {
+		_ = pc.Close()
+		_ = ln.Close()
+	})
+
+	go func() {
+		b := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(b)
+			if err != nil {
+				return
+			}
+
+			if rb, ok := answer(b[:n]); ok {
+				_, _ = pc.WriteTo(rb, addr)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			b := make([]byte, 2+512)
+			n, err := c.Read(b)
+			if err == nil && n > 2 {
+				if rb, ok := answer(b[2:n]); ok {
+					_, _ = c.Write(append([]byte{byte(len(rb) >> 8), byte(len(rb))}, rb...))
+				}
+			}
+			_ = c.Close()
+		}
+	}()
+
+	return pc.LocalAddr().String()
+}
+
+// answer builds a response to query which answers its question with
+// 192.0.2.53.
+func answer(query []byte) ([]byte, bool) {
+	var p dnsmessage.Parser
+	hdr, err := p.Start(query)
+	if err != nil {
+		return nil, false
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil, false
+	}
+
+	res := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:       hdr.ID,
+			Response: true,
+		},
+		Questions: []dnsmessage.Question{q},
+		Answers: []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{
+				Name:  q.Name,
+				Type:  dnsmessage.TypeA,
+				Class: dnsmessage.ClassINET,
+				TTL:   60,
+			},
+			Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 53}},
+		}},
+	}
+
+	rb, err := res.Pack()
+	if err != nil {
+		return nil, false
+	}
+
+	return rb, true
+}
+
+// query sends an A query for name to the DNS server at addr using network,
+// and returns the response code and the address in the first answer, if any.
+func query(t *testing.T, network, addr, name string) (dnsmessage.RCode, net.IP) {
+	t.Helper()
+
+	q := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               1234,
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	b, err := q.Pack()
+	if err != nil {
+		t.Fatalf("failed to pack query: %v", err)
+	}
+
+	c, err := net.Dial(network, addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	// TCP messages are prefixed with their length.
+	if network == "tcp" {
+		b = append([]byte{byte(len(b) >> 8), byte(len(b))}, b...)
+	}
+
+	_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.Write(b); err != nil {
+		t.Fatalf("failed to write query: %v", err)
+	}
+
+	rb := make([]byte, 2+512)
+	n, err := c.Read(rb)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if network == "tcp" {
+		if n < 2 {
+			t.Fatalf("short TCP response: %d bytes", n)
+		}
+		rb, n = rb[2:], n-2
+	}
+
+	var res dnsmessage.Message
+	if err := res.Unpack(rb[:n]); err != nil {
+		t.Fatalf("failed to unpack response: %v", err)
+	}
+	if res.Header.ID != q.Header.ID {
+		t.Fatalf("unexpected response ID: %d", res.Header.ID)
+	}
+
+	var ip net.IP
+	if len(res.Answers) > 0 {
+		if a, ok := res.Answers[0].Body.(*dnsmessage.AResource); ok {
+			ip = net.IP(a.A[:])
+		}
+	}
+
+	return res.Header.RCode, ip
+}
@@ -0,0 +1,115 @@
+package wol
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// Hooks are optional functions called by a Client or RawClient at points
+// while sending a magic packet, such as to create tracing spans. Each hook is
+// passed the same SendInfo for a single attempt, and the context passed to a
+// method such as WakeContext, or context.Background otherwise, so spans can
+// be attached to the caller's trace. Nil hooks are skipped.
+//
+// Hooks are called synchronously, so they must not block, and must be safe
+// for concurrent use if the client is used concurrently.
+type Hooks struct {
+	// BeforeMarshal is called when an attempt begins, before the magic
+	// packet is marshaled.
+	BeforeMarshal func(ctx context.Context, info *SendInfo)
+
+	// AfterSend is called after the magic packet is sent successfully.
+	AfterSend func(ctx context.Context, info *SendInfo)
+
+	// OnError is called with the error which caused an attempt to fail.
+	OnError func(ctx context.Context, info *SendInfo, err error)
+}
+
+// SendInfo describes an attempt to send a magic packet, as passed to Hooks.
+type SendInfo struct {
+	// Start is the time at which the attempt began.
+	Start time.Time
+
+	// Transport, Source, Destination, Target, Sleep, and Password have the
+	// same meaning as the fields of SendEvent.
+	Transport   string
+	Source      string
+	Destination string
+	Target      net.HardwareAddr
+	Sleep       bool
+	Password    bool
+
+	// Value may be set by BeforeMarshal to carry state between hooks for
+	// the same attempt, such as a tracing span.
+	Value any
+}
+
+// A sender carries the optional instrumentation shared by Client and
+// RawClient.
+type sender struct {
+	o Observer
+	h *Hooks
+	l *slog.Logger
+}
+
+// enabled reports whether any instrumentation is configured, so clients can
+// skip gathering a SendInfo otherwise.
+func (s sender) enabled() bool {
+	return s.o != nil || s.h != nil || s.l != nil
+}
+
+// begin calls the BeforeMarshal hook, if any, for info.
+func (s sender) begin(ctx context.Context, info *SendInfo) {
+	if s.h != nil && s.h.BeforeMarshal != nil {
+		s.h.BeforeMarshal(ctx, info)
+	}
+}
+
+// end calls the AfterSend or OnError hook, logs, and notifies the Observer
+// depending on the outcome err of an attempt described by info.
+func (s sender) end(ctx context.Context, info *SendInfo, err error) {
+	if s.h != nil {
+		switch {
+		case err == nil && s.h.AfterSend != nil:
+			s.h.AfterSend(ctx, info)
+		case err != nil && s.h.OnError != nil:
+			s.h.OnError(ctx, info, err)
+		}
+	}
+
+	now := time.Now()
+
+	if s.l != nil {
+		attrs := []slog.Attr{
+			slog.String("transport", info.Transport),
+			slog.String("source", info.Source),
+			slog.String("destination", info.Destination),
+			slog.String("target", info.Target.String()),
+			slog.Bool("sleep", info.Sleep),
+			slog.Bool("password", info.Password),
+			slog.Duration("duration", now.Sub(info.Start)),
+		}
+
+		if err != nil {
+			attrs = append(attrs, slog.Any("err", err))
+			s.l.LogAttrs(ctx, slog.LevelWarn, "failed to send magic packet", attrs...)
+		} else {
+			s.l.LogAttrs(ctx, slog.LevelDebug, "sent magic packet", attrs...)
+		}
+	}
+
+	if s.o != nil {
+		s.o.ObserveSend(&SendEvent{
+			Time:        now,
+			Transport:   info.Transport,
+			Source:      info.Source,
+			Destination: info.Destination,
+			Target:      info.Target,
+			Sleep:       info.Sleep,
+			Password:    info.Password,
+			Err:         err,
+		})
+	}
+}
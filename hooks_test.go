@@ -0,0 +1,182 @@
+package wol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientHooks(t *testing.T) {
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	var tests = []struct {
+		name   string
+		target net.HardwareAddr
+		calls  []string
+		ok     bool
+	}{
+		{
+			name:   "OK",
+			target: target,
+			calls:  []string{"before", "after"},
+			ok:     true,
+		},
+		{
+			name:   "invalid target",
+			target: make(net.HardwareAddr, 5),
+			calls:  []string{"before", "error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Each hook must receive the caller's context, such as one
+			// carrying a trace.
+			ctx := context.WithValue(context.Background(), hookKey{}, "trace")
+
+			var calls []string
+			c := &Client{
+				Hooks: &Hooks{
+					BeforeMarshal: func(ctx context.Context, info *SendInfo) {
+						calls = append(calls, "before")
+						checkContext(t, ctx)
+						info.Value = "span"
+					},
+					AfterSend: func(ctx context.Context, info *SendInfo) {
+						calls = append(calls, "after")
+						checkContext(t, ctx)
+						checkInfo(t, info)
+					},
+					OnError: func(ctx context.Context, info *SendInfo, err error) {
+						calls = append(calls, "error")
+						checkContext(t, ctx)
+						checkInfo(t, info)
+
+						if !errors.Is(err, errInvalidTarget) {
+							t.Fatalf("unexpected error: %v", err)
+						}
+					},
+				},
+				p: &writeToPacketConn{},
+			}
+
+			err := c.WakeContext(ctx, "127.0.0.1:9", tt.target)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to wake: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if diff := cmp.Diff(tt.calls, calls); diff != "" {
+				t.Fatalf("unexpected hook calls (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRawClientLogger(t *testing.T) {
+	var buf bytes.Buffer
+	c := &RawClient{
+		Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		})),
+		ifi: &net.Interface{
+			Name:         "eth0",
+			HardwareAddr: make(net.HardwareAddr, 6),
+		},
+		p: &writeToPacketConn{},
+	}
+
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	if err := c.Sleep(target); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	if err := c.WakePassword(target, []byte{1}); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	type record struct {
+		Level       string `json:"level"`
+		Msg         string `json:"msg"`
+		Transport   string `json:"transport"`
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+		Target      string `json:"target"`
+		Sleep       bool   `json:"sleep"`
+		Password    bool   `json:"password"`
+		Err         string `json:"err"`
+	}
+
+	var got []record
+	d := json.NewDecoder(&buf)
+	for d.More() {
+		var r record
+		if err := d.Decode(&r); err != nil {
+			t.Fatalf("failed to decode log record: %v", err)
+		}
+
+		got = append(got, r)
+	}
+
+	want := []record{
+		{
+			Level:       "DEBUG",
+			Msg:         "sent magic packet",
+			Transport:   TransportRaw,
+			Source:      "eth0",
+			Destination: target.String(),
+			Target:      target.String(),
+			Sleep:       true,
+		},
+		{
+			Level:       "WARN",
+			Msg:         "failed to send magic packet",
+			Transport:   TransportRaw,
+			Source:      "eth0",
+			Destination: target.String(),
+			Target:      target.String(),
+			Password:    true,
+			Err:         errInvalidPassword.Error(),
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected log records (-want +got):\n%s", diff)
+	}
+}
+
+// A hookKey is a context key used to verify that hooks receive the caller's
+// context.
+type hookKey struct{}
+
+// checkContext verifies that ctx is the context passed to WakeContext.
+func checkContext(t *testing.T, ctx context.Context) {
+	t.Helper()
+
+	if v := ctx.Value(hookKey{}); v != "trace" {
+		t.Fatalf("hook did not receive caller's context: %v", v)
+	}
+}
+
+// checkInfo verifies the common fields of a SendInfo passed to hooks by a
+// Client.
+func checkInfo(t *testing.T, info *SendInfo) {
+	t.Helper()
+
+	if info.Start.IsZero() {
+		t.Fatal("SendInfo has no start time")
+	}
+	if info.Value != "span" {
+		t.Fatalf("value was not carried between hooks: %v", info.Value)
+	}
+	if info.Transport != TransportUDP || info.Destination != "127.0.0.1:9" {
+		t.Fatalf("unexpected transport and destination: %s, %s", info.Transport, info.Destination)
+	}
+}
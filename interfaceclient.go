@@ -0,0 +1,69 @@
+package wol
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime"
+)
+
+// NewInterfaceClient creates a Destination which sends magic packets on the
+// network attached to ifi, using the best transport available, and reports
+// which transport it chose.
+//
+// If this process may open raw Ethernet sockets on ifi, NewInterfaceClient
+// returns a RawClient and TransportRaw. If it may not, because permission is
+// denied, such as when run without the CAP_NET_RAW capability on Linux, or
+// because RawClient is unsupported on this platform, it falls back to a
+// Client which sends magic packets to the broadcast address of each IPv4
+// subnet configured on ifi, and returns TransportUDP.
+//
+// Any other error from NewRawClient, such as a failure to bind to ifi, is
+// returned rather than hidden by the fallback. If neither transport is
+// available, the errors from both are returned.
+func NewInterfaceClient(ifi *net.Interface) (Destination, string, error) {
+	return newInterfaceClient(ifi, NewRawClient)
+}
+
+// newInterfaceClient implements NewInterfaceClient, using newRaw to attempt
+// to create a RawClient.
+func newInterfaceClient(
+	ifi *net.Interface,
+	newRaw func(ifi *net.Interface) (*RawClient, error),
+) (Destination, string, error) {
+	rc, rerr := newRaw(ifi)
+	if rerr == nil {
+		return rc, TransportRaw, nil
+	}
+	if !rawUnavailable(rerr) {
+		return nil, "", rerr
+	}
+
+	// Make sure the interface has somewhere to send UDP broadcasts before
+	// opening a socket.
+	if _, err := interfaceBroadcasts(ifi); err != nil {
+		return nil, "", errors.Join(rerr, err)
+	}
+
+	c, err := NewClient()
+	if err != nil {
+		return nil, "", errors.Join(rerr, err)
+	}
+
+	// Client resolves the interface name to its broadcast addresses on each
+	// send, so changes to the interface's addresses are picked up.
+	return &udpDestination{
+		c:    c,
+		addr: ifi.Name,
+	}, TransportUDP, nil
+}
+
+// rawUnavailable reports whether err, returned when creating a RawClient,
+// means this process cannot use raw Ethernet sockets at all, rather than that
+// something is wrong with a particular network interface.
+func rawUnavailable(err error) bool {
+	// mdlayher/packet reports a plain error on platforms other than Linux.
+	return errors.Is(err, os.ErrPermission) ||
+		errors.Is(err, errors.ErrUnsupported) ||
+		runtime.GOOS != "linux"
+}
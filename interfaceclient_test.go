@@ -0,0 +1,117 @@
+package wol
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestNewInterfaceClient(t *testing.T) {
+	// UDP fallback requires an IPv4 subnet, which loopback usually has.
+	lo := loopbackInterface(t)
+
+	var tests = []struct {
+		name      string
+		ifi       *net.Interface
+		rawErr    error
+		transport string
+		linux     bool
+		ok        bool
+	}{
+		{
+			name:      "raw",
+			ifi:       lo,
+			transport: TransportRaw,
+			ok:        true,
+		},
+		{
+			name:      "permission denied",
+			ifi:       lo,
+			rawErr:    os.ErrPermission,
+			transport: TransportUDP,
+			ok:        true,
+		},
+		{
+			name:      "unsupported",
+			ifi:       lo,
+			rawErr:    errors.ErrUnsupported,
+			transport: TransportUDP,
+			ok:        true,
+		},
+		{
+			name:   "bind failed",
+			ifi:    lo,
+			rawErr: errors.New("bind failed"),
+			// Elsewhere, every error means raw sockets are unsupported.
+			linux: true,
+		},
+		{
+			name:   "no subnets",
+			ifi:    &net.Interface{Index: 1 << 30, Name: "wol-does-not-exist0"},
+			rawErr: os.ErrPermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.linux && runtime.GOOS != "linux" {
+				t.Skipf("skipping, only applies on Linux")
+			}
+
+			d, transport, err := newInterfaceClient(tt.ifi, func(ifi *net.Interface) (*RawClient, error) {
+				if tt.rawErr != nil {
+					return nil, tt.rawErr
+				}
+
+				return NewRawClientConn(ifi, &noopPacketConn{}), nil
+			})
+			if !tt.ok {
+				if err == nil {
+					_ = d.Close()
+					t.Fatal("expected an error, but none occurred")
+				}
+				if !errors.Is(err, tt.rawErr) {
+					t.Fatalf("expected raw client error in: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer d.Close()
+
+			if transport != tt.transport {
+				t.Fatalf("unexpected transport: %q, want %q", transport, tt.transport)
+			}
+
+			target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+			if err := d.WakeContext(context.Background(), target); err != nil {
+				t.Fatalf("failed to wake: %v", err)
+			}
+		})
+	}
+}
+
+func loopbackInterface(t *testing.T) *net.Interface {
+	t.Helper()
+
+	ifis, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("skipping, failed to list interfaces: %v", err)
+	}
+
+	for _, ifi := range ifis {
+		if ifi.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		if _, err := interfaceBroadcasts(&ifi); err == nil {
+			return &ifi
+		}
+	}
+
+	t.Skip("skipping, no loopback interface with an IPv4 subnet")
+	return nil
+}
@@ -0,0 +1,322 @@
+// Package inventory parses lists of machines which can be woken using
+// Wake-on-LAN.
+//
+// An inventory file is compatible with the ethers(5) format: each line
+// contains a hardware address, whose octets may omit leading zeros, followed
+// by a host name. Lines may additionally contain options as key=value pairs,
+// and comments begin with '#'. For example:
+//
+//	# MAC address      name   options
+//	00:12:7f:eb:6b:40  nas    addr=192.168.1.255:9 ip=192.168.1.10
+//	00:12:7f:eb:6b:41  build  iface=eth0 password=abcd
+//	00:12:7f:eb:6b:42  lab    dest=raw://eth0?vlan=20
+//
+// The supported options are:
+//
+//   - addr: UDP address used to send magic packets to the host
+//   - iface: network interface used to send raw Ethernet magic packets
+//   - dest: destination URL used to send magic packets to the host, as
+//     accepted by wol.OpenDestination; it may not be combined with addr or
+//     iface
+//   - ip: IP address of the host, used to check whether it is alive
+//   - password: SecureOn password, as either text or a 6 byte hex
+//     hardware-address-style string
+//   - probe: how to check whether the host is alive: "icmp", "arp", or
+//     "tcp:<port>"
+//
+// A host which sets both addr and iface is woken using addr, as reported by
+// Host.Method.
+package inventory
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mdlayher/wol"
+)
+
+// DefaultPath is the conventional location of an ethers(5) file, which is
+// a valid inventory file.
+const DefaultPath = "/etc/ethers"
+
+// A Host is a single machine in an Inventory.
+type Host struct {
+	// Name and MAC are the host's name and hardware address.
+	Name string
+	MAC  net.HardwareAddr
+
+	// Addr is the optional UDP address used to send magic packets to the
+	// host, such as its subnet's broadcast address.
+	Addr string
+
+	// Interface is the optional name of a network interface used to send
+	// raw Ethernet magic packets to the host.
+	Interface string
+
+	// Dest is an optional destination URL used to send magic packets to the
+	// host, such as "udp://192.168.1.255:9" or "relay://agent:4000". See
+	// wol.OpenDestination for details.
+	Dest string
+
+	// IP is the optional IP address of the host, which can be used to check
+	// whether it is alive.
+	IP net.IP
+
+	// Password is the host's optional SecureOn password.
+	Password []byte
+
+	// Probe optionally specifies how to check whether the host is alive
+	// using its IP address: "icmp", "arp", or "tcp:" followed by a port.
+	Probe string
+}
+
+// An Inventory is a list of Hosts.
+type Inventory struct {
+	Hosts []*Host
+}
+
+// A ParseError describes an invalid line in an inventory file.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("inventory: line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Open opens and parses the inventory file at path.
+func Open(path string) (*Inventory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// OpenLenient opens and parses the inventory file at path using
+// ParseLenient.
+func OpenLenient(path string) (*Inventory, []*ParseError, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	return ParseLenient(f)
+}
+
+// Parse parses an Inventory from r. Any invalid line is an error.
+func Parse(r io.Reader) (*Inventory, error) {
+	return parse(r, func(perr *ParseError) error { return perr })
+}
+
+// ParseLenient is like Parse, but skips invalid lines rather than failing,
+// as is appropriate for files which are also used by other programs, such as
+// the system's /etc/ethers. A ParseError is returned for each skipped line.
+// The first of several hosts with the same name is kept.
+func ParseLenient(r io.Reader) (*Inventory, []*ParseError, error) {
+	var skipped []*ParseError
+	inv, err := parse(r, func(perr *ParseError) error {
+		skipped = append(skipped, perr)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return inv, skipped, nil
+}
+
+// parse parses an Inventory from r, passing each invalid line to invalid.
+// If invalid returns an error, parsing stops and the error is returned.
+func parse(r io.Reader, invalid func(perr *ParseError) error) (*Inventory, error) {
+	inv := &Inventory{}
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		if i := strings.IndexByte(text, '#'); i != -1 {
+			text = text[:i]
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		h, err := parseHost(fields)
+		if err == nil {
+			if _, ok := inv.Lookup(h.Name); ok {
+				err = fmt.Errorf("duplicate host %q", h.Name)
+			}
+		}
+		if err != nil {
+			if err := invalid(&ParseError{Line: line, Err: err}); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		inv.Hosts = append(inv.Hosts, h)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// Lookup finds a Host by name. Names are compared case-insensitively.
+func (inv *Inventory) Lookup(name string) (*Host, bool) {
+	for _, h := range inv.Hosts {
+		if strings.EqualFold(h.Name, name) {
+			return h, true
+		}
+	}
+
+	return nil, false
+}
+
+// LookupMAC finds a Host by hardware address.
+func (inv *Inventory) LookupMAC(mac net.HardwareAddr) (*Host, bool) {
+	for _, h := range inv.Hosts {
+		if h.MAC.String() == mac.String() {
+			return h, true
+		}
+	}
+
+	return nil, false
+}
+
+// parseHost parses a Host from the fields of a single line.
+func parseHost(fields []string) (*Host, error) {
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("expected hardware address and name, but got %q", strings.Join(fields, " "))
+	}
+
+	mac, err := parseMAC(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Host{
+		Name: fields[1],
+		MAC:  mac,
+	}
+
+	for _, f := range fields[2:] {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || v == "" {
+			return nil, fmt.Errorf("malformed option %q", f)
+		}
+
+		switch k {
+		case "addr":
+			h.Addr = v
+		case "iface":
+			h.Interface = v
+		case "dest":
+			if _, err := wol.ParseDestination(v); err != nil {
+				return nil, err
+			}
+			h.Dest = v
+		case "ip":
+			if h.IP = net.ParseIP(v); h.IP == nil {
+				return nil, fmt.Errorf("invalid IP address %q", v)
+			}
+		case "password":
+			if h.Password, err = parsePassword(v); err != nil {
+				return nil, err
+			}
+		case "probe":
+			if err := checkProbe(v); err != nil {
+				return nil, err
+			}
+			h.Probe = v
+		default:
+			return nil, fmt.Errorf("unknown option %q", k)
+		}
+	}
+
+	if h.Dest != "" && (h.Addr != "" || h.Interface != "") {
+		return nil, errors.New("dest option may not be combined with addr or iface")
+	}
+
+	return h, nil
+}
+
+// parseMAC parses an Ethernet hardware address. In addition to the formats
+// accepted by net.ParseMAC, it accepts the ethers(5) format, in which leading
+// zeros may be omitted from each octet, such as 0:1b:2:eb:6b:40.
+func parseMAC(s string) (net.HardwareAddr, error) {
+	if mac, err := net.ParseMAC(s); err == nil {
+		if len(mac) != 6 {
+			return nil, fmt.Errorf("hardware address %q is not an Ethernet address", s)
+		}
+
+		return mac, nil
+	}
+
+	octets := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == '-' })
+	if len(octets) != 6 || strings.Count(s, ":")+strings.Count(s, "-") != 5 {
+		return nil, fmt.Errorf("invalid hardware address %q", s)
+	}
+
+	mac := make(net.HardwareAddr, 0, 6)
+	for _, o := range octets {
+		b, err := strconv.ParseUint(o, 16, 8)
+		if err != nil || len(o) > 2 {
+			return nil, fmt.Errorf("invalid hardware address %q", s)
+		}
+
+		mac = append(mac, byte(b))
+	}
+
+	return mac, nil
+}
+
+// parsePassword parses a SecureOn password as either a hardware-address-style
+// hex string or raw text.
+func parsePassword(s string) ([]byte, error) {
+	b := []byte(s)
+	if mac, err := net.ParseMAC(s); err == nil && len(mac) == 6 {
+		b = mac
+	}
+
+	if l := len(b); l != 4 && l != 6 {
+		return nil, fmt.Errorf("password must be 4 or 6 bytes, but got %d", l)
+	}
+
+	return b, nil
+}
+
+// checkProbe verifies that s is a valid probe option.
+func checkProbe(s string) error {
+	if s == "icmp" || s == "arp" {
+		return nil
+	}
+
+	if p, ok := strings.CutPrefix(s, "tcp:"); ok {
+		if n, err := strconv.ParseUint(p, 10, 16); err == nil && n != 0 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid probe %q", s)
+}
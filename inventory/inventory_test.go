@@ -0,0 +1,202 @@
+package inventory_test
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/wol/inventory"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		name string
+		s    string
+		inv  *inventory.Inventory
+		line int
+	}{
+		{
+			name: "empty",
+			s:    "# nothing here\n\n",
+			inv:  &inventory.Inventory{},
+		},
+		{
+			name: "ethers",
+			s:    "00:12:7f:eb:6b:40 nas\n00-12-7f-eb-6b-41\tbuild # comment\n",
+			inv: &inventory.Inventory{Hosts: []*inventory.Host{
+				{Name: "nas", MAC: mustMAC("00:12:7f:eb:6b:40")},
+				{Name: "build", MAC: mustMAC("00:12:7f:eb:6b:41")},
+			}},
+		},
+		{
+			name: "ethers short octets",
+			s:    "0:12:7f:eb:6b:40 nas\n",
+			inv: &inventory.Inventory{Hosts: []*inventory.Host{
+				{Name: "nas", MAC: mustMAC("00:12:7f:eb:6b:40")},
+			}},
+		},
+		{
+			name: "options",
+			s: strings.Join([]string{
+				"00:12:7f:eb:6b:40 nas addr=192.168.1.255:9 ip=192.168.1.10 password=abcd",
+				"00:12:7f:eb:6b:41 build iface=eth0 password=01:02:03:04:05:06 probe=tcp:22",
+				"00:12:7f:eb:6b:42 lab dest=raw://eth0?vlan=20",
+			}, "\n"),
+			inv: &inventory.Inventory{Hosts: []*inventory.Host{
+				{
+					Name:     "nas",
+					MAC:      mustMAC("00:12:7f:eb:6b:40"),
+					Addr:     "192.168.1.255:9",
+					IP:       net.ParseIP("192.168.1.10"),
+					Password: []byte("abcd"),
+				},
+				{
+					Name:      "build",
+					MAC:       mustMAC("00:12:7f:eb:6b:41"),
+					Interface: "eth0",
+					Password:  []byte{1, 2, 3, 4, 5, 6},
+					Probe:     "tcp:22",
+				},
+				{
+					Name: "lab",
+					MAC:  mustMAC("00:12:7f:eb:6b:42"),
+					Dest: "raw://eth0?vlan=20",
+				},
+			}},
+		},
+		{
+			name: "missing name",
+			s:    "# first\n00:12:7f:eb:6b:40\n",
+			line: 2,
+		},
+		{
+			name: "bad MAC",
+			s:    "nas 00:12:7f:eb:6b:40\n",
+			line: 1,
+		},
+		{
+			name: "bad short octets",
+			s:    "0:12:7f:eb:6b nas\n",
+			line: 1,
+		},
+		{
+			name: "unknown option",
+			s:    "00:12:7f:eb:6b:40 nas foo=bar\n",
+			line: 1,
+		},
+		{
+			name: "bad password",
+			s:    "00:12:7f:eb:6b:40 nas password=abcde\n",
+			line: 1,
+		},
+		{
+			name: "bad probe",
+			s:    "00:12:7f:eb:6b:40 nas probe=tcp:http\n",
+			line: 1,
+		},
+		{
+			name: "bad dest",
+			s:    "00:12:7f:eb:6b:40 nas dest=192.168.1.255:9\n",
+			line: 1,
+		},
+		{
+			name: "dest and addr",
+			s:    "00:12:7f:eb:6b:40 nas dest=udp://192.168.1.255:9 addr=192.168.1.255:9\n",
+			line: 1,
+		},
+		{
+			name: "duplicate",
+			s:    "00:12:7f:eb:6b:40 nas\n00:12:7f:eb:6b:41 NAS\n",
+			line: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, err := inventory.Parse(strings.NewReader(tt.s))
+			if tt.line != 0 {
+				var perr *inventory.ParseError
+				if !errors.As(err, &perr) || perr.Line != tt.line {
+					t.Fatalf("expected parse error on line %d, but got: %v", tt.line, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.inv, inv); diff != "" {
+				t.Fatalf("unexpected inventory (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	// A system /etc/ethers file which is also maintained by other programs.
+	s := strings.Join([]string{
+		"# /etc/ethers",
+		"0:12:7f:eb:6b:40 nas",
+		"00:12:7f:eb:6b:41 192.168.1.11",
+		"00:12:7f:eb:6b:42 build some-other-tool-token",
+		"00:12:7f:eb:6b:43 nas",
+		"not-a-mac printer",
+		"00:12:7f:eb:6b:44",
+		"00:12:7f:eb:6b:45 desktop ip=192.168.1.15",
+	}, "\n")
+
+	inv, skipped, err := inventory.ParseLenient(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	want := &inventory.Inventory{Hosts: []*inventory.Host{
+		{Name: "nas", MAC: mustMAC("00:12:7f:eb:6b:40")},
+		{Name: "192.168.1.11", MAC: mustMAC("00:12:7f:eb:6b:41")},
+		{Name: "desktop", MAC: mustMAC("00:12:7f:eb:6b:45"), IP: net.ParseIP("192.168.1.15")},
+	}}
+	if diff := cmp.Diff(want, inv); diff != "" {
+		t.Fatalf("unexpected inventory (-want +got):\n%s", diff)
+	}
+
+	var lines []int
+	for _, perr := range skipped {
+		lines = append(lines, perr.Line)
+	}
+	if diff := cmp.Diff([]int{4, 5, 6, 7}, lines); diff != "" {
+		t.Fatalf("unexpected skipped lines (-want +got):\n%s", diff)
+	}
+
+	// The same file is invalid when parsed strictly.
+	if _, err := inventory.Parse(strings.NewReader(s)); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestInventoryLookup(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader("00:12:7f:eb:6b:40 nas\n"))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if _, ok := inv.Lookup("NAS"); !ok {
+		t.Fatal("failed to look up host by name")
+	}
+	if _, ok := inv.LookupMAC(mustMAC("00:12:7F:EB:6B:40")); !ok {
+		t.Fatal("failed to look up host by MAC")
+	}
+	if _, ok := inv.Lookup("build"); ok {
+		t.Fatal("unexpectedly found unknown host")
+	}
+}
+
+func mustMAC(s string) net.HardwareAddr {
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return mac
+}
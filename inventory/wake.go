@@ -0,0 +1,136 @@
+package inventory
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/mdlayher/wol"
+)
+
+// DefaultAddr is the UDP address used to wake a Host which specifies neither
+// a destination URL, an address, nor a network interface: the limited
+// broadcast address, on the discard port.
+const DefaultAddr = "255.255.255.255:9"
+
+// A Method is the way in which a Host is woken.
+type Method int
+
+// Possible Method values.
+const (
+	// MethodDefault sends a UDP magic packet to DefaultAddr.
+	MethodDefault Method = iota
+
+	// MethodDest wakes a Host using its destination URL.
+	MethodDest
+
+	// MethodAddr sends a UDP magic packet to a Host's address.
+	MethodAddr
+
+	// MethodInterface sends a magic packet on a Host's network interface.
+	MethodInterface
+)
+
+// Method reports how h is woken when no other way is specified: using its
+// destination URL, UDP address, or network interface, in that order of
+// precedence, or DefaultAddr if it specifies none of them. via is the URL,
+// address, or interface name which is used.
+//
+// Every program which wakes a Host from the inventory should follow Method,
+// so a Host is woken the same way no matter which program wakes it.
+func (h *Host) Method() (m Method, via string) {
+	switch {
+	case h.Dest != "":
+		return MethodDest, h.Dest
+	case h.Addr != "":
+		return MethodAddr, h.Addr
+	case h.Interface != "":
+		return MethodInterface, h.Interface
+	default:
+		return MethodDefault, DefaultAddr
+	}
+}
+
+// Wake sends a magic packet to h in the way reported by Method. The magic
+// packet carries h's password, if any. A Host woken using its network
+// interface is sent a UDP broadcast instead of a raw Ethernet frame if raw
+// sockets are unavailable, as with wol.NewInterfaceClient.
+//
+// If obs is not nil, it is notified of the attempt to send the magic packet.
+func (h *Host) Wake(ctx context.Context, obs wol.Observer) error {
+	m, via := h.Method()
+	switch m {
+	case MethodDest:
+		return h.wakeDest(ctx, via, obs)
+	case MethodInterface:
+		return h.wakeInterface(ctx, via, obs)
+	}
+
+	c, err := wol.NewClient()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	c.Observer = obs
+
+	return c.WakePasswordContext(ctx, via, h.MAC, h.Password)
+}
+
+// wakeDest wakes h using the destination URL dest.
+func (h *Host) wakeDest(ctx context.Context, dest string, obs wol.Observer) error {
+	d, err := wol.OpenDestination(dest)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	var transport string
+	if u, err := wol.ParseDestination(dest); err == nil {
+		transport = u.Scheme
+	}
+
+	err = d.WakePasswordContext(ctx, h.MAC, h.Password)
+	h.observe(obs, &wol.SendEvent{
+		Transport:   transport,
+		Destination: dest,
+		Err:         err,
+	})
+
+	return err
+}
+
+// wakeInterface wakes h using the network interface named iface.
+func (h *Host) wakeInterface(ctx context.Context, iface string, obs wol.Observer) error {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return err
+	}
+
+	d, transport, err := wol.NewInterfaceClient(ifi)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	err = d.WakePasswordContext(ctx, h.MAC, h.Password)
+	h.observe(obs, &wol.SendEvent{
+		Transport: transport,
+		Source:    iface,
+		Err:       err,
+	})
+
+	return err
+}
+
+// observe notifies obs, if not nil, of e, an attempt to wake h. A
+// wol.Destination has no Observer, so its attempts are observed here instead.
+func (h *Host) observe(obs wol.Observer, e *wol.SendEvent) {
+	if obs == nil {
+		return
+	}
+
+	e.Time = time.Now()
+	e.Target = h.MAC
+	e.Password = len(h.Password) > 0
+	obs.ObserveSend(e)
+}
@@ -0,0 +1,134 @@
+package inventory_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+)
+
+func TestHostMethod(t *testing.T) {
+	var tests = []struct {
+		name string
+		h    *inventory.Host
+		m    inventory.Method
+		via  string
+	}{
+		{
+			name: "default",
+			h:    &inventory.Host{},
+			m:    inventory.MethodDefault,
+			via:  inventory.DefaultAddr,
+		},
+		{
+			name: "dest",
+			h:    &inventory.Host{Dest: "relay://agent:4000"},
+			m:    inventory.MethodDest,
+			via:  "relay://agent:4000",
+		},
+		{
+			name: "addr",
+			h:    &inventory.Host{Addr: "192.168.1.255:9"},
+			m:    inventory.MethodAddr,
+			via:  "192.168.1.255:9",
+		},
+		{
+			name: "iface",
+			h:    &inventory.Host{Interface: "eth0"},
+			m:    inventory.MethodInterface,
+			via:  "eth0",
+		},
+		{
+			name: "addr and iface",
+			h:    &inventory.Host{Addr: "192.168.1.255:9", Interface: "eth0"},
+			m:    inventory.MethodAddr,
+			via:  "192.168.1.255:9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, via := tt.h.Method()
+			if m != tt.m || via != tt.via {
+				t.Fatalf("unexpected method: got (%d, %q), want (%d, %q)", m, via, tt.m, tt.via)
+			}
+		})
+	}
+}
+
+func TestHostWake(t *testing.T) {
+	l, err := wol.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	addr := l.Addr().String()
+	mac := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	var tests = []struct {
+		name      string
+		h         *inventory.Host
+		transport string
+	}{
+		{
+			name: "addr",
+			h: &inventory.Host{
+				MAC:  mac,
+				Addr: addr,
+			},
+			transport: wol.TransportUDP,
+		},
+		{
+			name: "dest",
+			h: &inventory.Host{
+				MAC:      mac,
+				Dest:     "udp://" + addr,
+				Password: []byte("abcd"),
+			},
+			transport: "udp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []*wol.SendEvent
+			obs := observerFunc(func(e *wol.SendEvent) {
+				events = append(events, e)
+			})
+
+			if err := tt.h.Wake(context.Background(), obs); err != nil {
+				t.Fatalf("failed to wake: %v", err)
+			}
+
+			p, _, err := l.Receive()
+			if err != nil {
+				t.Fatalf("failed to receive: %v", err)
+			}
+
+			want := &wol.MagicPacket{
+				Target:   tt.h.MAC,
+				Password: tt.h.Password,
+			}
+			if diff := cmp.Diff(want, p, cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("unexpected magic packet (-want +got):\n%s", diff)
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("expected 1 send event, but got %d", len(events))
+			}
+			if e := events[0]; e.Transport != tt.transport || e.Err != nil {
+				t.Fatalf("unexpected send event: %s, %v", e.Transport, e.Err)
+			}
+		})
+	}
+}
+
+// An observerFunc adapts a function to wol.Observer.
+type observerFunc func(e *wol.SendEvent)
+
+func (fn observerFunc) ObserveSend(e *wol.SendEvent) { fn(e) }
@@ -0,0 +1,499 @@
+// Package ipmi implements an IPMI v2.0 RMCP+ client which can power on
+// machines using their baseboard management controller (BMC).
+//
+// Machines which ignore Wake-on-LAN magic packets once fully powered off can
+// often still be powered on by their BMC. A Client implements wol.Waker, so
+// it can be used interchangeably with the Wake-on-LAN clients in package wol.
+//
+// Sessions are established using cipher suite 3: RAKP-HMAC-SHA1
+// authentication, HMAC-SHA1-96 integrity, and AES-CBC-128 confidentiality.
+package ipmi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/wol"
+)
+
+// Port is the well-known UDP port for RMCP.
+const Port = 623
+
+// A Privilege is an IPMI session privilege level.
+type Privilege byte
+
+// Possible Privilege values.
+const (
+	PrivilegeCallback      Privilege = 0x01
+	PrivilegeUser          Privilege = 0x02
+	PrivilegeOperator      Privilege = 0x03
+	PrivilegeAdministrator Privilege = 0x04
+)
+
+// Network functions and commands used by a Client.
+const (
+	netFnChassis = 0x00
+	netFnApp     = 0x06
+
+	cmdGetChassisStatus    = 0x01
+	cmdChassisControl      = 0x02
+	cmdSetSessionPrivilege = 0x3b
+	cmdCloseSession        = 0x3c
+)
+
+// Field values and lengths used by a Client.
+const (
+	chassisControlPowerUp = 0x01
+
+	chassisStatusPowerOn       = 0x01
+	chassisStatusPowerOverload = 0x02
+	chassisStatusPowerFault    = 0x08
+
+	statusNoErrors       = 0x00
+	completionCodeNormal = 0x00
+	roleNameOnlyLookup   = 0x10
+
+	maxUsernameLen         = 16
+	maxPasswordLen         = 20
+	openSessionResponseLen = 36
+	rakp2Len               = 8 + randomLen + guidLen + authLen
+	rakp4Len               = 8 + icvLen
+
+	defaultTimeout  = 2 * time.Second
+	defaultAttempts = 3
+)
+
+var (
+	// errSkip is returned by a roundTrip parse function to indicate that a
+	// packet is unrelated to the request and should be ignored.
+	errSkip = errors.New("skip packet")
+
+	// errTimeout is returned when a BMC does not respond to a request.
+	errTimeout = errors.New("ipmi: timed out waiting for BMC response")
+)
+
+var _ wol.Waker = &Client{}
+
+// Config contains configuration for a Client.
+type Config struct {
+	// Username and Password specify the credentials used to authenticate
+	// with a BMC. Username may be at most 16 bytes, and Password may be at
+	// most 20 bytes.
+	Username string
+	Password string
+
+	// Privilege specifies the privilege level requested for a session. If
+	// zero, PrivilegeAdministrator is used. Powering on a machine requires
+	// at least PrivilegeOperator.
+	Privilege Privilege
+
+	// Timeout specifies how long to wait for each response from a BMC
+	// before retransmitting a request. If zero, a default is used.
+	Timeout time.Duration
+}
+
+// A StatusError is returned when a BMC rejects a session establishment
+// message or a command.
+type StatusError struct {
+	// Op describes the rejected operation.
+	Op string
+
+	// Code is the RMCP+ status code or IPMI completion code returned by the
+	// BMC.
+	Code byte
+}
+
+// Error implements error.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ipmi: %s failed with code 0x%02x", e.Op, e.Code)
+}
+
+// ChassisStatus contains the power state of a machine, as reported by its
+// BMC.
+type ChassisStatus struct {
+	PowerOn       bool
+	PowerOverload bool
+	PowerFault    bool
+}
+
+// A Client is an IPMI v2.0 client with an active RMCP+ session to a BMC.
+type Client struct {
+	mu sync.Mutex
+
+	c       net.Conn
+	timeout time.Duration
+
+	consoleID uint32
+	bmcID     uint32
+	keys      *sessionKeys
+	seq       uint32
+	rqSeq     byte
+}
+
+// Dial dials the BMC at addr over UDP and establishes an authenticated and
+// encrypted RMCP+ session. If addr does not specify a port, Port is used.
+func Dial(addr string, cfg *Config) (*Client, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if len(cfg.Username) > maxUsernameLen {
+		return nil, errors.New("ipmi: username too long")
+	}
+	if len(cfg.Password) > maxPasswordLen {
+		return nil, errors.New("ipmi: password too long")
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, fmt.Sprint(Port))
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		c:       conn,
+		timeout: cfg.Timeout,
+	}
+	if c.timeout == 0 {
+		c.timeout = defaultTimeout
+	}
+
+	priv := cfg.Privilege
+	if priv == 0 {
+		priv = PrivilegeAdministrator
+	}
+
+	if err := c.open(cfg.Username, cfg.Password, priv); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Close closes a Client's session and its underlying socket.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Make a best effort to close the session so the BMC can free its
+	// resources, but always close the socket.
+	id := binary.LittleEndian.AppendUint32(nil, c.bmcID)
+	_, _ = c.command(context.Background(), netFnApp, cmdCloseSession, id)
+
+	return c.c.Close()
+}
+
+// PowerOn instructs the BMC to power on its machine.
+func (c *Client) PowerOn(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := c.command(ctx, netFnChassis, cmdChassisControl, []byte{chassisControlPowerUp})
+	return err
+}
+
+// ChassisStatus retrieves the power state of the BMC's machine.
+func (c *Client) ChassisStatus(ctx context.Context) (*ChassisStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, err := c.command(ctx, netFnChassis, cmdGetChassisStatus, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 1 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return &ChassisStatus{
+		PowerOn:       b[0]&chassisStatusPowerOn != 0,
+		PowerOverload: b[0]&chassisStatusPowerOverload != 0,
+		PowerFault:    b[0]&chassisStatusPowerFault != 0,
+	}, nil
+}
+
+// WakeContext implements wol.Waker by powering on the BMC's machine. A BMC
+// only controls a single machine, so target is ignored.
+func (c *Client) WakeContext(ctx context.Context, _ net.HardwareAddr) error {
+	return c.PowerOn(ctx)
+}
+
+// open establishes an RMCP+ session using the RAKP handshake.
+func (c *Client) open(username, password string, priv Privilege) error {
+	id, err := randomUint32()
+	if err != nil {
+		return err
+	}
+	c.consoleID = id
+
+	// Open Session Request: propose cipher suite 3.
+	req := []byte{0x00, byte(priv), 0x00, 0x00}
+	req = binary.LittleEndian.AppendUint32(req, c.consoleID)
+	req = append(req,
+		0x00, 0x00, 0x00, 0x08, authRAKPHMACSHA1, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x08, integrityHMACSHA196, 0x00, 0x00, 0x00,
+		0x02, 0x00, 0x00, 0x08, confAESCBC128, 0x00, 0x00, 0x00,
+	)
+
+	err = c.handshake(payloadOpenSessionRequest, req, payloadOpenSessionResponse, func(b []byte) error {
+		if len(b) < openSessionResponseLen {
+			return errShortPacket
+		}
+		if b[1] != statusNoErrors {
+			return &StatusError{Op: "open session", Code: b[1]}
+		}
+		if binary.LittleEndian.Uint32(b[4:8]) != c.consoleID {
+			return errSkip
+		}
+		if b[16] != authRAKPHMACSHA1 || b[24] != integrityHMACSHA196 || b[32] != confAESCBC128 {
+			return errors.New("ipmi: BMC does not support cipher suite 3")
+		}
+
+		c.bmcID = binary.LittleEndian.Uint32(b[8:12])
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// RAKP Message 1: send our random number and username.
+	var rcon [randomLen]byte
+	if _, err := io.ReadFull(rand.Reader, rcon[:]); err != nil {
+		return err
+	}
+
+	kuid := []byte(password)
+	user := []byte(username)
+	role := byte(priv) | roleNameOnlyLookup
+	sidcon := binary.LittleEndian.AppendUint32(nil, c.consoleID)
+	sidbmc := binary.LittleEndian.AppendUint32(nil, c.bmcID)
+
+	rakp1 := append([]byte{0x00, 0x00, 0x00, 0x00}, sidbmc...)
+	rakp1 = append(rakp1, rcon[:]...)
+	rakp1 = append(rakp1, role, 0x00, 0x00, byte(len(user)))
+	rakp1 = append(rakp1, user...)
+
+	// RAKP Message 2: verify the BMC knows the user's password.
+	var rbmc, guid []byte
+	err = c.handshake(payloadRAKP1, rakp1, payloadRAKP2, func(b []byte) error {
+		if len(b) < 8 {
+			return errShortPacket
+		}
+		if b[1] != statusNoErrors {
+			return &StatusError{Op: "RAKP message 2", Code: b[1]}
+		}
+		if len(b) < rakp2Len {
+			return errShortPacket
+		}
+		if !bytes.Equal(b[4:8], sidcon) {
+			return errSkip
+		}
+
+		rbmc = b[8 : 8+randomLen]
+		guid = b[8+randomLen : 8+randomLen+guidLen]
+
+		want := hmacSHA1(kuid, sidcon, sidbmc, rcon[:], rbmc, guid, []byte{role, byte(len(user))}, user)
+		if !hmac.Equal(b[8+randomLen+guidLen:rakp2Len], want) {
+			return errors.New("ipmi: BMC authentication failed, check username and password")
+		}
+
+		// Copy out values which must outlive the receive buffer.
+		rbmc = append([]byte(nil), rbmc...)
+		guid = append([]byte(nil), guid...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// RAKP Message 3: prove we know the user's password.
+	rakp3 := append([]byte{0x00, statusNoErrors, 0x00, 0x00}, sidbmc...)
+	rakp3 = append(rakp3, hmacSHA1(kuid, rbmc, sidcon, []byte{role, byte(len(user))}, user)...)
+
+	sik := hmacSHA1(kuid, rcon[:], rbmc, []byte{role, byte(len(user))}, user)
+
+	// RAKP Message 4: verify the BMC derived the same session integrity key.
+	err = c.handshake(payloadRAKP3, rakp3, payloadRAKP4, func(b []byte) error {
+		if len(b) < 8 {
+			return errShortPacket
+		}
+		if b[1] != statusNoErrors {
+			return &StatusError{Op: "RAKP message 4", Code: b[1]}
+		}
+		if len(b) < rakp4Len {
+			return errShortPacket
+		}
+		if !bytes.Equal(b[4:8], sidcon) {
+			return errSkip
+		}
+
+		want := hmacSHA1(sik, rcon[:], sidbmc, guid)[:icvLen]
+		if !hmac.Equal(b[8:rakp4Len], want) {
+			return errors.New("ipmi: BMC session integrity check failed")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.keys = deriveKeys(sik)
+
+	// Sessions begin at a lower privilege level and must be raised to the
+	// requested level before privileged commands can be issued.
+	_, err = c.command(context.Background(), netFnApp, cmdSetSessionPrivilege, []byte{byte(priv)})
+	return err
+}
+
+// handshake sends an unauthenticated session establishment payload and
+// passes the payload of the matching response to parse.
+func (c *Client) handshake(reqType byte, req []byte, resType byte, parse func(b []byte) error) error {
+	b, err := marshalPacket(&packet{
+		PayloadType: reqType,
+		Payload:     req,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.roundTrip(context.Background(), func() ([]byte, error) { return b, nil }, func(b []byte) error {
+		p, err := unmarshalPacket(b, nil)
+		if err != nil || p.PayloadType != resType {
+			return errSkip
+		}
+
+		return parse(p.Payload)
+	})
+}
+
+// command sends an IPMI command within the active session and returns the
+// response data following the completion code.
+func (c *Client) command(ctx context.Context, netFn, cmd byte, data []byte) ([]byte, error) {
+	c.rqSeq = (c.rqSeq + 1) & 0x3f
+	rqSeq := c.rqSeq
+
+	req := marshalRequest(&message{
+		NetFn:   netFn,
+		Command: cmd,
+		Seq:     rqSeq,
+		Data:    data,
+	})
+
+	var out []byte
+	err := c.roundTrip(ctx, func() ([]byte, error) {
+		// Each transmission, including retransmissions, must use a new
+		// session sequence number.
+		c.seq++
+		return marshalPacket(&packet{
+			PayloadType: payloadIPMI,
+			SessionID:   c.bmcID,
+			Sequence:    c.seq,
+			Payload:     req,
+		}, c.keys)
+	}, func(b []byte) error {
+		p, err := unmarshalPacket(b, c.keys)
+		if err != nil || p.PayloadType != payloadIPMI || p.SessionID != c.consoleID {
+			return errSkip
+		}
+
+		m, err := unmarshalMessage(p.Payload)
+		if err != nil || m.NetFn != netFn+1 || m.Command != cmd || m.Seq != rqSeq || len(m.Data) < 1 {
+			return errSkip
+		}
+
+		if m.Data[0] != completionCodeNormal {
+			return &StatusError{
+				Op:   fmt.Sprintf("command 0x%02x/0x%02x", netFn, cmd),
+				Code: m.Data[0],
+			}
+		}
+
+		out = append([]byte(nil), m.Data[1:]...)
+		return nil
+	})
+
+	return out, err
+}
+
+// roundTrip sends the packet produced by build and waits for a response
+// accepted by parse, retransmitting on timeout, until ctx is done.
+func (c *Client) roundTrip(ctx context.Context, build func() ([]byte, error), parse func(b []byte) error) error {
+	// Interrupt any pending read when ctx is canceled.
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.c.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	buf := make([]byte, 1024)
+	for i := 0; i < defaultAttempts; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		b, err := build()
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.c.Write(b); err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(c.timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err := c.c.SetReadDeadline(deadline); err != nil {
+			return err
+		}
+
+		for {
+			n, err := c.c.Read(buf)
+			if err != nil {
+				var nerr net.Error
+				if errors.As(err, &nerr) && nerr.Timeout() {
+					break
+				}
+
+				return err
+			}
+
+			if err := parse(buf[:n]); err != errSkip {
+				return err
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return errTimeout
+}
+
+// randomUint32 returns a random, non-zero uint32.
+func randomUint32() (uint32, error) {
+	var b [4]byte
+	for {
+		if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+			return 0, err
+		}
+
+		if v := binary.LittleEndian.Uint32(b[:]); v != 0 {
+			return v, nil
+		}
+	}
+}
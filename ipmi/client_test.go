@@ -0,0 +1,374 @@
+package ipmi
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientPowerOn(t *testing.T) {
+	bmc := newFakeBMC(t, "admin", "password")
+
+	c, err := Dial(bmc.addr(), &Config{
+		Username: "admin",
+		Password: "password",
+	})
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+
+	ctx := context.Background()
+	status, err := c.ChassisStatus(ctx)
+	if err != nil {
+		t.Fatalf("failed to get chassis status: %v", err)
+	}
+	if diff := cmp.Diff(&ChassisStatus{}, status); diff != "" {
+		t.Fatalf("unexpected initial status (-want +got):\n%s", diff)
+	}
+
+	if err := c.WakeContext(ctx, nil); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+
+	status, err = c.ChassisStatus(ctx)
+	if err != nil {
+		t.Fatalf("failed to get chassis status: %v", err)
+	}
+	if diff := cmp.Diff(&ChassisStatus{PowerOn: true}, status); diff != "" {
+		t.Fatalf("unexpected status after wake (-want +got):\n%s", diff)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if !bmc.closed() {
+		t.Fatal("session was not closed")
+	}
+}
+
+func TestClientPowerOnCanceled(t *testing.T) {
+	bmc := newFakeBMC(t, "admin", "password")
+
+	c, err := Dial(bmc.addr(), &Config{
+		Username: "admin",
+		Password: "password",
+	})
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.PowerOn(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status, err := c.ChassisStatus(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get chassis status: %v", err)
+	}
+	if status.PowerOn {
+		t.Fatal("machine powered on after request was canceled")
+	}
+}
+
+func TestDialBadCredentials(t *testing.T) {
+	var tests = []struct {
+		name     string
+		username string
+		password string
+		code     byte
+	}{
+		{
+			name:     "unknown user",
+			username: "nobody",
+			password: "password",
+			code:     rakpUnauthorizedName,
+		},
+		{
+			name:     "bad password",
+			username: "admin",
+			password: "hunter2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bmc := newFakeBMC(t, "admin", "password")
+
+			_, err := Dial(bmc.addr(), &Config{
+				Username: tt.username,
+				Password: tt.password,
+				Timeout:  100 * time.Millisecond,
+			})
+			if err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			var serr *StatusError
+			if tt.code != 0 {
+				if !errors.As(err, &serr) || serr.Code != tt.code {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDialTimeout(t *testing.T) {
+	// Bind a socket which never replies.
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	_, err = Dial(pc.LocalAddr().String(), &Config{Timeout: 10 * time.Millisecond})
+	if err != errTimeout {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := make([]byte, 16)
+	for i := 0; i < 40; i++ {
+		in := make([]byte, i)
+		for j := range in {
+			in[j] = byte(j)
+		}
+
+		b, err := encrypt(key, in)
+		if err != nil {
+			t.Fatalf("failed to encrypt: %v", err)
+		}
+
+		out, err := decrypt(key, b)
+		if err != nil {
+			t.Fatalf("failed to decrypt: %v", err)
+		}
+
+		if diff := cmp.Diff(in, out); diff != "" {
+			t.Fatalf("unexpected plaintext (-want +got):\n%s", diff)
+		}
+	}
+}
+
+// RAKP status code returned by fakeBMC for an unknown user.
+const rakpUnauthorizedName = 0x0d
+
+// A fakeBMC is an in-process BMC which supports cipher suite 3 and the
+// commands needed to power on a machine.
+type fakeBMC struct {
+	t    *testing.T
+	pc   net.PacketConn
+	user string
+	kuid []byte
+
+	mu               sync.Mutex
+	consoleID, bmcID uint32
+	role             byte
+	rcon             []byte
+	rbmc             []byte
+	guid             []byte
+	keys             *sessionKeys
+	powerOn          bool
+	isClosed         bool
+}
+
+func newFakeBMC(t *testing.T, user, password string) *fakeBMC {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	b := &fakeBMC{
+		t:     t,
+		pc:    pc,
+		user:  user,
+		kuid:  []byte(password),
+		bmcID: 0x0a0b0c0d,
+		rbmc:  []byte("bmc random value"),
+		guid:  []byte("bmc system guid!"),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.serve()
+	}()
+
+	t.Cleanup(func() {
+		_ = pc.Close()
+		wg.Wait()
+	})
+
+	return b
+}
+
+func (b *fakeBMC) addr() string { return b.pc.LocalAddr().String() }
+
+func (b *fakeBMC) closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.isClosed
+}
+
+func (b *fakeBMC) serve() {
+	buf := make([]byte, 1024)
+	for {
+		n, addr, err := b.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		res, err := b.handle(buf[:n])
+		if err != nil {
+			b.t.Errorf("fake BMC failed to handle packet: %v", err)
+			return
+		}
+		if res == nil {
+			continue
+		}
+
+		if _, err := b.pc.WriteTo(res, addr); err != nil {
+			return
+		}
+	}
+}
+
+func (b *fakeBMC) handle(in []byte) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Handshake packets are unauthenticated, so inspect the payload type
+	// before choosing whether to decrypt.
+	if len(in) < 6 {
+		return nil, errShortPacket
+	}
+
+	var keys *sessionKeys
+	if in[5]&payloadTypeMask == payloadIPMI {
+		keys = b.keys
+	}
+
+	p, err := unmarshalPacket(in, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	le := binary.LittleEndian
+	reply := func(pt byte, payload []byte, keys *sessionKeys) ([]byte, error) {
+		return marshalPacket(&packet{
+			PayloadType: pt,
+			SessionID:   b.consoleID,
+			Payload:     payload,
+		}, keys)
+	}
+
+	switch p.PayloadType {
+	case payloadOpenSessionRequest:
+		b.consoleID = le.Uint32(p.Payload[4:8])
+
+		res := []byte{p.Payload[0], statusNoErrors, p.Payload[1], 0x00}
+		res = le.AppendUint32(res, b.consoleID)
+		res = le.AppendUint32(res, b.bmcID)
+		res = append(res, p.Payload[8:32]...)
+		return reply(payloadOpenSessionResponse, res, nil)
+	case payloadRAKP1:
+		b.rcon = append([]byte(nil), p.Payload[8:24]...)
+		b.role = p.Payload[24]
+		user := string(p.Payload[28 : 28+int(p.Payload[27])])
+
+		res := []byte{p.Payload[0], statusNoErrors, 0x00, 0x00}
+		res = le.AppendUint32(res, b.consoleID)
+		if user != b.user {
+			res[1] = rakpUnauthorizedName
+			return reply(payloadRAKP2, res, nil)
+		}
+
+		res = append(res, b.rbmc...)
+		res = append(res, b.guid...)
+		res = append(res, hmacSHA1(b.kuid,
+			le.AppendUint32(nil, b.consoleID), le.AppendUint32(nil, b.bmcID),
+			b.rcon, b.rbmc, b.guid, []byte{b.role, byte(len(user))}, []byte(user),
+		)...)
+		return reply(payloadRAKP2, res, nil)
+	case payloadRAKP3:
+		user := []byte(b.user)
+		want := hmacSHA1(b.kuid, b.rbmc, le.AppendUint32(nil, b.consoleID),
+			[]byte{b.role, byte(len(user))}, user)
+
+		res := []byte{p.Payload[0], statusNoErrors, 0x00, 0x00}
+		res = le.AppendUint32(res, b.consoleID)
+		if !hmac.Equal(p.Payload[8:], want) {
+			// Invalid integrity check value.
+			res[1] = 0x0f
+			return reply(payloadRAKP4, res, nil)
+		}
+
+		sik := hmacSHA1(b.kuid, b.rcon, b.rbmc, []byte{b.role, byte(len(user))}, user)
+		res = append(res, hmacSHA1(sik, b.rcon, le.AppendUint32(nil, b.bmcID), b.guid)[:icvLen]...)
+		b.keys = deriveKeys(sik)
+		return reply(payloadRAKP4, res, nil)
+	case payloadIPMI:
+		if b.keys == nil || p.SessionID != b.bmcID {
+			return nil, errors.New("IPMI message outside of session")
+		}
+
+		m, err := unmarshalMessage(p.Payload)
+		if err != nil {
+			return nil, err
+		}
+
+		data := []byte{completionCodeNormal}
+		switch {
+		case m.NetFn == netFnApp && m.Command == cmdSetSessionPrivilege:
+			data = append(data, m.Data[0])
+		case m.NetFn == netFnApp && m.Command == cmdCloseSession:
+			b.isClosed = true
+		case m.NetFn == netFnChassis && m.Command == cmdGetChassisStatus:
+			var status byte
+			if b.powerOn {
+				status |= chassisStatusPowerOn
+			}
+			data = append(data, status, 0x00, 0x00)
+		case m.NetFn == netFnChassis && m.Command == cmdChassisControl:
+			b.powerOn = true
+		default:
+			// Invalid command.
+			data[0] = 0xc1
+		}
+
+		return reply(payloadIPMI, marshalResponse(&message{
+			NetFn:   m.NetFn + 1,
+			Command: m.Command,
+			Seq:     m.Seq,
+			Data:    data,
+		}), b.keys)
+	default:
+		return nil, errors.New("unexpected payload type")
+	}
+}
+
+// marshalResponse marshals m as a response from the BMC to software. m.Data
+// must begin with a completion code.
+func marshalResponse(m *message) []byte {
+	b := []byte{addrSoftware, m.NetFn << 2, 0, addrBMC, m.Seq << 2, m.Command}
+	b[2] = checksum(b[0:2])
+	b = append(b, m.Data...)
+
+	return append(b, checksum(b[3:]))
+}
@@ -0,0 +1,292 @@
+package ipmi
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// RMCP and IPMI v2.0 session constants.
+const (
+	rmcpVersion   = 0x06
+	rmcpNoACK     = 0xff
+	rmcpClassIPMI = 0x07
+
+	authTypeRMCPPlus = 0x06
+
+	payloadEncrypted     = 0x80
+	payloadAuthenticated = 0x40
+	payloadTypeMask      = 0x3f
+)
+
+// RMCP+ payload types.
+const (
+	payloadIPMI                = 0x00
+	payloadOpenSessionRequest  = 0x10
+	payloadOpenSessionResponse = 0x11
+	payloadRAKP1               = 0x12
+	payloadRAKP2               = 0x13
+	payloadRAKP3               = 0x14
+	payloadRAKP4               = 0x15
+)
+
+// Algorithm identifiers for cipher suite 3: RAKP-HMAC-SHA1 authentication,
+// HMAC-SHA1-96 integrity, and AES-CBC-128 confidentiality.
+const (
+	authRAKPHMACSHA1    = 0x01
+	integrityHMACSHA196 = 0x01
+	confAESCBC128       = 0x01
+)
+
+// Fixed IPMB addresses used for LAN messages.
+const (
+	addrBMC      = 0x20
+	addrSoftware = 0x81
+)
+
+// Sizes of fixed-length protocol fields.
+const (
+	randomLen = 16
+	guidLen   = 16
+	authLen   = sha1.Size
+	icvLen    = 12
+)
+
+var (
+	errShortPacket  = errors.New("ipmi: packet too short")
+	errNotIPMI      = errors.New("ipmi: not an RMCP+ IPMI packet")
+	errIntegrity    = errors.New("ipmi: packet integrity check failed")
+	errBadChecksum  = errors.New("ipmi: invalid message checksum")
+	errBadPadding   = errors.New("ipmi: invalid confidentiality padding")
+	errNotEncrypted = errors.New("ipmi: session packet is not encrypted and authenticated")
+)
+
+// A packet is an RMCP+ session packet.
+type packet struct {
+	PayloadType byte
+	SessionID   uint32
+	Sequence    uint32
+	Payload     []byte
+}
+
+// sessionKeys holds the keys derived during RAKP for an active session.
+type sessionKeys struct {
+	k1 []byte
+	k2 []byte
+}
+
+// deriveKeys derives integrity and confidentiality keys from a session
+// integrity key.
+func deriveKeys(sik []byte) *sessionKeys {
+	return &sessionKeys{
+		k1: hmacSHA1(sik, bytes.Repeat([]byte{0x01}, authLen)),
+		k2: hmacSHA1(sik, bytes.Repeat([]byte{0x02}, authLen)),
+	}
+}
+
+// marshalPacket marshals p into binary form. If keys is non-nil, p's payload
+// is encrypted and an integrity trailer is added.
+func marshalPacket(p *packet, keys *sessionKeys) ([]byte, error) {
+	pt := p.PayloadType
+	payload := p.Payload
+	if keys != nil {
+		pt |= payloadEncrypted | payloadAuthenticated
+
+		var err error
+		payload, err = encrypt(keys.k2[:aes.BlockSize], payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b := []byte{rmcpVersion, 0x00, rmcpNoACK, rmcpClassIPMI, authTypeRMCPPlus, pt}
+	b = binary.LittleEndian.AppendUint32(b, p.SessionID)
+	b = binary.LittleEndian.AppendUint32(b, p.Sequence)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(payload)))
+	b = append(b, payload...)
+
+	if keys == nil {
+		return b, nil
+	}
+
+	// Pad the integrity-protected portion, beginning at the authentication
+	// type, so that it ends on a 4 byte boundary after the pad length and
+	// next header fields.
+	end := len(b)
+	for (len(b)-4+2)%4 != 0 {
+		b = append(b, 0xff)
+	}
+	b = append(b, byte(len(b)-end), rmcpClassIPMI)
+
+	return append(b, hmacSHA1(keys.k1, b[4:])[:icvLen]...), nil
+}
+
+// unmarshalPacket unmarshals a packet from b. If keys is non-nil, the packet
+// must be encrypted and authenticated using keys.
+func unmarshalPacket(b []byte, keys *sessionKeys) (*packet, error) {
+	// RMCP header, auth type, payload type, session ID, sequence, length.
+	const hdrLen = 4 + 2 + 4 + 4 + 2
+	if len(b) < hdrLen {
+		return nil, errShortPacket
+	}
+
+	if b[0] != rmcpVersion || b[3] != rmcpClassIPMI || b[4] != authTypeRMCPPlus {
+		return nil, errNotIPMI
+	}
+
+	p := &packet{
+		PayloadType: b[5] & payloadTypeMask,
+		SessionID:   binary.LittleEndian.Uint32(b[6:10]),
+		Sequence:    binary.LittleEndian.Uint32(b[10:14]),
+	}
+
+	n := int(binary.LittleEndian.Uint16(b[14:16]))
+	if len(b) < hdrLen+n {
+		return nil, errShortPacket
+	}
+	payload := b[hdrLen : hdrLen+n]
+
+	if keys == nil {
+		p.Payload = payload
+		return p, nil
+	}
+
+	flags := payloadEncrypted | payloadAuthenticated
+	if b[5]&byte(flags) != byte(flags) {
+		return nil, errNotEncrypted
+	}
+
+	if len(b) < hdrLen+n+2+icvLen {
+		return nil, errShortPacket
+	}
+
+	icv := b[len(b)-icvLen:]
+	if !hmac.Equal(icv, hmacSHA1(keys.k1, b[4:len(b)-icvLen])[:icvLen]) {
+		return nil, errIntegrity
+	}
+
+	pl, err := decrypt(keys.k2[:aes.BlockSize], payload)
+	if err != nil {
+		return nil, err
+	}
+	p.Payload = pl
+
+	return p, nil
+}
+
+// encrypt encrypts b using AES-CBC-128 with a random IV, returning the IV
+// followed by the ciphertext.
+func encrypt(key, b []byte) ([]byte, error) {
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Confidentiality pad bytes count upward from 1, followed by the pad
+	// length.
+	pad := aes.BlockSize - (len(b)+1)%aes.BlockSize
+	if pad == aes.BlockSize {
+		pad = 0
+	}
+
+	pt := make([]byte, 0, len(b)+pad+1)
+	pt = append(pt, b...)
+	for i := 1; i <= pad; i++ {
+		pt = append(pt, byte(i))
+	}
+	pt = append(pt, byte(pad))
+
+	out := make([]byte, aes.BlockSize+len(pt))
+	iv := out[:aes.BlockSize]
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	cipher.NewCBCEncrypter(blk, iv).CryptBlocks(out[aes.BlockSize:], pt)
+	return out, nil
+}
+
+// decrypt reverses encrypt.
+func decrypt(key, b []byte) ([]byte, error) {
+	if len(b) < 2*aes.BlockSize || len(b)%aes.BlockSize != 0 {
+		return nil, errBadPadding
+	}
+
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	pt := make([]byte, len(b)-aes.BlockSize)
+	cipher.NewCBCDecrypter(blk, b[:aes.BlockSize]).CryptBlocks(pt, b[aes.BlockSize:])
+
+	pad := int(pt[len(pt)-1])
+	if pad+1 > len(pt) {
+		return nil, errBadPadding
+	}
+
+	return pt[:len(pt)-1-pad], nil
+}
+
+// A message is an IPMI LAN request or response message.
+type message struct {
+	NetFn   byte
+	Command byte
+	Seq     byte
+	Data    []byte
+}
+
+// marshalRequest marshals m as a request from software to the BMC.
+func marshalRequest(m *message) []byte {
+	b := []byte{addrBMC, m.NetFn << 2, 0, addrSoftware, m.Seq << 2, m.Command}
+	b[2] = checksum(b[0:2])
+	b = append(b, m.Data...)
+
+	return append(b, checksum(b[3:]))
+}
+
+// unmarshalMessage unmarshals a request or response message from b,
+// verifying its checksums. The data field of a response begins with its
+// completion code.
+func unmarshalMessage(b []byte) (*message, error) {
+	if len(b) < 7 {
+		return nil, errShortPacket
+	}
+
+	if checksum(b[0:2]) != b[2] || checksum(b[3:len(b)-1]) != b[len(b)-1] {
+		return nil, errBadChecksum
+	}
+
+	return &message{
+		NetFn:   b[1] >> 2,
+		Seq:     b[4] >> 2,
+		Command: b[5],
+		Data:    b[6 : len(b)-1],
+	}, nil
+}
+
+// checksum computes the two's complement checksum of b.
+func checksum(b []byte) byte {
+	var c byte
+	for _, v := range b {
+		c += v
+	}
+
+	return -c
+}
+
+// hmacSHA1 computes HMAC-SHA1 of the concatenation of b using key.
+func hmacSHA1(key []byte, b ...[]byte) []byte {
+	h := hmac.New(sha1.New, key)
+	for _, v := range b {
+		h.Write(v)
+	}
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,97 @@
+package wol
+
+import (
+	"net"
+
+	"github.com/mdlayher/ethernet"
+	"github.com/mdlayher/packet"
+)
+
+// A Listener receives Wake-on-LAN magic packets from a UDP or Ethernet
+// socket.
+type Listener struct {
+	p   net.PacketConn
+	raw bool
+}
+
+// Listen creates a Listener which receives Wake-on-LAN magic packets sent to
+// the specified UDP address, such as ":9".
+func Listen(addr string) (*Listener, error) {
+	p, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewListenerConn(p), nil
+}
+
+// NewListenerConn creates a Listener which receives Wake-on-LAN magic packets
+// using an existing net.PacketConn. Each packet read from p must contain only
+// a magic packet, as with a UDP socket. NewListenerConn is useful for
+// testing, in combination with package woltest.
+//
+// Closing the Listener closes p.
+func NewListenerConn(p net.PacketConn) *Listener {
+	return &Listener{
+		p: p,
+	}
+}
+
+// ListenRaw creates a Listener which receives Wake-on-LAN magic packets
+// carried directly in Ethernet frames on the specified network interface.
+//
+// Like NewRawClient, ListenRaw typically requires elevated user privileges.
+func ListenRaw(ifi *net.Interface) (*Listener, error) {
+	p, err := packet.Listen(ifi, packet.Raw, EtherType, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Listener{
+		p:   p,
+		raw: true,
+	}, nil
+}
+
+// Close closes a Listener's socket.
+func (l *Listener) Close() error {
+	return l.p.Close()
+}
+
+// Addr returns the local network address of a Listener's socket.
+func (l *Listener) Addr() net.Addr {
+	return l.p.LocalAddr()
+}
+
+// Receive blocks until a valid magic packet is received, and returns it
+// along with the address of its sender.
+//
+// Any data which cannot be decoded as a magic packet is silently discarded.
+func (l *Listener) Receive() (*MagicPacket, net.Addr, error) {
+	// Magic packets are small, but leave room for a full Ethernet frame.
+	b := make([]byte, 1500)
+
+	for {
+		n, addr, err := l.p.ReadFrom(b)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pb := b[:n]
+		if l.raw {
+			f := new(ethernet.Frame)
+			if err := f.UnmarshalBinary(pb); err != nil {
+				continue
+			}
+
+			pb = f.Payload
+		}
+
+		p := new(MagicPacket)
+		if err := p.UnmarshalBinary(pb); err != nil {
+			continue
+		}
+
+		return p, addr, nil
+	}
+}
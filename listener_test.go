@@ -0,0 +1,73 @@
+package wol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListenerReceive(t *testing.T) {
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	var tests = []struct {
+		name string
+		send func(c *Client, addr string) error
+		want *MagicPacket
+	}{
+		{
+			name: "wake",
+			send: func(c *Client, addr string) error {
+				return c.WakePassword(addr, target, []byte{1, 2, 3, 4})
+			},
+			want: &MagicPacket{
+				Target:   target,
+				Password: []byte{1, 2, 3, 4},
+			},
+		},
+		{
+			name: "sleep",
+			send: func(c *Client, addr string) error {
+				return c.Sleep(addr, target)
+			},
+			want: &MagicPacket{
+				Target:   net.HardwareAddr{0xad, 0xde, 0xef, 0xbe, 0xad, 0xde},
+				Password: []byte{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := Listen("127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+			defer l.Close()
+
+			c, err := NewClient()
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer c.Close()
+
+			// Send garbage first to ensure it is skipped by the Listener.
+			if _, err := c.p.WriteTo([]byte("hello"), l.Addr()); err != nil {
+				t.Fatalf("failed to send garbage: %v", err)
+			}
+
+			if err := tt.send(c, l.Addr().String()); err != nil {
+				t.Fatalf("failed to send: %v", err)
+			}
+
+			p, _, err := l.Receive()
+			if err != nil {
+				t.Fatalf("failed to receive: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, p); diff != "" {
+				t.Fatalf("unexpected MagicPacket (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Package metrics exports Prometheus metrics for Wake-on-LAN clients, and for
+// the servers and relays built on them.
+//
+// A Metrics implements wol.Observer, so it can instrument the send path of a
+// wol.Client or wol.RawClient by setting their Observer fields. Servers and
+// relays, such as those in packages mqtt and wolgrpc, accept a Metrics to
+// count the requests they receive, relay, and reject.
+//
+// Metrics are labeled by transport ("udp" or "raw") and network interface.
+// The interface label is empty for UDP, where the operating system selects
+// the network interface.
+package metrics
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/mdlayher/wol"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "wol"
+
+// Causes of send errors, used as the cause label of
+// wol_send_errors_total.
+const (
+	causeTimeout     = "timeout"
+	causePermission  = "permission"
+	causeUnreachable = "unreachable"
+	causeAddress     = "address"
+	causeClosed      = "closed"
+	causeOther       = "other"
+)
+
+var _ wol.Observer = &Metrics{}
+
+// Metrics contains Prometheus metrics for Wake-on-LAN activity. All methods
+// are no-ops on a nil *Metrics, so servers may call them unconditionally.
+type Metrics struct {
+	sent       *prometheus.CounterVec
+	sendErrors *prometheus.CounterVec
+	received   *prometheus.CounterVec
+	relayed    *prometheus.CounterVec
+	rejected   *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	alive      *prometheus.GaugeVec
+}
+
+// New creates Metrics and registers them with reg.
+func New(reg prometheus.Registerer) *Metrics {
+	labels := []string{"transport", "interface"}
+
+	m := &Metrics{
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "packets_sent_total",
+			Help:      "Number of magic packets sent.",
+		}, labels),
+
+		sendErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "send_errors_total",
+			Help:      "Number of failed attempts to send magic packets, by cause.",
+		}, append(labels, "cause")),
+
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "packets_received_total",
+			Help:      "Number of magic packets received by listeners.",
+		}, labels),
+
+		relayed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "packets_relayed_total",
+			Help:      "Number of magic packets sent on behalf of remote requests.",
+		}, labels),
+
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "packets_rejected_total",
+			Help:      "Number of magic packets or wake requests rejected, by reason.",
+		}, append(labels, "reason")),
+
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "wake_to_online_seconds",
+			Help:      "Time from sending a magic packet to a host until it is observed alive.",
+			Buckets:   []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+		}, []string{"host"}),
+
+		alive: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "host_alive",
+			Help:      "Whether a host passed its most recent liveness check (1) or not (0).",
+		}, []string{"host"}),
+	}
+
+	reg.MustRegister(
+		m.sent,
+		m.sendErrors,
+		m.received,
+		m.relayed,
+		m.rejected,
+		m.latency,
+		m.alive,
+	)
+
+	return m
+}
+
+// Handler returns an http.Handler which serves metrics gathered from g in
+// the Prometheus exposition format, typically at "/metrics".
+func Handler(g prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+}
+
+// ObserveSend implements wol.Observer, counting magic packets sent and send
+// errors.
+func (m *Metrics) ObserveSend(e *wol.SendEvent) {
+	if m == nil {
+		return
+	}
+
+	ifi := ""
+	if e.Transport == wol.TransportRaw {
+		ifi = e.Source
+	}
+
+	if e.Err != nil {
+		m.sendErrors.WithLabelValues(e.Transport, ifi, cause(e.Err)).Inc()
+		return
+	}
+
+	m.sent.WithLabelValues(e.Transport, ifi).Inc()
+}
+
+// Received counts a magic packet received using transport on network
+// interface ifi.
+func (m *Metrics) Received(transport, ifi string) {
+	if m == nil {
+		return
+	}
+
+	m.received.WithLabelValues(transport, ifi).Inc()
+}
+
+// Relayed counts a magic packet sent using transport on network interface
+// ifi on behalf of a remote request.
+func (m *Metrics) Relayed(transport, ifi string) {
+	if m == nil {
+		return
+	}
+
+	m.relayed.WithLabelValues(transport, ifi).Inc()
+}
+
+// Rejected counts a magic packet or wake request which was rejected for
+// reason, such as "unknown_host". transport and ifi may be empty if the
+// request was rejected before they were known.
+func (m *Metrics) Rejected(transport, ifi, reason string) {
+	if m == nil {
+		return
+	}
+
+	m.rejected.WithLabelValues(transport, ifi, reason).Inc()
+}
+
+// WakeLatency records the time d from sending a magic packet to host until
+// the host was observed alive.
+func (m *Metrics) WakeLatency(host string, d time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.latency.WithLabelValues(host).Observe(d.Seconds())
+}
+
+// SetAlive records whether host passed its most recent liveness check.
+func (m *Metrics) SetAlive(host string, alive bool) {
+	if m == nil {
+		return
+	}
+
+	v := 0.0
+	if alive {
+		v = 1
+	}
+
+	m.alive.WithLabelValues(host).Set(v)
+}
+
+// cause classifies a send error for the cause label.
+func cause(err error) string {
+	var (
+		nerr  net.Error
+		dnerr *net.DNSError
+		aerr  *net.AddrError
+		perr  *net.ParseError
+	)
+
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.As(err, &nerr) && nerr.Timeout():
+		return causeTimeout
+	case errors.Is(err, fs.ErrPermission):
+		return causePermission
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH):
+		return causeUnreachable
+	case errors.As(err, &dnerr), errors.As(err, &aerr), errors.As(err, &perr):
+		return causeAddress
+	case errors.Is(err, net.ErrClosed):
+		return causeClosed
+	default:
+		return causeOther
+	}
+}
@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/woltest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsClients(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := New(reg)
+
+	n := woltest.NewNetwork()
+	ifi, rc := n.ListenRaw()
+
+	pc, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	c := wol.NewClientConn(pc)
+	c.Observer = m
+	defer c.Close()
+
+	r := wol.NewRawClientConn(ifi, rc)
+	r.Observer = m
+	defer r.Close()
+
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	addr := (&net.UDPAddr{IP: n.Broadcast(), Port: 9}).String()
+
+	for i := 0; i < 2; i++ {
+		if err := c.Wake(addr, target); err != nil {
+			t.Fatalf("failed to wake over UDP: %v", err)
+		}
+	}
+	if err := r.Wake(target); err != nil {
+		t.Fatalf("failed to wake over Ethernet: %v", err)
+	}
+	if err := c.Wake("foo", target); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	want := `
+# HELP wol_packets_sent_total Number of magic packets sent.
+# TYPE wol_packets_sent_total counter
+wol_packets_sent_total{interface="",transport="udp"} 2
+wol_packets_sent_total{interface="woltest1",transport="raw"} 1
+# HELP wol_send_errors_total Number of failed attempts to send magic packets, by cause.
+# TYPE wol_send_errors_total counter
+wol_send_errors_total{cause="address",interface="",transport="udp"} 1
+`
+
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"wol_packets_sent_total", "wol_send_errors_total"); err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := New(reg)
+
+	m.Received(wol.TransportRaw, "eth0")
+	m.Relayed(wol.TransportUDP, "")
+	m.Rejected("", "", "unknown_host")
+	m.WakeLatency("desktop", 4*time.Second)
+	m.SetAlive("desktop", true)
+	m.SetAlive("nas", false)
+
+	srv := httptest.NewServer(Handler(reg))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to get metrics: %v", err)
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+
+	for _, s := range []string{
+		`wol_packets_received_total{interface="eth0",transport="raw"} 1`,
+		`wol_packets_relayed_total{interface="",transport="udp"} 1`,
+		`wol_packets_rejected_total{interface="",reason="unknown_host",transport=""} 1`,
+		`wol_wake_to_online_seconds_bucket{host="desktop",le="5"} 1`,
+		`wol_wake_to_online_seconds_bucket{host="desktop",le="2.5"} 0`,
+		`wol_host_alive{host="desktop"} 1`,
+		`wol_host_alive{host="nas"} 0`,
+	} {
+		if !strings.Contains(string(b), s) {
+			t.Errorf("metrics do not contain %q", s)
+		}
+	}
+}
+
+func TestMetricsNil(t *testing.T) {
+	// All methods must be safe to call on nil Metrics.
+	var m *Metrics
+	m.ObserveSend(&wol.SendEvent{})
+	m.Received("", "")
+	m.Relayed("", "")
+	m.Rejected("", "", "")
+	m.WakeLatency("", 0)
+	m.SetAlive("", false)
+}
+
+func Test_cause(t *testing.T) {
+	var tests = []struct {
+		err  error
+		want string
+	}{
+		{
+			err:  os.ErrDeadlineExceeded,
+			want: causeTimeout,
+		},
+		{
+			err:  &net.OpError{Op: "write", Err: os.NewSyscallError("sendto", syscall.EACCES)},
+			want: causePermission,
+		},
+		{
+			err:  &net.OpError{Op: "write", Err: os.NewSyscallError("sendto", syscall.ENETUNREACH)},
+			want: causeUnreachable,
+		},
+		{
+			err:  &net.AddrError{Err: "missing port in address", Addr: "foo"},
+			want: causeAddress,
+		},
+		{
+			err:  fmt.Errorf("write: %w", net.ErrClosed),
+			want: causeClosed,
+		},
+		{
+			err:  errors.New("invalid target"),
+			want: causeOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, cause(tt.err)); diff != "" {
+				t.Fatalf("unexpected cause (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
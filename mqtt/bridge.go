@@ -0,0 +1,592 @@
+// Package mqtt implements a bridge which wakes machines in response to MQTT
+// messages, for use with home and office automation systems.
+//
+// A Bridge subscribes to wake commands, wakes machines by hardware address or
+// inventory host name, and publishes the result of each command and the
+// liveness of each host back to the broker. It can also publish Home
+// Assistant MQTT discovery payloads, so each host appears as a button which
+// wakes it and a sensor which reports whether it is alive.
+//
+// With the default topic prefix of "wol", a Bridge uses the following topics:
+//
+//   - wol/wake: wake commands containing a hardware address, a host name, or
+//     a JSON object such as {"target": "nas", "id": "1"}
+//   - wol/<host>/wake: wake commands for a single host; the payload is ignored
+//   - wol/status: the result of each wake command, as a JSON object
+//   - wol/<host>/state: "online" or "offline", retained
+//   - wol/bridge: "online" or "offline" availability of the Bridge, retained
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"github.com/mdlayher/wol/metrics"
+	"github.com/mdlayher/wol/tracker"
+)
+
+// Defaults used when a Bridge's fields are unset.
+const (
+	defaultPrefix   = "wol"
+	defaultClientID = "wol-bridge"
+	defaultInterval = 30 * time.Second
+)
+
+// Payloads published to state and availability topics.
+const (
+	online  = "online"
+	offline = "offline"
+)
+
+// A WakeFunc wakes a single host.
+type WakeFunc func(h *inventory.Host) error
+
+// A CheckFunc returns a wol.Check which reports whether a host is alive. It
+// returns nil if the host's liveness cannot be checked.
+type CheckFunc func(h *inventory.Host) wol.Check
+
+// A Bridge wakes machines in response to MQTT messages.
+type Bridge struct {
+	// Broker is the URL of the MQTT broker, such as "tcp://localhost:1883".
+	Broker string
+
+	// ClientID, Username, and Password optionally specify the client ID and
+	// credentials used to connect to the broker. If ClientID is empty,
+	// "wol-bridge" is used.
+	ClientID string
+	Username string
+	Password string
+
+	// Prefix is the prefix for all topics used by the Bridge, other than
+	// discovery topics. If empty, "wol" is used.
+	Prefix string
+
+	// DiscoveryPrefix optionally enables Home Assistant MQTT discovery
+	// using the specified prefix, typically "homeassistant".
+	DiscoveryPrefix string
+
+	// Inventory optionally specifies hosts which can be woken by name. Only
+	// hosts in Inventory have per-host topics and discovery payloads.
+	Inventory *inventory.Inventory
+
+	// Wake optionally wakes a host. If nil, inventory.Host.Wake is used.
+	Wake WakeFunc
+
+	// Observer is optionally notified of each attempt to send a magic packet
+	// using the default Wake function, such as for an audit log. The
+	// Identity of each event is "mqtt:" followed by the command's topic.
+	Observer wol.Observer
+
+	// Metrics optionally records metrics for wake commands and for the
+	// liveness of each host, including the time from waking a host until it
+	// is observed alive. Magic packets sent using the default Wake function
+	// and their errors are also counted.
+	Metrics *metrics.Metrics
+
+	// Check optionally specifies how to check whether a host is alive. If
+	// nil, tracker.DefaultProbe is used, which honors each host's probe
+	// option in the inventory.
+	Check CheckFunc
+
+	// Interval specifies how often host liveness is checked. If zero, a
+	// default of 30 seconds is used.
+	Interval time.Duration
+
+	// woken records the time at which each host was most recently woken,
+	// until it is observed alive.
+	mu    sync.Mutex
+	woken map[string]time.Time
+}
+
+// A Status is the result of a wake command, published to the status topic.
+type Status struct {
+	// ID is copied from the command, if set.
+	ID string `json:"id,omitempty"`
+
+	// Target is the target of the command, and Name and MAC identify the
+	// host it resolved to, if any.
+	Target string `json:"target"`
+	Name   string `json:"name,omitempty"`
+	MAC    string `json:"mac,omitempty"`
+
+	// Error is empty if the magic packet was sent successfully.
+	Error string `json:"error,omitempty"`
+
+	// Time is the time at which the command was processed.
+	Time time.Time `json:"time"`
+}
+
+// A command is a decoded wake command.
+type command struct {
+	ID     string `json:"id"`
+	Target string `json:"target"`
+}
+
+// Run connects to the broker and processes wake commands until ctx is
+// canceled. Run reconnects automatically if the connection to the broker is
+// lost after it is first established.
+func (b *Bridge) Run(ctx context.Context) error {
+	if b.Broker == "" {
+		return errors.New("mqtt: bridge has no broker")
+	}
+
+	o := paho.NewClientOptions().
+		AddBroker(b.Broker).
+		SetClientID(b.clientID()).
+		SetUsername(b.Username).
+		SetPassword(b.Password).
+		SetWill(b.topic("bridge"), offline, 1, true).
+		// Wake commands may block, so handle each message concurrently.
+		SetOrderMatters(false)
+
+	// Subscriptions and discovery payloads are (re)established on every
+	// connection.
+	connected := make(chan error, 1)
+	o.SetOnConnectHandler(func(c paho.Client) {
+		err := b.setup(c)
+		select {
+		case connected <- err:
+		default:
+		}
+	})
+
+	c := paho.NewClient(o)
+	if err := wait(ctx, c.Connect()); err != nil {
+		return fmt.Errorf("mqtt: failed to connect: %w", err)
+	}
+	defer c.Disconnect(250)
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-connected:
+		if err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.monitor(ctx, c)
+	}()
+
+	<-ctx.Done()
+
+	// Publish availability explicitly; the will is only sent by the broker
+	// when the connection is lost.
+	_ = wait(context.Background(), c.Publish(b.topic("bridge"), 1, true, offline))
+	return nil
+}
+
+// setup subscribes to command topics and publishes availability and
+// discovery payloads.
+func (b *Bridge) setup(c paho.Client) error {
+	filters := map[string]byte{
+		b.topic("wake"):   1,
+		b.topic("+/wake"): 1,
+	}
+	if err := wait(context.Background(), c.SubscribeMultiple(filters, func(c paho.Client, m paho.Message) {
+		b.handle(c, m)
+	})); err != nil {
+		return fmt.Errorf("mqtt: failed to subscribe: %w", err)
+	}
+
+	if b.DiscoveryPrefix != "" && b.Inventory != nil {
+		for _, h := range b.Inventory.Hosts {
+			if !validName(h.Name) {
+				continue
+			}
+
+			for _, d := range b.discovery(h) {
+				if err := b.publish(c, d.topic, true, d.config); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return wait(context.Background(), c.Publish(b.topic("bridge"), 1, true, online))
+}
+
+// handle processes a single wake command.
+func (b *Bridge) handle(c paho.Client, m paho.Message) {
+	var cmd command
+	if name, ok := b.hostTopic(m.Topic()); ok {
+		// The payload of a per-host command, such as Home Assistant's
+		// "PRESS", is ignored.
+		cmd.Target = name
+	} else {
+		cmd = decodeCommand(m.Payload())
+	}
+
+	s := &Status{
+		ID:     cmd.ID,
+		Target: cmd.Target,
+	}
+
+	if err := b.wake(cmd.Target, "mqtt:"+m.Topic(), s); err != nil {
+		s.Error = err.Error()
+	}
+	s.Time = time.Now()
+
+	_ = b.publish(c, b.topic("status"), false, s)
+}
+
+// wake resolves target and wakes it on behalf of identity, filling in s with
+// the host's details.
+func (b *Bridge) wake(target, identity string, s *Status) error {
+	if target == "" {
+		b.Metrics.Rejected("", "", "no_target")
+		return errors.New("no target")
+	}
+
+	h, err := b.resolve(target)
+	if err != nil {
+		b.Metrics.Rejected("", "", "unknown_host")
+		return err
+	}
+
+	s.Name = h.Name
+	s.MAC = h.MAC.String()
+
+	if b.Wake != nil {
+		err = b.Wake(h)
+	} else {
+		err = b.defaultWake(h, identity)
+	}
+	if err != nil {
+		return err
+	}
+
+	if h.Name != "" {
+		b.mu.Lock()
+		if b.woken == nil {
+			b.woken = make(map[string]time.Time)
+		}
+		b.woken[h.Name] = time.Now()
+		b.mu.Unlock()
+	}
+
+	return nil
+}
+
+// defaultWake wakes h on behalf of identity using the default Wake
+// function, notifying b's Observer and Metrics.
+func (b *Bridge) defaultWake(h *inventory.Host, identity string) error {
+	var obs []wol.Observer
+	if b.Observer != nil {
+		obs = append(obs, &identityObserver{o: b.Observer, identity: identity})
+	}
+	if b.Metrics != nil {
+		obs = append(obs, b.Metrics)
+	}
+
+	var o wol.Observer
+	if len(obs) > 0 {
+		o = wol.MultiObserver(obs...)
+	}
+
+	if err := h.Wake(context.Background(), o); err != nil {
+		return err
+	}
+
+	switch {
+	case h.Dest != "":
+		b.Metrics.Relayed(destTransport(h.Dest), "")
+	case h.Interface != "":
+		b.Metrics.Relayed(wol.TransportRaw, h.Interface)
+	default:
+		b.Metrics.Relayed(wol.TransportUDP, "")
+	}
+
+	return nil
+}
+
+// checked records the result of a liveness check for the host named name,
+// and the time taken to wake it if it has since come online.
+func (b *Bridge) checked(name string, alive bool) {
+	b.Metrics.SetAlive(name, alive)
+	if !alive {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t, ok := b.woken[name]; ok {
+		b.Metrics.WakeLatency(name, time.Since(t))
+		delete(b.woken, name)
+	}
+}
+
+// resolve resolves s as either a hardware address or the name of a host in
+// b's inventory.
+func (b *Bridge) resolve(s string) (*inventory.Host, error) {
+	inv := b.Inventory
+	if inv == nil {
+		inv = &inventory.Inventory{}
+	}
+
+	if mac, err := net.ParseMAC(s); err == nil {
+		if h, ok := inv.LookupMAC(mac); ok {
+			return h, nil
+		}
+
+		return &inventory.Host{MAC: mac}, nil
+	}
+
+	if h, ok := inv.Lookup(s); ok {
+		return h, nil
+	}
+
+	return nil, fmt.Errorf("%q is neither a hardware address nor a known host", s)
+}
+
+// monitor periodically publishes the liveness of each host until ctx is
+// canceled. States are only published when they change.
+func (b *Bridge) monitor(ctx context.Context, c paho.Client) {
+	if b.Inventory == nil {
+		return
+	}
+
+	check := b.Check
+	if check == nil {
+		check = tracker.DefaultProbe
+	}
+
+	type hostCheck struct {
+		h     *inventory.Host
+		check wol.Check
+	}
+
+	var checks []hostCheck
+	for _, h := range b.Inventory.Hosts {
+		if !validName(h.Name) {
+			continue
+		}
+
+		if fn := check(h); fn != nil {
+			checks = append(checks, hostCheck{h: h, check: fn})
+		}
+	}
+	if len(checks) == 0 {
+		return
+	}
+
+	interval := b.Interval
+	if interval == 0 {
+		interval = defaultInterval
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	states := make(map[*inventory.Host]string)
+	for {
+		for _, hc := range checks {
+			// Bound each check so one unresponsive host cannot delay the
+			// others indefinitely.
+			cctx, cancel := context.WithTimeout(ctx, interval)
+			state := online
+			if err := hc.check(cctx); err != nil {
+				state = offline
+			}
+			cancel()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			// Only hosts which come online after being woken count
+			// towards wake latency.
+			if states[hc.h] == online {
+				b.forget(hc.h.Name)
+			}
+			b.checked(hc.h.Name, state == online)
+			if states[hc.h] == state {
+				continue
+			}
+
+			if err := wait(ctx, c.Publish(b.topic(hc.h.Name+"/state"), 1, true, state)); err == nil {
+				states[hc.h] = state
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// forget discards the time at which the host named name was woken, such as
+// when it was already alive.
+func (b *Bridge) forget(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.woken, name)
+}
+
+// A discoveryConfig is a Home Assistant MQTT discovery payload and the topic
+// it is published to.
+type discoveryConfig struct {
+	topic  string
+	config interface{}
+}
+
+// discovery returns the Home Assistant discovery payloads for h.
+func (b *Bridge) discovery(h *inventory.Host) []discoveryConfig {
+	id := "wol_" + strings.ReplaceAll(h.MAC.String(), ":", "")
+	device := map[string]interface{}{
+		"identifiers": []string{id},
+		"name":        h.Name,
+		"connections": [][]string{{"mac", h.MAC.String()}},
+	}
+
+	cfgs := []discoveryConfig{{
+		topic: fmt.Sprintf("%s/button/%s/config", b.DiscoveryPrefix, id),
+		config: map[string]interface{}{
+			"name":               "Wake",
+			"unique_id":          id + "_wake",
+			"command_topic":      b.topic(h.Name + "/wake"),
+			"availability_topic": b.topic("bridge"),
+			"icon":               "mdi:power",
+			"device":             device,
+		},
+	}}
+
+	check := b.Check
+	if check == nil {
+		check = tracker.DefaultProbe
+	}
+	if check(h) != nil {
+		cfgs = append(cfgs, discoveryConfig{
+			topic: fmt.Sprintf("%s/binary_sensor/%s/config", b.DiscoveryPrefix, id),
+			config: map[string]interface{}{
+				"name":         "Online",
+				"unique_id":    id + "_online",
+				"state_topic":  b.topic(h.Name + "/state"),
+				"payload_on":   online,
+				"payload_off":  offline,
+				"device_class": "connectivity",
+				"device":       device,
+			},
+		})
+	}
+
+	return cfgs
+}
+
+// publish publishes v as JSON to topic.
+func (b *Bridge) publish(c paho.Client, topic string, retain bool, v interface{}) error {
+	p, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := wait(context.Background(), c.Publish(topic, 1, retain, p)); err != nil {
+		return fmt.Errorf("mqtt: failed to publish to %q: %w", topic, err)
+	}
+
+	return nil
+}
+
+// hostTopic reports whether topic is a per-host command topic, and returns
+// the host's name if so.
+func (b *Bridge) hostTopic(topic string) (string, bool) {
+	s := strings.TrimPrefix(topic, b.prefix()+"/")
+	name := strings.TrimSuffix(s, "/wake")
+	if s == topic || name == s || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+
+	return name, true
+}
+
+// topic returns the full topic for the topic suffix s.
+func (b *Bridge) topic(s string) string {
+	return b.prefix() + "/" + s
+}
+
+func (b *Bridge) prefix() string {
+	if b.Prefix == "" {
+		return defaultPrefix
+	}
+
+	return b.Prefix
+}
+
+func (b *Bridge) clientID() string {
+	if b.ClientID == "" {
+		return defaultClientID
+	}
+
+	return b.ClientID
+}
+
+// decodeCommand decodes a wake command from either a JSON object or a plain
+// text target.
+func decodeCommand(p []byte) command {
+	s := strings.TrimSpace(string(p))
+
+	var cmd command
+	if strings.HasPrefix(s, "{") {
+		if err := json.Unmarshal([]byte(s), &cmd); err == nil {
+			cmd.Target = strings.TrimSpace(cmd.Target)
+			return cmd
+		}
+	}
+
+	return command{Target: s}
+}
+
+// validName reports whether name can be used in an MQTT topic.
+func validName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "/+#")
+}
+
+// wait waits for t to complete or ctx to be canceled.
+func wait(ctx context.Context, t paho.Token) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.Done():
+		return t.Error()
+	}
+}
+
+// destTransport returns the scheme of the destination URL dest.
+func destTransport(dest string) string {
+	u, err := wol.ParseDestination(dest)
+	if err != nil {
+		return ""
+	}
+
+	return u.Scheme
+}
+
+// An identityObserver sets the Identity of each wol.SendEvent before passing
+// it to another wol.Observer.
+type identityObserver struct {
+	o        wol.Observer
+	identity string
+}
+
+func (o *identityObserver) ObserveSend(e *wol.SendEvent) {
+	e.Identity = o.identity
+	o.o.ObserveSend(e)
+}
@@ -0,0 +1,538 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"github.com/mdlayher/wol/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBridge(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader(strings.Join([]string{
+		"00:12:7f:eb:6b:40 desktop ip=192.0.2.10",
+		"00:12:7f:eb:6b:41 nas",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("failed to parse inventory: %v", err)
+	}
+
+	broker := newFakeBroker(t)
+
+	var alive atomic.Bool
+	woken := make(chan string, 1)
+	b := &Bridge{
+		Broker:          broker.url(),
+		DiscoveryPrefix: "homeassistant",
+		Inventory:       inv,
+		Interval:        10 * time.Millisecond,
+		Wake: func(h *inventory.Host) error {
+			if h.Name == "nas" {
+				return errors.New("no route to host")
+			}
+
+			woken <- h.MAC.String()
+			return nil
+		},
+		Check: func(h *inventory.Host) wol.Check {
+			if h.Name != "desktop" {
+				return nil
+			}
+
+			return func(_ context.Context) error {
+				if !alive.Load() {
+					return errors.New("offline")
+				}
+
+				return nil
+			}
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errC := make(chan error, 1)
+	go func() { errC <- b.Run(ctx) }()
+
+	// Discovery payloads are published before the Bridge announces itself
+	// online, and only hosts with liveness checks have sensors.
+	broker.expect(t, "wol/bridge", online)
+	for _, topic := range []string{
+		"homeassistant/button/wol_00127feb6b40/config",
+		"homeassistant/binary_sensor/wol_00127feb6b40/config",
+		"homeassistant/button/wol_00127feb6b41/config",
+	} {
+		if _, ok := broker.retained(topic); !ok {
+			t.Fatalf("no discovery payload published to %q", topic)
+		}
+	}
+	if _, ok := broker.retained("homeassistant/binary_sensor/wol_00127feb6b41/config"); ok {
+		t.Fatal("unexpected sensor for host without liveness check")
+	}
+
+	broker.expect(t, "wol/desktop/state", offline)
+
+	var tests = []struct {
+		name    string
+		topic   string
+		payload string
+		woken   string
+		want    *Status
+	}{
+		{
+			name:    "name",
+			topic:   "wol/wake",
+			payload: "desktop\n",
+			woken:   "00:12:7f:eb:6b:40",
+			want: &Status{
+				Target: "desktop",
+				Name:   "desktop",
+				MAC:    "00:12:7f:eb:6b:40",
+			},
+		},
+		{
+			name:    "JSON hardware address",
+			topic:   "wol/wake",
+			payload: `{"target": "00:12:7f:eb:6b:99", "id": "42"}`,
+			woken:   "00:12:7f:eb:6b:99",
+			want: &Status{
+				ID:     "42",
+				Target: "00:12:7f:eb:6b:99",
+				MAC:    "00:12:7f:eb:6b:99",
+			},
+		},
+		{
+			name:    "host topic",
+			topic:   "wol/desktop/wake",
+			payload: "PRESS",
+			woken:   "00:12:7f:eb:6b:40",
+			want: &Status{
+				Target: "desktop",
+				Name:   "desktop",
+				MAC:    "00:12:7f:eb:6b:40",
+			},
+		},
+		{
+			name:    "wake error",
+			topic:   "wol/nas/wake",
+			payload: "PRESS",
+			want: &Status{
+				Target: "nas",
+				Name:   "nas",
+				MAC:    "00:12:7f:eb:6b:41",
+				Error:  "no route to host",
+			},
+		},
+		{
+			name:    "unknown host",
+			topic:   "wol/wake",
+			payload: "bogus",
+			want: &Status{
+				Target: "bogus",
+				Error:  `"bogus" is neither a hardware address nor a known host`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker.publish(tt.topic, tt.payload)
+
+			var got Status
+			if err := json.Unmarshal(broker.expect(t, "wol/status", ""), &got); err != nil {
+				t.Fatalf("failed to unmarshal status: %v", err)
+			}
+			if got.Time.IsZero() {
+				t.Fatal("status has no time")
+			}
+			got.Time = time.Time{}
+
+			if diff := cmp.Diff(tt.want, &got); diff != "" {
+				t.Fatalf("unexpected status (-want +got):\n%s", diff)
+			}
+
+			if tt.woken == "" {
+				return
+			}
+
+			select {
+			case mac := <-woken:
+				if mac != tt.woken {
+					t.Fatalf("unexpected host woken: %s", mac)
+				}
+			default:
+				t.Fatal("no host was woken")
+			}
+		})
+	}
+
+	alive.Store(true)
+	broker.expect(t, "wol/desktop/state", online)
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to run bridge: %v", err)
+	}
+
+	broker.expect(t, "wol/bridge", offline)
+}
+
+func TestBridgeMetrics(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader("00:12:7f:eb:6b:40 desktop"))
+	if err != nil {
+		t.Fatalf("failed to parse inventory: %v", err)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	b := &Bridge{
+		Inventory: inv,
+		Metrics:   metrics.New(reg),
+		Wake:      func(_ *inventory.Host) error { return nil },
+	}
+
+	for _, target := range []string{"desktop", "laptop", ""} {
+		_ = b.wake(target, "test", &Status{})
+	}
+
+	// Only the first liveness check after waking records latency.
+	b.checked("desktop", false)
+	b.checked("desktop", true)
+	b.checked("desktop", true)
+
+	want := `
+# HELP wol_host_alive Whether a host passed its most recent liveness check (1) or not (0).
+# TYPE wol_host_alive gauge
+wol_host_alive{host="desktop"} 1
+# HELP wol_packets_rejected_total Number of magic packets or wake requests rejected, by reason.
+# TYPE wol_packets_rejected_total counter
+wol_packets_rejected_total{interface="",reason="no_target",transport=""} 1
+wol_packets_rejected_total{interface="",reason="unknown_host",transport=""} 1
+`
+
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"wol_host_alive", "wol_packets_rejected_total"); err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+
+	if n := testutil.CollectAndCount(reg, "wol_wake_to_online_seconds"); n != 1 {
+		t.Fatalf("expected 1 latency histogram, but got %d", n)
+	}
+}
+
+func TestBridgeHostTopic(t *testing.T) {
+	var tests = []struct {
+		topic string
+		name  string
+		ok    bool
+	}{
+		{topic: "wol/nas/wake", name: "nas", ok: true},
+		{topic: "wol/wake"},
+		{topic: "wol//wake"},
+		{topic: "wol/a/b/wake"},
+		{topic: "other/nas/wake"},
+		{topic: "wol/nas/state"},
+	}
+
+	b := &Bridge{}
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			name, ok := b.hostTopic(tt.topic)
+			if name != tt.name || ok != tt.ok {
+				t.Fatalf("unexpected result: %q, %v", name, ok)
+			}
+		})
+	}
+}
+
+// A message is an MQTT application message.
+type message struct {
+	topic   string
+	payload []byte
+}
+
+// A fakeBroker is an in-process MQTT 3.1.1 broker which supports the subset
+// of the protocol used by a Bridge. Messages are always delivered with QoS 0.
+type fakeBroker struct {
+	ln       net.Listener
+	messages chan message
+
+	mu     sync.Mutex
+	conns  map[*brokerConn]struct{}
+	retain map[string][]byte
+}
+
+// A brokerConn is a client connection to a fakeBroker.
+type brokerConn struct {
+	c       net.Conn
+	mu      sync.Mutex
+	filters []string
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	b := &fakeBroker{
+		ln:       ln,
+		messages: make(chan message, 64),
+		conns:    make(map[*brokerConn]struct{}),
+		retain:   make(map[string][]byte),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.serve(t, &brokerConn{c: c})
+			}()
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = ln.Close()
+
+		b.mu.Lock()
+		for c := range b.conns {
+			_ = c.c.Close()
+		}
+		b.mu.Unlock()
+
+		wg.Wait()
+	})
+
+	return b
+}
+
+func (b *fakeBroker) url() string { return "tcp://" + b.ln.Addr().String() }
+
+// expect waits for a message published by a client to topic and returns its
+// payload. If want is not empty, the payload must match it.
+func (b *fakeBroker) expect(t *testing.T, topic, want string) []byte {
+	t.Helper()
+
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+
+	for {
+		select {
+		case m := <-b.messages:
+			if m.topic != topic {
+				continue
+			}
+			if want != "" && string(m.payload) != want {
+				t.Fatalf("unexpected payload for %q: %q", topic, m.payload)
+			}
+
+			return m.payload
+		case <-timer.C:
+			t.Fatalf("timed out waiting for message to %q", topic)
+		}
+	}
+}
+
+// retained returns the retained message for topic, if any.
+func (b *fakeBroker) retained(topic string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p, ok := b.retain[topic]
+	return p, ok
+}
+
+// publish delivers a message to all subscribed clients.
+func (b *fakeBroker) publish(topic, payload string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for c := range b.conns {
+		c.deliver(message{topic: topic, payload: []byte(payload)})
+	}
+}
+
+func (b *fakeBroker) serve(t *testing.T, c *brokerConn) {
+	b.mu.Lock()
+	b.conns[c] = struct{}{}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, c)
+		b.mu.Unlock()
+		_ = c.c.Close()
+	}()
+
+	r := bufio.NewReader(c.c)
+	for {
+		typ, flags, body, err := readPacket(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				t.Errorf("fake broker failed to read packet: %v", err)
+			}
+			return
+		}
+
+		switch typ {
+		case 1: // CONNECT
+			c.write(0x20, []byte{0x00, 0x00})
+		case 3: // PUBLISH
+			n := int(binary.BigEndian.Uint16(body[0:2]))
+			m := message{topic: string(body[2 : 2+n])}
+			body = body[2+n:]
+
+			if qos := flags >> 1 & 0x03; qos > 0 {
+				c.write(0x40, body[0:2])
+				body = body[2:]
+			}
+			m.payload = append([]byte(nil), body...)
+
+			b.mu.Lock()
+			if flags&0x01 != 0 {
+				b.retain[m.topic] = m.payload
+			}
+			for other := range b.conns {
+				other.deliver(m)
+			}
+			b.mu.Unlock()
+
+			b.messages <- m
+		case 8: // SUBSCRIBE
+			id, body := body[0:2], body[2:]
+			ack := append([]byte(nil), id...)
+
+			var filters []string
+			for len(body) > 0 {
+				n := int(binary.BigEndian.Uint16(body[0:2]))
+				filters = append(filters, string(body[2:2+n]))
+				body = body[2+n+1:]
+				ack = append(ack, 0x00)
+			}
+
+			c.mu.Lock()
+			c.filters = append(c.filters, filters...)
+			c.mu.Unlock()
+			c.write(0x90, ack)
+		case 12: // PINGREQ
+			c.write(0xd0, nil)
+		case 14: // DISCONNECT
+			return
+		default:
+			t.Errorf("fake broker received unexpected packet type %d", typ)
+			return
+		}
+	}
+}
+
+// deliver sends m to c if c is subscribed to its topic.
+func (c *brokerConn) deliver(m message) {
+	c.mu.Lock()
+	var match bool
+	for _, f := range c.filters {
+		if matchTopic(f, m.topic) {
+			match = true
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	if !match {
+		return
+	}
+
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(m.topic)))
+	b = append(b, m.topic...)
+	c.write(0x30, append(b, m.payload...))
+}
+
+// write writes a packet with the specified first byte and body to c.
+func (c *brokerConn) write(first byte, body []byte) {
+	b := []byte{first}
+
+	n := len(body)
+	for {
+		d := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			d |= 0x80
+		}
+		b = append(b, d)
+		if n == 0 {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = c.c.Write(append(b, body...))
+}
+
+// readPacket reads a single MQTT control packet from r.
+func readPacket(r *bufio.Reader) (typ, flags byte, body []byte, err error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	var n, shift int
+	for {
+		d, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+
+		n |= int(d&0x7f) << shift
+		if d&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+
+	body = make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, 0, nil, err
+	}
+
+	return first >> 4, first & 0x0f, body, nil
+}
+
+// matchTopic reports whether topic matches the subscription filter.
+func matchTopic(filter, topic string) bool {
+	fs, ts := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, f := range fs {
+		switch {
+		case f == "#":
+			return true
+		case i >= len(ts):
+			return false
+		case f != "+" && f != ts[i]:
+			return false
+		}
+	}
+
+	return len(fs) == len(ts)
+}
@@ -0,0 +1,178 @@
+//go:build linux
+
+package nic
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+// sopassLen is the length of a SecureOn password.
+const sopassLen = 6
+
+// A Client queries and configures Wake-on-LAN settings using the ethtool
+// generic netlink family.
+type Client struct {
+	c      *genetlink.Conn
+	family genetlink.Family
+}
+
+// Dial creates a Client which communicates with the kernel's ethtool generic
+// netlink family.
+func Dial() (*Client, error) {
+	c, err := genetlink.Dial(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(c)
+}
+
+// newClient wraps an existing generic netlink connection in a Client.
+func newClient(c *genetlink.Conn) (*Client, error) {
+	f, err := c.GetFamily(unix.ETHTOOL_GENL_NAME)
+	if err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("nic: ethtool netlink family unavailable: %w", err)
+	}
+
+	return &Client{
+		c:      c,
+		family: f,
+	}, nil
+}
+
+// Close closes a Client's netlink connection.
+func (c *Client) Close() error {
+	return c.c.Close()
+}
+
+// WakeOnLAN retrieves the Wake-on-LAN configuration of a network interface.
+func (c *Client) WakeOnLAN(ifi *net.Interface) (*WakeOnLAN, error) {
+	ae := netlink.NewAttributeEncoder()
+	ae.Nested(unix.ETHTOOL_A_WOL_HEADER, header(ifi))
+
+	msgs, err := c.execute(unix.ETHTOOL_MSG_WOL_GET, netlink.Request, ae)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("nic: expected 1 Wake-on-LAN reply, but got %d", len(msgs))
+	}
+
+	w := &WakeOnLAN{Interface: ifi}
+
+	ad, err := netlink.NewAttributeDecoder(msgs[0].Data)
+	if err != nil {
+		return nil, err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+		case unix.ETHTOOL_A_WOL_MODES:
+			ad.Nested(func(nad *netlink.AttributeDecoder) error {
+				w.Enabled, w.Supported = parseBitset(nad)
+				return nil
+			})
+		case unix.ETHTOOL_A_WOL_SOPASS:
+			w.SecureOnPassword = ad.Bytes()
+		}
+	}
+	if err := ad.Err(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// SetWakeOnLAN sets the Wake-on-LAN modes enabled on a network interface.
+// Any modes not present in enabled are disabled.
+//
+// If password is non-nil, it sets the SecureOn password used by
+// ModeMagicSecure, and must be exactly 6 bytes in length.
+//
+// SetWakeOnLAN requires the CAP_NET_ADMIN capability.
+func (c *Client) SetWakeOnLAN(ifi *net.Interface, enabled Mode, password []byte) error {
+	if password != nil && len(password) != sopassLen {
+		return errors.New("nic: SecureOn password must be exactly 6 bytes")
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Nested(unix.ETHTOOL_A_WOL_HEADER, header(ifi))
+	ae.Nested(unix.ETHTOOL_A_WOL_MODES, func(nae *netlink.AttributeEncoder) error {
+		// Set the exact list of enabled modes rather than modifying the
+		// current configuration with a mask.
+		nae.Flag(unix.ETHTOOL_A_BITSET_NOMASK, true)
+		nae.Uint32(unix.ETHTOOL_A_BITSET_SIZE, modeBits)
+		nae.Bytes(unix.ETHTOOL_A_BITSET_VALUE, nlenc.Uint32Bytes(uint32(enabled)))
+		return nil
+	})
+	if password != nil {
+		ae.Bytes(unix.ETHTOOL_A_WOL_SOPASS, password)
+	}
+
+	_, err := c.execute(unix.ETHTOOL_MSG_WOL_SET, netlink.Request|netlink.Acknowledge, ae)
+	return err
+}
+
+// execute sends a generic netlink request with attributes from ae to the
+// ethtool family.
+func (c *Client) execute(cmd uint8, flags netlink.HeaderFlags, ae *netlink.AttributeEncoder) ([]genetlink.Message, error) {
+	b, err := ae.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := c.c.Execute(genetlink.Message{
+		Header: genetlink.Header{
+			Command: cmd,
+			Version: c.family.Version,
+		},
+		Data: b,
+	}, c.family.ID, flags)
+	if err != nil {
+		return nil, fmt.Errorf("nic: ethtool request failed: %w", err)
+	}
+
+	return msgs, nil
+}
+
+// header returns a function which encodes a request header for ifi, asking
+// the kernel to reply with compact bitsets.
+func header(ifi *net.Interface) func(*netlink.AttributeEncoder) error {
+	return func(nae *netlink.AttributeEncoder) error {
+		nae.Uint32(unix.ETHTOOL_A_HEADER_DEV_INDEX, uint32(ifi.Index))
+		nae.Uint32(unix.ETHTOOL_A_HEADER_FLAGS, unix.ETHTOOL_FLAG_COMPACT_BITSETS)
+		return nil
+	}
+}
+
+// parseBitset parses a compact ethtool bitset into its value and mask.
+func parseBitset(ad *netlink.AttributeDecoder) (value, mask Mode) {
+	for ad.Next() {
+		switch ad.Type() {
+		case unix.ETHTOOL_A_BITSET_VALUE:
+			value = Mode(firstUint32(ad.Bytes()))
+		case unix.ETHTOOL_A_BITSET_MASK:
+			mask = Mode(firstUint32(ad.Bytes()))
+		}
+	}
+
+	return value, mask
+}
+
+// firstUint32 decodes the first native endian uint32 word of a bitset,
+// which holds every known Wake-on-LAN mode.
+func firstUint32(b []byte) uint32 {
+	if len(b) < 4 {
+		return 0
+	}
+
+	return nlenc.Uint32(b[:4])
+}
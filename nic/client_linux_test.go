@@ -0,0 +1,214 @@
+//go:build linux
+
+package nic
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+func TestClientWakeOnLAN(t *testing.T) {
+	nic := &fakeNIC{
+		index:     2,
+		supported: ModePHY | ModeMagic | ModeMagicSecure,
+		enabled:   0,
+		sopass:    []byte{0, 0, 0, 0, 0, 0},
+	}
+
+	c := testClient(t, nic)
+	ifi := &net.Interface{Index: 2, Name: "eth0"}
+
+	w, err := c.WakeOnLAN(ifi)
+	if err != nil {
+		t.Fatalf("failed to get Wake-on-LAN: %v", err)
+	}
+
+	want := &WakeOnLAN{
+		Interface:        ifi,
+		Supported:        ModePHY | ModeMagic | ModeMagicSecure,
+		SecureOnPassword: []byte{0, 0, 0, 0, 0, 0},
+	}
+	if diff := cmp.Diff(want, w); diff != "" {
+		t.Fatalf("unexpected initial Wake-on-LAN (-want +got):\n%s", diff)
+	}
+
+	password := []byte{1, 2, 3, 4, 5, 6}
+	if err := c.SetWakeOnLAN(ifi, ModeMagic|ModeMagicSecure, password); err != nil {
+		t.Fatalf("failed to set Wake-on-LAN: %v", err)
+	}
+
+	w, err = c.WakeOnLAN(ifi)
+	if err != nil {
+		t.Fatalf("failed to get Wake-on-LAN: %v", err)
+	}
+
+	want.Enabled = ModeMagic | ModeMagicSecure
+	want.SecureOnPassword = password
+	if diff := cmp.Diff(want, w); diff != "" {
+		t.Fatalf("unexpected updated Wake-on-LAN (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientSetWakeOnLANErrors(t *testing.T) {
+	nic := &fakeNIC{
+		index:     2,
+		supported: ModeMagic,
+	}
+
+	c := testClient(t, nic)
+	ifi := &net.Interface{Index: 2, Name: "eth0"}
+
+	if err := c.SetWakeOnLAN(ifi, ModeMagic, []byte{1, 2, 3}); err == nil {
+		t.Fatal("expected an error for short password, but none occurred")
+	}
+
+	if err := c.SetWakeOnLAN(ifi, ModePHY, nil); !errors.Is(err, unix.EOPNOTSUPP) {
+		t.Fatalf("unexpected error for unsupported mode: %v", err)
+	}
+
+	if _, err := c.WakeOnLAN(&net.Interface{Index: 3}); !errors.Is(err, unix.ENODEV) {
+		t.Fatalf("unexpected error for unknown interface: %v", err)
+	}
+}
+
+func TestModeString(t *testing.T) {
+	var tests = []struct {
+		m    Mode
+		want string
+	}{
+		{m: 0, want: "disabled"},
+		{m: ModeMagic, want: "magic"},
+		{m: ModePHY | ModeMagic | ModeMagicSecure, want: "phy|magic|magicsecure"},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, tt.m.String()); diff != "" {
+			t.Fatalf("unexpected string (-want +got):\n%s", diff)
+		}
+	}
+}
+
+// A fakeNIC emulates the kernel's handling of ethtool Wake-on-LAN messages
+// for a single network interface.
+type fakeNIC struct {
+	index     int
+	supported Mode
+	enabled   Mode
+	sopass    []byte
+}
+
+func testClient(t *testing.T, nic *fakeNIC) *Client {
+	t.Helper()
+
+	family := genetlink.Family{
+		ID:      20,
+		Version: unix.ETHTOOL_GENL_VERSION,
+		Name:    unix.ETHTOOL_GENL_NAME,
+	}
+
+	conn := genltest.Dial(genltest.ServeFamily(family, nic.serve))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func (n *fakeNIC) serve(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+	// Receive calls without a request, such as when waiting for an
+	// acknowledgement, produce no further messages.
+	if nreq.Header.Length == 0 {
+		return nil, io.EOF
+	}
+
+	var (
+		index    int
+		modes    Mode
+		setModes bool
+		sopass   []byte
+	)
+
+	ad, err := netlink.NewAttributeDecoder(greq.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+		case unix.ETHTOOL_A_WOL_HEADER:
+			ad.Nested(func(nad *netlink.AttributeDecoder) error {
+				for nad.Next() {
+					if nad.Type() == unix.ETHTOOL_A_HEADER_DEV_INDEX {
+						index = int(nad.Uint32())
+					}
+				}
+				return nil
+			})
+		case unix.ETHTOOL_A_WOL_MODES:
+			setModes = true
+			ad.Nested(func(nad *netlink.AttributeDecoder) error {
+				modes, _ = parseBitset(nad)
+				return nil
+			})
+		case unix.ETHTOOL_A_WOL_SOPASS:
+			sopass = ad.Bytes()
+		}
+	}
+	if err := ad.Err(); err != nil {
+		return nil, err
+	}
+
+	if index != n.index {
+		return nil, unix.ENODEV
+	}
+
+	switch greq.Header.Command {
+	case unix.ETHTOOL_MSG_WOL_GET:
+		ae := netlink.NewAttributeEncoder()
+		ae.Nested(unix.ETHTOOL_A_WOL_MODES, func(nae *netlink.AttributeEncoder) error {
+			nae.Uint32(unix.ETHTOOL_A_BITSET_SIZE, modeBits)
+			nae.Bytes(unix.ETHTOOL_A_BITSET_VALUE, nlenc.Uint32Bytes(uint32(n.enabled)))
+			nae.Bytes(unix.ETHTOOL_A_BITSET_MASK, nlenc.Uint32Bytes(uint32(n.supported)))
+			return nil
+		})
+		if n.sopass != nil {
+			ae.Bytes(unix.ETHTOOL_A_WOL_SOPASS, n.sopass)
+		}
+
+		b, err := ae.Encode()
+		if err != nil {
+			return nil, err
+		}
+
+		return []genetlink.Message{{
+			Header: genetlink.Header{Command: unix.ETHTOOL_MSG_WOL_GET_REPLY},
+			Data:   b,
+		}}, nil
+	case unix.ETHTOOL_MSG_WOL_SET:
+		if setModes {
+			if modes&^n.supported != 0 {
+				return nil, unix.EOPNOTSUPP
+			}
+			n.enabled = modes
+		}
+		if sopass != nil {
+			n.sopass = sopass
+		}
+
+		return nil, nil
+	default:
+		return nil, unix.EINVAL
+	}
+}
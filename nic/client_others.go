@@ -0,0 +1,29 @@
+//go:build !linux
+
+package nic
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// errUnimplemented is returned by all functions on platforms that do not
+// support the ethtool generic netlink family.
+var errUnimplemented = fmt.Errorf("nic: not implemented on %s", runtime.GOOS)
+
+// A Client queries and configures Wake-on-LAN settings. Client is only
+// supported on Linux.
+type Client struct{}
+
+// Dial is not supported on this platform.
+func Dial() (*Client, error) { return nil, errUnimplemented }
+
+// Close is not supported on this platform.
+func (*Client) Close() error { return errUnimplemented }
+
+// WakeOnLAN is not supported on this platform.
+func (*Client) WakeOnLAN(_ *net.Interface) (*WakeOnLAN, error) { return nil, errUnimplemented }
+
+// SetWakeOnLAN is not supported on this platform.
+func (*Client) SetWakeOnLAN(_ *net.Interface, _ Mode, _ []byte) error { return errUnimplemented }
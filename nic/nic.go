@@ -0,0 +1,78 @@
+// Package nic inspects and configures Wake-on-LAN support on network
+// interface controllers.
+//
+// Many Wake-on-LAN failures are caused by a network interface which has
+// Wake-on-LAN disabled, so a machine will not respond to magic packets once
+// it goes to sleep. A Client can verify and fix this configuration.
+//
+// Package nic uses the ethtool generic netlink family, and is only supported
+// on Linux 5.6+.
+package nic
+
+import (
+	"net"
+	"strings"
+)
+
+// A Mode is a bitmask of Wake-on-LAN modes supported or enabled on a network
+// interface.
+type Mode uint32
+
+// Possible Mode values.
+const (
+	ModePHY Mode = 1 << iota
+	ModeUnicast
+	ModeMulticast
+	ModeBroadcast
+	ModeARP
+	ModeMagic
+	ModeMagicSecure
+	ModeFilter
+
+	// modeBits is the number of Wake-on-LAN modes known to the kernel.
+	modeBits = 8
+)
+
+// modeNames are the names used by ethtool for each Mode bit.
+var modeNames = [modeBits]string{
+	"phy",
+	"ucast",
+	"mcast",
+	"bcast",
+	"arp",
+	"magic",
+	"magicsecure",
+	"filter",
+}
+
+// String returns the ethtool names of the modes set in m, separated by '|'.
+func (m Mode) String() string {
+	if m == 0 {
+		return "disabled"
+	}
+
+	var names []string
+	for i, name := range modeNames {
+		if m&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+
+	return strings.Join(names, "|")
+}
+
+// WakeOnLAN is the Wake-on-LAN configuration of a network interface.
+type WakeOnLAN struct {
+	// Interface is the network interface which was queried.
+	Interface *net.Interface
+
+	// Supported and Enabled are the Wake-on-LAN modes supported by the
+	// interface and currently enabled on it.
+	Supported Mode
+	Enabled   Mode
+
+	// SecureOnPassword is the 6 byte password required by ModeMagicSecure.
+	// It is only populated when the interface supports ModeMagicSecure and
+	// the caller has the CAP_NET_ADMIN capability.
+	SecureOnPassword []byte
+}
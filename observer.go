@@ -0,0 +1,75 @@
+package wol
+
+import (
+	"net"
+	"time"
+)
+
+// Transports used to send magic packets, as reported in SendEvents.
+const (
+	TransportUDP = "udp"
+	TransportRaw = "raw"
+)
+
+// An Observer is notified of each attempt to send a magic packet, such as to
+// keep an audit log of wake activity. Observers must be safe for concurrent
+// use.
+type Observer interface {
+	ObserveSend(e *SendEvent)
+}
+
+// A SendEvent describes an attempt to send a magic packet.
+type SendEvent struct {
+	// Time is the time at which the attempt completed.
+	Time time.Time
+
+	// Transport is TransportUDP or TransportRaw.
+	Transport string
+
+	// Source is the local UDP address or network interface used to send
+	// the magic packet, if known.
+	Source string
+
+	// Destination is the UDP address or Ethernet hardware address the
+	// magic packet was sent to.
+	Destination string
+
+	// Target is the hardware address of the machine to wake or sleep.
+	Target net.HardwareAddr
+
+	// Sleep reports whether the magic packet was a Sleep-on-LAN packet.
+	Sleep bool
+
+	// Password reports whether the magic packet carried a password.
+	Password bool
+
+	// Identity optionally identifies who requested the attempt. Client and
+	// RawClient do not set Identity, but servers and relays which act on
+	// behalf of others may.
+	Identity string
+
+	// Err is nil if the magic packet was sent successfully.
+	Err error
+}
+
+// MultiObserver returns an Observer which notifies each of obs in order, such
+// as to both keep an audit log and collect metrics. Nil Observers are
+// skipped.
+func MultiObserver(obs ...Observer) Observer {
+	var mo multiObserver
+	for _, o := range obs {
+		if o != nil {
+			mo = append(mo, o)
+		}
+	}
+
+	return mo
+}
+
+type multiObserver []Observer
+
+func (mo multiObserver) ObserveSend(e *SendEvent) {
+	for _, o := range mo {
+		o.ObserveSend(e)
+	}
+}
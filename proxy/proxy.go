@@ -0,0 +1,299 @@
+// Package proxy implements a TCP proxy which wakes its backend on demand.
+//
+// A Proxy sits in front of a server which is allowed to sleep. When a client
+// connects and the backend does not accept connections, the Proxy sends it a
+// Wake-on-LAN magic packet and holds the client's connection until the
+// backend is ready, then splices traffic between the two. Idle backends stay
+// asleep until they are needed.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/wol"
+)
+
+// Defaults used when a Proxy's fields are unset.
+const (
+	defaultDialTimeout = time.Second
+	defaultWakeTimeout = 2 * time.Minute
+	defaultInterval    = time.Second
+	defaultResend      = 10 * time.Second
+)
+
+// A Proxy is a TCP proxy which wakes its backend when clients connect.
+type Proxy struct {
+	// Backend is the TCP address of the backend, such as "10.0.0.10:22".
+	Backend string
+
+	// Target is the hardware address of the backend machine.
+	Target net.HardwareAddr
+
+	// Waker sends magic packets to wake the backend, such as a
+	// wol.RawClient or the Waker of a wol.Client.
+	Waker wol.Waker
+
+	// Ready optionally reports whether the backend is ready to accept
+	// connections after it is woken. If nil, the backend is ready when a TCP
+	// connection to Backend succeeds.
+	Ready wol.Check
+
+	// DialTimeout bounds each connection attempt to the backend. If zero, a
+	// default of 1 second is used.
+	DialTimeout time.Duration
+
+	// WakeTimeout specifies how long client connections are held while the
+	// backend wakes, before they are closed. If zero, a default of 2 minutes
+	// is used.
+	WakeTimeout time.Duration
+
+	// Interval specifies how often Ready is checked while the backend wakes.
+	// If zero, a default of 1 second is used.
+	Interval time.Duration
+
+	// Resend specifies how often magic packets are resent while the backend
+	// wakes, in case one is lost. If zero, a default of 10 seconds is used.
+	Resend time.Duration
+
+	// Observer is optionally notified of each attempt by Waker to send a
+	// magic packet. Its events name Backend as the Destination, since
+	// Waker's own destination is unknown, and the client whose connection
+	// started the wake as the Identity. Leave Observer nil if Waker notifies an Observer itself, such as
+	// a wol.RawClient with its Observer set, so sends are not observed twice.
+	Observer wol.Observer
+
+	// Logger optionally logs connections and wakes.
+	Logger *slog.Logger
+
+	mu     sync.Mutex
+	waking *wake
+}
+
+// A wake is a single, shared attempt to wake the backend.
+type wake struct {
+	done chan struct{}
+	err  error
+}
+
+// Serve accepts connections on l and proxies them to the backend until l is
+// closed or ctx is canceled. Serve closes l before it returns, and waits for
+// all proxied connections to finish.
+func (p *Proxy) Serve(ctx context.Context, l net.Listener) error {
+	if p.Backend == "" || p.Waker == nil || len(p.Target) == 0 {
+		return errors.New("proxy: Backend, Target, and Waker must be set")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.handle(ctx, c)
+		}()
+	}
+}
+
+// handle proxies a single client connection.
+func (p *Proxy) handle(ctx context.Context, c net.Conn) {
+	defer c.Close()
+
+	// Stop proxying if the Proxy is stopped.
+	stop := context.AfterFunc(ctx, func() { _ = c.Close() })
+	defer stop()
+
+	b, err := p.dial(ctx)
+	if err != nil {
+		p.log(slog.LevelInfo, "backend unavailable, waking", "client", c.RemoteAddr().String(), "err", err)
+
+		if err := p.wake(ctx, c.RemoteAddr().String()); err != nil {
+			p.log(slog.LevelWarn, "failed to wake backend", "client", c.RemoteAddr().String(), "err", err)
+			return
+		}
+
+		if b, err = p.dial(ctx); err != nil {
+			p.log(slog.LevelWarn, "failed to connect to backend", "client", c.RemoteAddr().String(), "err", err)
+			return
+		}
+	}
+	defer b.Close()
+
+	p.log(slog.LevelDebug, "proxying connection", "client", c.RemoteAddr().String())
+	splice(c, b)
+}
+
+// wake wakes the backend on behalf of client and waits until it is ready.
+// Concurrent calls share a single attempt.
+func (p *Proxy) wake(ctx context.Context, client string) error {
+	p.mu.Lock()
+	w := p.waking
+	if w == nil {
+		w = &wake{done: make(chan struct{})}
+		p.waking = w
+
+		go func() {
+			w.err = p.tryWake(ctx, client)
+			close(w.done)
+
+			p.mu.Lock()
+			p.waking = nil
+			p.mu.Unlock()
+		}()
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-w.done:
+		return w.err
+	}
+}
+
+// tryWake sends magic packets to the backend until it is ready or the wake
+// timeout expires.
+func (p *Proxy) tryWake(ctx context.Context, client string) error {
+	timeout := p.WakeTimeout
+	if timeout == 0 {
+		timeout = defaultWakeTimeout
+	}
+	interval := p.Interval
+	if interval == 0 {
+		interval = defaultInterval
+	}
+	resend := p.Resend
+	if resend == 0 {
+		resend = defaultResend
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ready := p.Ready
+	if ready == nil {
+		ready = func(ctx context.Context) error {
+			c, err := p.dial(ctx)
+			if err != nil {
+				return err
+			}
+
+			return c.Close()
+		}
+	}
+
+	check := time.NewTicker(interval)
+	defer check.Stop()
+
+	// Check readiness before each send, so a client which failed to connect
+	// just before a previous wake finished does not send another packet.
+	var sent time.Time
+	for {
+		if err := ready(ctx); err == nil {
+			return nil
+		}
+
+		if time.Since(sent) >= resend {
+			err := p.Waker.WakeContext(ctx, p.Target)
+			p.observe(client, err)
+			if err != nil {
+				return fmt.Errorf("failed to send magic packet: %w", err)
+			}
+
+			sent = time.Now()
+			p.log(slog.LevelInfo, "sent magic packet", "target", p.Target.String())
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("backend did not become ready: %w", ctx.Err())
+		case <-check.C:
+		}
+	}
+}
+
+// dial connects to the backend.
+func (p *Proxy) dial(ctx context.Context) (net.Conn, error) {
+	timeout := p.DialTimeout
+	if timeout == 0 {
+		timeout = defaultDialTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", p.Backend)
+}
+
+// observe notifies p's Observer, if any, of an attempt to wake the backend
+// on behalf of client.
+func (p *Proxy) observe(client string, err error) {
+	if p.Observer == nil {
+		return
+	}
+
+	p.Observer.ObserveSend(&wol.SendEvent{
+		Time:        time.Now(),
+		Destination: p.Backend,
+		Target:      p.Target,
+		Identity:    client,
+		Err:         err,
+	})
+}
+
+// log logs a message if p has a Logger.
+func (p *Proxy) log(level slog.Level, msg string, args ...any) {
+	if p.Logger == nil {
+		return
+	}
+
+	p.Logger.Log(context.Background(), level, msg, append([]any{"backend", p.Backend}, args...)...)
+}
+
+// splice copies data between a and b until both directions are finished.
+func splice(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	cp := func(dst, src net.Conn) {
+		defer wg.Done()
+		_, _ = io.Copy(dst, src)
+
+		// Propagate EOF to the other side while allowing its replies to
+		// continue.
+		if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+			_ = cw.CloseWrite()
+		} else {
+			_ = dst.Close()
+		}
+	}
+
+	go cp(a, b)
+	go cp(b, a)
+	wg.Wait()
+}
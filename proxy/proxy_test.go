@@ -0,0 +1,216 @@
+package proxy_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/proxy"
+)
+
+var target = net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+func TestProxyWakesBackend(t *testing.T) {
+	backend := freeAddr(t)
+
+	// The backend only starts listening once it is woken.
+	var (
+		wakes atomic.Int32
+		once  sync.Once
+	)
+	p := &proxy.Proxy{
+		Backend:  backend,
+		Target:   target,
+		Interval: 10 * time.Millisecond,
+		Waker: wol.WakerFunc(func(_ context.Context, mac net.HardwareAddr) error {
+			if diff := cmp.Diff(target, mac); diff != "" {
+				t.Errorf("unexpected target (-want +got):\n%s", diff)
+			}
+
+			wakes.Add(1)
+			once.Do(func() { echoServer(t, backend) })
+			return nil
+		}),
+	}
+
+	addr := serve(t, p)
+
+	// Concurrent clients share a single wake.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if got := roundTrip(t, addr, "hello"); got != "hello" {
+				t.Errorf("unexpected echo: %q", got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := wakes.Load(); n != 1 {
+		t.Fatalf("expected 1 wake, but got %d", n)
+	}
+
+	// Once the backend is awake, connections are proxied immediately.
+	if got := roundTrip(t, addr, "again"); got != "again" {
+		t.Fatalf("unexpected echo: %q", got)
+	}
+	if n := wakes.Load(); n != 1 {
+		t.Fatalf("expected no further wakes, but got %d", n)
+	}
+}
+
+func TestProxyWakeTimeout(t *testing.T) {
+	var wakes, events atomic.Int32
+	p := &proxy.Proxy{
+		Backend:     freeAddr(t),
+		Target:      target,
+		Interval:    10 * time.Millisecond,
+		Resend:      20 * time.Millisecond,
+		WakeTimeout: 100 * time.Millisecond,
+		Waker: wol.WakerFunc(func(_ context.Context, _ net.HardwareAddr) error {
+			wakes.Add(1)
+			return nil
+		}),
+		Observer: observerFunc(func(e *wol.SendEvent) {
+			if e.Identity == "" || e.Err != nil {
+				t.Errorf("unexpected send event: %q, %v", e.Identity, e.Err)
+			}
+
+			events.Add(1)
+		}),
+	}
+
+	addr := serve(t, p)
+
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	// The backend never wakes, so the connection is closed after the wake
+	// timeout, and magic packets are resent meanwhile.
+	_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, but got: %v", err)
+	}
+
+	if n := wakes.Load(); n < 2 {
+		t.Fatalf("expected magic packets to be resent, but got %d", n)
+	}
+	if n, e := wakes.Load(), events.Load(); n != e {
+		t.Fatalf("expected %d send events, but got %d", n, e)
+	}
+}
+
+func TestProxyServeErrors(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	if err := (&proxy.Proxy{}).Serve(context.Background(), l); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+// serve starts p on a local listener and returns its address.
+func serve(t *testing.T, p *proxy.Proxy) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Serve(ctx, l) }()
+
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("failed to serve: %v", err)
+		}
+	})
+
+	return l.Addr().String()
+}
+
+// freeAddr returns a local TCP address which is not listening.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+// echoServer starts a TCP echo server on addr.
+func echoServer(t *testing.T, addr string) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Errorf("failed to listen: %v", err)
+		return
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer c.Close()
+				_, _ = io.Copy(c, c)
+			}()
+		}
+	}()
+}
+
+// roundTrip sends s through the proxy at addr, half-closes the connection,
+// and returns the response.
+func roundTrip(t *testing.T, addr, s string) string {
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Errorf("failed to dial: %v", err)
+		return ""
+	}
+	defer c.Close()
+
+	_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.WriteString(c, s); err != nil {
+		t.Errorf("failed to write: %v", err)
+		return ""
+	}
+	_ = c.(*net.TCPConn).CloseWrite()
+
+	b, err := io.ReadAll(c)
+	if err != nil {
+		t.Errorf("failed to read: %v", err)
+	}
+
+	return string(b)
+}
+
+// An observerFunc adapts a function to wol.Observer.
+type observerFunc func(e *wol.SendEvent)
+
+func (fn observerFunc) ObserveSend(e *wol.SendEvent) { fn(e) }
@@ -1,21 +1,59 @@
 package wol
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"net"
+	"time"
 
 	"github.com/mdlayher/ethernet"
 	"github.com/mdlayher/packet"
+	"golang.org/x/net/bpf"
 )
 
+// dropAll is a BPF filter which drops every inbound frame. A RawClient only
+// sends frames, so without it, inbound Wake-on-LAN frames would accumulate in
+// its socket's receive buffer for as long as the RawClient is open.
+var dropAll = func() []bpf.RawInstruction {
+	prog, err := bpf.Assemble([]bpf.Instruction{bpf.RetConstant{Val: 0}})
+	if err != nil {
+		panic(fmt.Sprintf("wol: failed to assemble BPF filter: %v", err))
+	}
+
+	return prog
+}()
+
 // A RawClient is a Wake-on-LAN client which operates directly on top of
 // Ethernet frames using Ethernet sockets.  It can be used to send WoL magic
 // packets to other machines on a local network, using their hardware addresses.
 type RawClient struct {
+	// Observer is optionally notified of each attempt to send a magic
+	// packet. It must be set before the RawClient is used.
+	Observer Observer
+
+	// Hooks are optionally called while sending each magic packet. They
+	// must be set before the RawClient is used.
+	Hooks *Hooks
+
+	// Logger optionally logs each attempt to send a magic packet: successes
+	// at debug level and failures at warning level. It must be set before
+	// the RawClient is used.
+	Logger *slog.Logger
+
+	// VLAN optionally specifies an IEEE 802.1Q VLAN ID with which to tag
+	// each frame, for sending magic packets to a VLAN which is trunked to
+	// the network interface. If zero, frames are untagged. It must be set
+	// before the RawClient is used.
+	VLAN uint16
+
 	ifi *net.Interface
 	p   net.PacketConn
 }
 
 // NewRawClient creates a new RawClient using the specified network interface.
+// A RawClient only sends frames, so its socket discards all inbound frames
+// using a BPF filter, and it may be kept open indefinitely.
 //
 // Note that Ethernet sockets typically require elevated user privileges, such
 // as the 'root' user on Linux, or the 'SET_CAP_RAW' capability.
@@ -25,15 +63,32 @@ type RawClient struct {
 func NewRawClient(ifi *net.Interface) (*RawClient, error) {
 	// Open a packet socket to send Wake-on-LAN magic packets.
 	// EtherType is set according to: https://wiki.wireshark.org/WakeOnLAN.
-	p, err := packet.Listen(ifi, packet.Raw, EtherType, nil)
+	//
+	// The socket is never read, so drop all inbound frames in the kernel.
+	// The filter is attached before the socket is bound, so no frames are
+	// queued in the meantime.
+	p, err := packet.Listen(ifi, packet.Raw, EtherType, &packet.Config{
+		Filter: dropAll,
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	return NewRawClientConn(ifi, p), nil
+}
+
+// NewRawClientConn creates a new RawClient which sends Ethernet frames with
+// a source address of ifi's hardware address using an existing
+// net.PacketConn. p must accept complete Ethernet frames and addresses of
+// type *packet.Addr. NewRawClientConn is useful for testing, in combination
+// with package woltest.
+//
+// Closing the RawClient closes p.
+func NewRawClientConn(ifi *net.Interface, p net.PacketConn) *RawClient {
 	return &RawClient{
 		ifi: ifi,
 		p:   p,
-	}, nil
+	}
 }
 
 // Close closes a RawClient's socket.
@@ -51,13 +106,74 @@ func (c *RawClient) Wake(target net.HardwareAddr) error {
 //
 // The password must be exactly 0 (empty), 4, or 6 bytes in length.
 func (c *RawClient) WakePassword(target net.HardwareAddr, password []byte) error {
-	return c.sendWake(target, password)
+	return c.send(context.Background(), target, password, false)
+}
+
+// WakeContext implements Waker. It is like Wake, but does not send a magic
+// packet if ctx is done.
+func (c *RawClient) WakeContext(ctx context.Context, target net.HardwareAddr) error {
+	return c.WakePasswordContext(ctx, target, nil)
+}
+
+// WakePasswordContext is like WakePassword, but does not send a magic packet
+// if ctx is done. ctx is passed to c's Hooks.
+func (c *RawClient) WakePasswordContext(ctx context.Context, target net.HardwareAddr, password []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.send(ctx, target, password, false)
+}
+
+// Sleep sends a Sleep-on-LAN packet to the specified hardware address.
+//
+// A Sleep-on-LAN packet is a magic packet whose target hardware address has
+// its bytes reversed. See Client.Sleep for details.
+func (c *RawClient) Sleep(target net.HardwareAddr) error {
+	return c.SleepPassword(target, nil)
+}
+
+// SleepPassword sends a Sleep-on-LAN packet to the specified hardware
+// address, using the specified password.
+//
+// The password must be exactly 0 (empty), 4, or 6 bytes in length.
+func (c *RawClient) SleepPassword(target net.HardwareAddr, password []byte) error {
+	return c.send(context.Background(), target, password, true)
+}
+
+// send sends a Wake-on-LAN or Sleep-on-LAN packet to target, calling c's
+// hooks and notifying its Logger and Observer, if any.
+func (c *RawClient) send(ctx context.Context, target net.HardwareAddr, password []byte, sleep bool) error {
+	mpTarget := target
+	if sleep {
+		mpTarget = SleepTarget(target)
+	}
+
+	s := sender{o: c.Observer, h: c.Hooks, l: c.Logger}
+	if !s.enabled() {
+		return c.sendWake(target, mpTarget, password)
+	}
+
+	info := &SendInfo{
+		Start:       time.Now(),
+		Transport:   TransportRaw,
+		Source:      c.ifi.Name,
+		Destination: target.String(),
+		Target:      target,
+		Sleep:       sleep,
+		Password:    len(password) > 0,
+	}
+
+	s.begin(ctx, info)
+	err := c.sendWake(target, mpTarget, password)
+	s.end(ctx, info, err)
+	return err
 }
 
 // sendWake crafts a magic packet using the input parameters, stores it in an
-// Ethernet frame, and sends the frame over an Ethernet socket to attempt to wake
-// a machine.
-func (c *RawClient) sendWake(target net.HardwareAddr, password []byte) error {
+// Ethernet frame addressed to dst, and sends the frame over an Ethernet socket
+// to attempt to wake a machine.
+func (c *RawClient) sendWake(dst, target net.HardwareAddr, password []byte) error {
 	// Create magic packet with target and password.
 	p := &MagicPacket{
 		Target:   target,
@@ -70,11 +186,14 @@ func (c *RawClient) sendWake(target net.HardwareAddr, password []byte) error {
 
 	// Create Ethernet frame to carry magic packet.
 	f := &ethernet.Frame{
-		Destination: target,
+		Destination: dst,
 		Source:      c.ifi.HardwareAddr,
 		EtherType:   EtherType,
 		Payload:     pb,
 	}
+	if c.VLAN != 0 {
+		f.VLAN = &ethernet.VLAN{ID: c.VLAN}
+	}
 	fb, err := f.MarshalBinary()
 	if err != nil {
 		return err
@@ -82,7 +201,7 @@ func (c *RawClient) sendWake(target net.HardwareAddr, password []byte) error {
 
 	// Send magic packet to target.
 	_, err = c.p.WriteTo(fb, &packet.Addr{
-		HardwareAddr: target,
+		HardwareAddr: dst,
 	})
 	return err
 }
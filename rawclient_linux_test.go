@@ -0,0 +1,54 @@
+//go:build linux
+
+package wol
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRawClientDropsInbound(t *testing.T) {
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skipf("skipping, no loopback interface: %v", err)
+	}
+
+	c, err := NewRawClient(lo)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			t.Skip("skipping, permission denied (try setting CAP_NET_RAW capability)")
+		}
+
+		t.Fatalf("failed to create raw client: %v", err)
+	}
+	defer c.Close()
+
+	l, err := ListenRaw(lo)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	// Frames sent on the loopback interface are received by every packet
+	// socket bound to it, including the RawClient's own.
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	if err := c.Wake(target); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+
+	if _, _, err := l.Receive(); err != nil {
+		t.Fatalf("failed to receive: %v", err)
+	}
+
+	if err := c.p.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+
+	b := make([]byte, 1500)
+	if n, _, err := c.p.ReadFrom(b); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected no buffered frames, but read %d bytes: %v", n, err)
+	}
+}
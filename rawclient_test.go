@@ -141,3 +141,67 @@ func (noopPacketConn) LocalAddr() net.Addr                { return nil }
 func (noopPacketConn) SetDeadline(t time.Time) error      { return nil }
 func (noopPacketConn) SetReadDeadline(t time.Time) error  { return nil }
 func (noopPacketConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func TestRawClientSleep(t *testing.T) {
+	p := &writeToPacketConn{}
+	c := &RawClient{
+		ifi: &net.Interface{
+			HardwareAddr: make(net.HardwareAddr, 6),
+		},
+		p: p,
+	}
+
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	if err := c.Sleep(target); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	f := new(ethernet.Frame)
+	if err := f.UnmarshalBinary(p.b); err != nil {
+		t.Fatalf("failed to unmarshal Ethernet frame: %v", err)
+	}
+
+	// The frame itself is addressed to the awake target machine, but the
+	// magic packet carries its reversed hardware address.
+	if diff := cmp.Diff(target, f.Destination); diff != "" {
+		t.Fatalf("unexpected destination (-want +got):\n%s", diff)
+	}
+
+	mp := new(MagicPacket)
+	if err := mp.UnmarshalBinary(f.Payload); err != nil {
+		t.Fatalf("failed to unmarshal MagicPacket: %v", err)
+	}
+
+	want := net.HardwareAddr{0xad, 0xde, 0xef, 0xbe, 0xad, 0xde}
+	if diff := cmp.Diff(want, mp.Target); diff != "" {
+		t.Fatalf("unexpected target (-want +got):\n%s", diff)
+	}
+}
+
+func TestRawClientVLAN(t *testing.T) {
+	p := &writeToPacketConn{}
+	c := &RawClient{
+		VLAN: 20,
+		ifi: &net.Interface{
+			HardwareAddr: make(net.HardwareAddr, 6),
+		},
+		p: p,
+	}
+
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	if err := c.Wake(target); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	f := new(ethernet.Frame)
+	if err := f.UnmarshalBinary(p.b); err != nil {
+		t.Fatalf("failed to unmarshal Ethernet frame: %v", err)
+	}
+
+	if diff := cmp.Diff(&ethernet.VLAN{ID: 20}, f.VLAN); diff != "" {
+		t.Fatalf("unexpected VLAN tag (-want +got):\n%s", diff)
+	}
+	if f.EtherType != EtherType {
+		t.Fatalf("unexpected EtherType: %v", f.EtherType)
+	}
+}
@@ -0,0 +1,394 @@
+// Package redfish implements a DMTF Redfish client which can power on
+// machines using their baseboard management controller (BMC).
+//
+// Newer BMCs expose Redfish, a RESTful HTTP API, rather than or in addition
+// to IPMI. A Client implements wol.Waker, so it can be used interchangeably
+// with the Wake-on-LAN clients in package wol.
+package redfish
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/wol"
+)
+
+// Well-known Redfish paths, headers, and defaults used by a Client.
+const (
+	serviceRoot     = "/redfish/v1/"
+	sessionsPath    = "/redfish/v1/SessionService/Sessions"
+	authTokenHeader = "X-Auth-Token"
+
+	defaultPollInterval = time.Second
+)
+
+// A PowerState is the power state of a ComputerSystem.
+type PowerState string
+
+// Possible PowerState values.
+const (
+	PowerOn          PowerState = "On"
+	PowerOff         PowerState = "Off"
+	PowerPoweringOn  PowerState = "PoweringOn"
+	PowerPoweringOff PowerState = "PoweringOff"
+)
+
+var _ wol.Waker = &Client{}
+
+// Config contains configuration for a Client.
+type Config struct {
+	// Username and Password specify the credentials used to authenticate
+	// with a Redfish service.
+	Username string
+	Password string
+
+	// Session specifies whether a Client should create a Redfish session
+	// and authenticate using its token, rather than sending HTTP basic
+	// authentication credentials with every request.
+	Session bool
+
+	// System optionally specifies the path of the ComputerSystem resource
+	// to control, such as "/redfish/v1/Systems/1". If empty, the first
+	// member of the service's Systems collection is used.
+	System string
+
+	// TLSConfig optionally specifies TLS configuration for connections to
+	// the Redfish service. BMCs commonly use self-signed certificates, in
+	// which case a custom RootCAs pool or InsecureSkipVerify may be needed.
+	// TLSConfig is ignored if HTTPClient is set.
+	TLSConfig *tls.Config
+
+	// HTTPClient optionally specifies the HTTP client used to make
+	// requests. If nil, a client using TLSConfig is created.
+	HTTPClient *http.Client
+
+	// PollInterval specifies how often WaitPowerState checks a system's
+	// power state. If zero, a default is used.
+	PollInterval time.Duration
+}
+
+// An Error is a Redfish error returned by a service.
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Code and Message are the Redfish error code and message, if the
+	// service returned an error body.
+	Code    string
+	Message string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("redfish: HTTP %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("redfish: HTTP %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// A Client is a Redfish client which controls a single ComputerSystem.
+type Client struct {
+	base *url.URL
+	hc   *http.Client
+	cfg  Config
+
+	system      string
+	resetTarget string
+
+	token   string
+	session string
+}
+
+// Dial connects to the Redfish service at endpoint, such as
+// "https://10.0.0.10", authenticates, and discovers the ComputerSystem to
+// control.
+func Dial(ctx context.Context, endpoint string, cfg *Config) (*Client, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if base.Scheme != "https" && base.Scheme != "http" {
+		return nil, fmt.Errorf("redfish: unsupported URL scheme %q", base.Scheme)
+	}
+
+	hc := cfg.HTTPClient
+	if hc == nil {
+		hc = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: cfg.TLSConfig,
+			},
+		}
+	}
+
+	c := &Client{
+		base: base,
+		hc:   hc,
+		cfg:  *cfg,
+	}
+	if c.cfg.PollInterval == 0 {
+		c.cfg.PollInterval = defaultPollInterval
+	}
+
+	if cfg.Session {
+		if err := c.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.discover(ctx); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Close deletes a Client's Redfish session, if one was created.
+func (c *Client) Close() error {
+	if c.session == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := c.do(ctx, http.MethodDelete, c.session, nil, nil)
+	c.token = ""
+	c.session = ""
+	return err
+}
+
+// PowerOn requests that the ComputerSystem be powered on using the
+// ComputerSystem.Reset action with a ResetType of On. PowerOn returns once
+// the service accepts the request; use WaitPowerState to wait for the system
+// to finish powering on.
+func (c *Client) PowerOn(ctx context.Context) error {
+	body := struct {
+		ResetType string
+	}{
+		ResetType: "On",
+	}
+
+	return c.do(ctx, http.MethodPost, c.resetTarget, body, nil)
+}
+
+// PowerState retrieves the current power state of the ComputerSystem.
+func (c *Client) PowerState(ctx context.Context) (PowerState, error) {
+	var sys computerSystem
+	if err := c.do(ctx, http.MethodGet, c.system, nil, &sys); err != nil {
+		return "", err
+	}
+
+	return sys.PowerState, nil
+}
+
+// WaitPowerState polls the ComputerSystem until it reaches the specified
+// power state or ctx is canceled.
+func (c *Client) WaitPowerState(ctx context.Context, want PowerState) error {
+	t := time.NewTicker(c.cfg.PollInterval)
+	defer t.Stop()
+
+	for {
+		got, err := c.PowerState(ctx)
+		if err != nil {
+			return err
+		}
+		if got == want {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("redfish: waiting for power state %q, last state %q: %w", want, got, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// WakeContext implements wol.Waker by powering on the ComputerSystem. A
+// Client controls a single system, so target is ignored.
+func (c *Client) WakeContext(ctx context.Context, _ net.HardwareAddr) error {
+	return c.PowerOn(ctx)
+}
+
+// login creates a Redfish session and stores its token.
+func (c *Client) login(ctx context.Context) error {
+	body := struct {
+		UserName string
+		Password string
+	}{
+		UserName: c.cfg.Username,
+		Password: c.cfg.Password,
+	}
+
+	res, err := c.request(ctx, http.MethodPost, sessionsPath, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	c.token = res.Header.Get(authTokenHeader)
+	if c.token == "" {
+		return errors.New("redfish: service did not return a session token")
+	}
+
+	if loc := res.Header.Get("Location"); loc != "" {
+		u, err := url.Parse(loc)
+		if err != nil {
+			return err
+		}
+		c.session = u.Path
+	}
+
+	return nil
+}
+
+// discover finds the ComputerSystem to control and its reset action target.
+func (c *Client) discover(ctx context.Context) error {
+	c.system = c.cfg.System
+	if c.system == "" {
+		var root struct {
+			Systems odataID
+		}
+		if err := c.do(ctx, http.MethodGet, serviceRoot, nil, &root); err != nil {
+			return err
+		}
+		if root.Systems.ID == "" {
+			return errors.New("redfish: service has no Systems collection")
+		}
+
+		var systems struct {
+			Members []odataID
+		}
+		if err := c.do(ctx, http.MethodGet, root.Systems.ID, nil, &systems); err != nil {
+			return err
+		}
+		if len(systems.Members) == 0 {
+			return errors.New("redfish: service has no ComputerSystems")
+		}
+
+		c.system = systems.Members[0].ID
+	}
+
+	var sys computerSystem
+	if err := c.do(ctx, http.MethodGet, c.system, nil, &sys); err != nil {
+		return err
+	}
+
+	c.resetTarget = sys.Actions.Reset.Target
+	if c.resetTarget == "" {
+		// Fall back to the path defined by the specification.
+		c.resetTarget = strings.TrimSuffix(c.system, "/") + "/Actions/ComputerSystem.Reset"
+	}
+
+	return nil
+}
+
+// do performs an HTTP request with an optional JSON body, and decodes the
+// JSON response into out if out is non-nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	res, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// request performs an authenticated HTTP request and checks its response
+// status. The caller must close the response body.
+func (c *Client) request(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base.ResolveReference(ref).String(), r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	switch {
+	case c.token != "":
+		req.Header.Set(authTokenHeader, c.token)
+	case !c.cfg.Session && c.cfg.Username != "":
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	rerr := &Error{StatusCode: res.StatusCode}
+
+	var eb struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&eb); err == nil {
+		rerr.Code = eb.Error.Code
+		rerr.Message = eb.Error.Message
+	}
+
+	return nil, rerr
+}
+
+// An odataID is a reference to another Redfish resource.
+type odataID struct {
+	ID string `json:"@odata.id"`
+}
+
+// A computerSystem is the subset of a Redfish ComputerSystem resource used by
+// a Client.
+type computerSystem struct {
+	PowerState PowerState
+	Actions    struct {
+		Reset struct {
+			Target string `json:"target"`
+		} `json:"#ComputerSystem.Reset"`
+	}
+}
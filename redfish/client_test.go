@@ -0,0 +1,220 @@
+package redfish_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/wol/redfish"
+)
+
+func TestClientPowerOn(t *testing.T) {
+	var tests = []struct {
+		name    string
+		session bool
+	}{
+		{name: "basic auth"},
+		{name: "session auth", session: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, svc := newFakeService(t)
+
+			c, err := redfish.Dial(context.Background(), srv.URL, &redfish.Config{
+				Username:     "root",
+				Password:     "calvin",
+				Session:      tt.session,
+				HTTPClient:   srv.Client(),
+				PollInterval: time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+
+			ctx := context.Background()
+			if got, err := c.PowerState(ctx); err != nil || got != redfish.PowerOff {
+				t.Fatalf("unexpected initial power state: %q, %v", got, err)
+			}
+
+			if err := c.WakeContext(ctx, nil); err != nil {
+				t.Fatalf("failed to wake: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+
+			if err := c.WaitPowerState(ctx, redfish.PowerOn); err != nil {
+				t.Fatalf("failed to wait for power on: %v", err)
+			}
+
+			if err := c.Close(); err != nil {
+				t.Fatalf("failed to close: %v", err)
+			}
+
+			if got := svc.sessionCount(); got != 0 {
+				t.Fatalf("expected all sessions to be deleted, but %d remain", got)
+			}
+		})
+	}
+}
+
+func TestDialUnauthorized(t *testing.T) {
+	srv, _ := newFakeService(t)
+
+	_, err := redfish.Dial(context.Background(), srv.URL, &redfish.Config{
+		Username:   "root",
+		Password:   "wrong",
+		HTTPClient: srv.Client(),
+	})
+
+	var rerr *redfish.Error
+	if !errors.As(err, &rerr) || rerr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rerr.Code != "Base.1.0.InsufficientPrivilege" {
+		t.Fatalf("unexpected Redfish error code: %q", rerr.Code)
+	}
+}
+
+// A fakeService emulates the subset of a Redfish service used by a Client.
+// The system passes through PoweringOn for a few polls before reaching On.
+type fakeService struct {
+	mu       sync.Mutex
+	state    redfish.PowerState
+	polls    int
+	sessions map[string]bool
+}
+
+func newFakeService(t *testing.T) (*httptest.Server, *fakeService) {
+	t.Helper()
+
+	s := &fakeService{
+		state:    redfish.PowerOff,
+		sessions: make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redfish/v1/", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"Systems": map[string]string{"@odata.id": "/redfish/v1/Systems"},
+		})
+	}))
+	mux.HandleFunc("/redfish/v1/Systems", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"Members": []map[string]string{{"@odata.id": "/redfish/v1/Systems/1"}},
+		})
+	}))
+	mux.HandleFunc("/redfish/v1/Systems/1", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.state == redfish.PowerPoweringOn {
+			if s.polls++; s.polls > 2 {
+				s.state = redfish.PowerOn
+			}
+		}
+
+		writeJSON(w, map[string]interface{}{
+			"PowerState": s.state,
+			"Actions": map[string]interface{}{
+				"#ComputerSystem.Reset": map[string]string{
+					"target": "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset",
+				},
+			},
+		})
+	}))
+	mux.HandleFunc("/redfish/v1/Systems/1/Actions/ComputerSystem.Reset", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ ResetType string }
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil || body.ResetType != "On" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.state = redfish.PowerPoweringOn
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc("/redfish/v1/SessionService/Sessions", func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ UserName, Password string }
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if body.UserName != "root" || body.Password != "calvin" {
+			unauthorized(w)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.sessions["token"] = true
+
+		w.Header().Set("X-Auth-Token", "token")
+		w.Header().Set("Location", "/redfish/v1/SessionService/Sessions/1")
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/redfish/v1/SessionService/Sessions/1", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.sessions, "token")
+	}))
+
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv, s
+}
+
+func (s *fakeService) sessionCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// auth wraps h with a check for either basic or session authentication.
+func (s *fakeService) auth(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		ok := s.sessions[r.Header.Get("X-Auth-Token")]
+		s.mu.Unlock()
+
+		if u, p, _ := r.BasicAuth(); u == "root" && p == "calvin" {
+			ok = true
+		}
+
+		if !ok {
+			unauthorized(w)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{
+			"code":    "Base.1.0.InsufficientPrivilege",
+			"message": "There are insufficient privileges for the account or credentials associated with the current session to perform the requested operation.",
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
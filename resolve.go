@@ -0,0 +1,141 @@
+package wol
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// defaultPort is the UDP port used when an address does not specify one.
+// Magic packets are conventionally sent to the discard port.
+const defaultPort = 9
+
+// resolveUDP resolves addr, in any of the forms accepted by Client.Wake, to
+// one or more UDP addresses. ctx bounds hostname lookups.
+func resolveUDP(ctx context.Context, addr string) ([]*net.UDPAddr, error) {
+	host, port, err := splitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Zoned IPv6 addresses are parsed by netip, but not by net.ParseIP.
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return udpAddrs(port, ip), nil
+	}
+
+	if prefix, err := netip.ParsePrefix(host); err == nil {
+		ip, err := directedBroadcast(prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		return udpAddrs(port, ip), nil
+	}
+
+	if ifi, err := net.InterfaceByName(host); err == nil {
+		ips, err := interfaceBroadcasts(ifi)
+		if err != nil {
+			return nil, err
+		}
+
+		return udpAddrs(port, ips...), nil
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+
+	return udpAddrs(port, ips...), nil
+}
+
+// splitHostPort splits addr into a host and UDP port. If addr has no port,
+// defaultPort is used.
+func splitHostPort(addr string) (string, uint16, error) {
+	host, service, err := net.SplitHostPort(addr)
+	if err != nil {
+		// Bare IPv6 addresses may be written with or without brackets.
+		return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"), defaultPort, nil
+	}
+
+	port, err := net.LookupPort("udp", service)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return host, uint16(port), nil
+}
+
+// directedBroadcast returns the broadcast address of an IPv4 prefix.
+func directedBroadcast(prefix netip.Prefix) (netip.Addr, error) {
+	if !prefix.Addr().Is4() {
+		return netip.Addr{}, fmt.Errorf("wol: prefix %s is not IPv4, and has no broadcast address", prefix)
+	}
+
+	b := prefix.Masked().Addr().As4()
+	host := uint32(1<<(32-prefix.Bits()) - 1)
+	binary.BigEndian.PutUint32(b[:], binary.BigEndian.Uint32(b[:])|host)
+
+	return netip.AddrFrom4(b), nil
+}
+
+// InterfaceBroadcasts returns the directed broadcast addresses of the IPv4
+// subnets configured on ifi, which a Client can use to send magic packets on
+// the network attached to ifi. Point-to-point and host prefixes, which have
+// no broadcast address, are skipped.
+func InterfaceBroadcasts(ifi *net.Interface) ([]netip.Addr, error) {
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []netip.Addr
+	for _, a := range addrs {
+		ipn, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+
+		ip4 := ipn.IP.To4()
+		ones, bits := ipn.Mask.Size()
+		if ip4 == nil || bits != 32 || ones > 30 {
+			continue
+		}
+
+		ip, err := directedBroadcast(netip.PrefixFrom(netip.AddrFrom4([4]byte(ip4)), ones))
+		if err != nil {
+			return nil, err
+		}
+
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
+
+// interfaceBroadcasts is like InterfaceBroadcasts, but returns an error if
+// ifi has no broadcast addresses.
+func interfaceBroadcasts(ifi *net.Interface) ([]netip.Addr, error) {
+	ips, err := InterfaceBroadcasts(ifi)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("wol: interface %q has no IPv4 subnets with broadcast addresses", ifi.Name)
+	}
+
+	return ips, nil
+}
+
+// udpAddrs returns a UDP address with port for each of ips.
+func udpAddrs(port uint16, ips ...netip.Addr) []*net.UDPAddr {
+	uaddrs := make([]*net.UDPAddr, 0, len(ips))
+	for _, ip := range ips {
+		uaddrs = append(uaddrs, net.UDPAddrFromAddrPort(netip.AddrPortFrom(ip.Unmap(), port)))
+	}
+
+	return uaddrs
+}
@@ -0,0 +1,164 @@
+package wol
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResolveUDP(t *testing.T) {
+	var tests = []struct {
+		name string
+		addr string
+		want []string
+	}{
+		{
+			name: "IPv4 no port",
+			addr: "10.0.0.255",
+			want: []string{"10.0.0.255:9"},
+		},
+		{
+			name: "IPv4 port",
+			addr: "10.0.0.255:7",
+			want: []string{"10.0.0.255:7"},
+		},
+		{
+			name: "IPv6 no port",
+			addr: "ff02::1",
+			want: []string{"[ff02::1]:9"},
+		},
+		{
+			name: "IPv6 brackets no port",
+			addr: "[ff02::1]",
+			want: []string{"[ff02::1]:9"},
+		},
+		{
+			name: "IPv6 zone port",
+			addr: "[ff02::1%eth0]:7",
+			want: []string{"[ff02::1%eth0]:7"},
+		},
+		{
+			name: "prefix",
+			addr: "10.0.0.0/24",
+			want: []string{"10.0.0.255:9"},
+		},
+		{
+			name: "prefix unmasked port",
+			addr: "192.168.1.17/30:7",
+			want: []string{"192.168.1.19:7"},
+		},
+		{
+			name: "prefix all",
+			addr: "0.0.0.0/0",
+			want: []string{"255.255.255.255:9"},
+		},
+		{
+			name: "IPv6 prefix",
+			addr: "2001:db8::/64",
+		},
+		{
+			name: "bad port",
+			addr: "10.0.0.255:99999",
+		},
+		{
+			name: "unknown host",
+			addr: "wol-does-not-exist.invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uaddrs, err := resolveUDP(context.Background(), tt.addr)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("expected an error, but resolved: %v", uaddrs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to resolve: %v", err)
+			}
+
+			var got []string
+			for _, ua := range uaddrs {
+				got = append(got, ua.String())
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected addresses (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolveUDPInterface(t *testing.T) {
+	ifis, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("skipping, failed to list interfaces: %v", err)
+	}
+
+	// Find any interface with an IPv4 subnet which has a broadcast address.
+	for _, ifi := range ifis {
+		want, err := interfaceBroadcasts(&ifi)
+		if err != nil {
+			continue
+		}
+
+		uaddrs, err := resolveUDP(context.Background(), ifi.Name+":7")
+		if err != nil {
+			t.Fatalf("failed to resolve: %v", err)
+		}
+
+		var got []netip.AddrPort
+		for _, ua := range uaddrs {
+			got = append(got, ua.AddrPort())
+		}
+
+		var wantAP []netip.AddrPort
+		for _, ip := range want {
+			wantAP = append(wantAP, netip.AddrPortFrom(ip, 7))
+		}
+
+		if diff := cmp.Diff(wantAP, got, cmp.Comparer(func(x, y netip.AddrPort) bool {
+			return x == y
+		})); diff != "" {
+			t.Fatalf("unexpected addresses (-want +got):\n%s", diff)
+		}
+		return
+	}
+
+	t.Skip("skipping, no interface with an IPv4 broadcast address")
+}
+
+func TestClientWakeMultiple(t *testing.T) {
+	p := &countPacketConn{}
+	c := &Client{p: p}
+
+	target := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	if err := c.Wake("localhost", target); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+
+	uaddrs, err := resolveUDP(context.Background(), "localhost")
+	if err != nil {
+		t.Fatalf("failed to resolve: %v", err)
+	}
+
+	if diff := cmp.Diff(len(uaddrs), len(p.addrs)); diff != "" {
+		t.Fatalf("unexpected number of packets sent (-want +got):\n%s", diff)
+	}
+}
+
+// A countPacketConn is a net.PacketConn which records each address written to.
+type countPacketConn struct {
+	addrs []net.Addr
+	noopPacketConn
+}
+
+func (c *countPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.addrs = append(c.addrs, addr)
+	return len(b), nil
+}
@@ -0,0 +1,47 @@
+package wol
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// NewRawClientRoute creates a new RawClient using the network interface which
+// the operating system would use to reach dst, as determined by its routing
+// table. dst may be the gateway or any address within the subnet where the
+// target machine resides, such as its broadcast address.
+//
+// This is useful on machines with multiple network interfaces, where the
+// correct interface to send raw Ethernet frames on is not obvious.
+//
+// NewRawClientRoute is only supported on Linux.
+func NewRawClientRoute(dst net.IP) (*RawClient, error) {
+	ifi, err := RouteInterface(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRawClient(ifi)
+}
+
+// NewRawClientPrefix is like NewRawClientRoute, but selects the network
+// interface using a destination subnet or gateway, such as 192.168.1.0/24 or
+// 192.168.1.1/24. The route is looked up for the prefix's address, so a
+// subnet's network address and any host address within it are equivalent.
+//
+// NewRawClientPrefix is only supported on Linux.
+func NewRawClientPrefix(dst netip.Prefix) (*RawClient, error) {
+	if !dst.IsValid() {
+		return nil, fmt.Errorf("wol: invalid route destination prefix %q", dst)
+	}
+
+	return NewRawClientRoute(dst.Addr().Unmap().AsSlice())
+}
+
+// RouteInterface returns the network interface which the operating system
+// would use to reach dst, as determined by its routing table.
+//
+// RouteInterface is only supported on Linux.
+func RouteInterface(dst net.IP) (*net.Interface, error) {
+	return routeInterface(dst)
+}
@@ -0,0 +1,84 @@
+//go:build linux
+
+package wol
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// rtmsgLen is the length of a struct rtmsg.
+const rtmsgLen = 12
+
+// routeInterface queries the kernel's routing table using a rtnetlink
+// RTM_GETROUTE request for the output interface used to reach dst.
+func routeInterface(dst net.IP) (*net.Interface, error) {
+	family, ip := uint8(unix.AF_INET), dst.To4()
+	if ip == nil {
+		family, ip = unix.AF_INET6, dst.To16()
+	}
+	if ip == nil {
+		return nil, fmt.Errorf("wol: invalid route destination IP address %q", dst)
+	}
+
+	c, err := netlink.Dial(unix.NETLINK_ROUTE, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Bytes(unix.RTA_DST, ip)
+	attrs, err := ae.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	// struct rtmsg: family, destination prefix length, and zero values for
+	// the remaining fields.
+	b := make([]byte, rtmsgLen)
+	b[0] = family
+	b[1] = uint8(len(ip) * 8)
+
+	msgs, err := c.Execute(netlink.Message{
+		Header: netlink.Header{
+			Type:  unix.RTM_GETROUTE,
+			Flags: netlink.Request,
+		},
+		Data: append(b, attrs...),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("wol: failed to look up route to %s: %w", dst, err)
+	}
+
+	for _, m := range msgs {
+		if m.Header.Type != unix.RTM_NEWROUTE || len(m.Data) < rtmsgLen {
+			continue
+		}
+
+		ad, err := netlink.NewAttributeDecoder(m.Data[rtmsgLen:])
+		if err != nil {
+			return nil, err
+		}
+
+		var index uint32
+		for ad.Next() {
+			if ad.Type() == unix.RTA_OIF {
+				index = ad.Uint32()
+			}
+		}
+		if err := ad.Err(); err != nil {
+			return nil, err
+		}
+
+		if index != 0 {
+			return net.InterfaceByIndex(int(index))
+		}
+	}
+
+	return nil, errors.New("wol: route has no output interface")
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+package wol
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"testing"
+)
+
+func TestRouteInterfaceLoopback(t *testing.T) {
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skipf("skipping, no loopback interface: %v", err)
+	}
+
+	for _, ip := range []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback} {
+		ifi, err := RouteInterface(ip)
+		if err != nil {
+			t.Fatalf("failed to look up route to %s: %v", ip, err)
+		}
+
+		if ifi.Index != lo.Index {
+			t.Fatalf("unexpected interface for %s: %q", ip, ifi.Name)
+		}
+	}
+}
+
+func TestNewRawClientPrefixLoopback(t *testing.T) {
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skipf("skipping, no loopback interface: %v", err)
+	}
+
+	for _, s := range []string{"127.0.0.0/8", "127.0.0.1/8", "::1/128"} {
+		c, err := NewRawClientPrefix(netip.MustParsePrefix(s))
+		if err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				t.Skip("skipping, permission denied (try setting CAP_NET_RAW capability)")
+			}
+
+			t.Fatalf("failed to create raw client for %s: %v", s, err)
+		}
+		_ = c.Close()
+
+		if c.ifi.Index != lo.Index {
+			t.Fatalf("unexpected interface for %s: %q", s, c.ifi.Name)
+		}
+	}
+
+	if _, err := NewRawClientPrefix(netip.Prefix{}); err == nil {
+		t.Fatal("expected an error for invalid prefix, but none occurred")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package wol
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// routeInterface is not implemented on this platform.
+func routeInterface(_ net.IP) (*net.Interface, error) {
+	return nil, fmt.Errorf("wol: route lookup not implemented on %s", runtime.GOOS)
+}
@@ -0,0 +1,138 @@
+// Package sleep implements a Sleep-on-LAN agent.
+//
+// Sleep-on-LAN is a loose convention which mirrors Wake-on-LAN: a machine is
+// put to sleep by sending it a magic packet whose target hardware address has
+// its bytes reversed. Unlike Wake-on-LAN, the packet must be handled by
+// software running on the awake machine, such as an Agent.
+//
+// Sleep-on-LAN packets are not authenticated. An Agent's optional password is
+// sent in cleartext in every packet, so anyone who captures a single packet
+// can replay it to put the machine to sleep at any time. Signed packets, such
+// as with an HMAC over the target and a timestamp, are not supported: an
+// Agent should only listen on trusted networks.
+package sleep
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net"
+	"os/exec"
+	"time"
+
+	"github.com/mdlayher/wol"
+)
+
+// A Hook is invoked by an Agent when a valid Sleep-on-LAN packet is received.
+type Hook func() error
+
+// Command returns a Hook which runs the named program with the specified
+// arguments.
+func Command(name string, arg ...string) Hook {
+	return func() error {
+		return exec.Command(name, arg...).Run()
+	}
+}
+
+// Suspend is a Hook which suspends a machine using systemd.
+var Suspend = Command("systemctl", "suspend")
+
+// An Agent listens for Sleep-on-LAN packets addressed to a network interface
+// and runs a Hook when one is received.
+type Agent struct {
+	// Interface specifies the network interface whose hardware address
+	// Sleep-on-LAN packets must target. Interface must be set.
+	Interface *net.Interface
+
+	// Password optionally specifies a password which must be present in
+	// a Sleep-on-LAN packet for it to be accepted, much like a Wake-on-LAN
+	// SecureOn password. If empty, packets with any password are accepted.
+	// The password is not secret from anyone who can capture packets; see
+	// the package documentation.
+	Password []byte
+
+	// Hook is invoked for each accepted Sleep-on-LAN packet. If nil, Suspend
+	// is used.
+	Hook Hook
+
+	// Cooldown optionally specifies a period after running Hook in which
+	// further Sleep-on-LAN packets are ignored. Senders commonly transmit
+	// several copies of a packet, so a short Cooldown avoids running Hook
+	// more than once per request.
+	Cooldown time.Duration
+
+	// Logger optionally logs each time Hook runs, and any error it returns.
+	Logger *slog.Logger
+}
+
+// Serve receives packets from l and runs a's Hook for each accepted
+// Sleep-on-LAN packet. Serve returns nil when l is closed, or an error if
+// reading from l fails. Errors from Hook are logged, and Serve continues to
+// receive packets so a later request may be retried.
+func (a *Agent) Serve(l *wol.Listener) error {
+	if a.Interface == nil {
+		return errors.New("sleep: agent has no network interface")
+	}
+
+	hook := a.Hook
+	if hook == nil {
+		hook = Suspend
+	}
+
+	target := wol.SleepTarget(a.Interface.HardwareAddr)
+
+	var last time.Time
+	for {
+		p, _, err := l.Receive()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !a.accept(target, p) {
+			continue
+		}
+
+		if a.Cooldown > 0 && !last.IsZero() && time.Since(last) < a.Cooldown {
+			continue
+		}
+
+		// The Cooldown applies even if hook fails, so the copies of a single
+		// request do not run a failing hook repeatedly.
+		last = time.Now()
+		if err := hook(); err != nil {
+			a.log(slog.LevelWarn, "sleep hook failed", slog.String("error", err.Error()))
+			continue
+		}
+
+		a.log(slog.LevelInfo, "ran sleep hook")
+	}
+}
+
+// accept reports whether p is a Sleep-on-LAN packet for target which also
+// carries a's password, if one is set.
+func (a *Agent) accept(target net.HardwareAddr, p *wol.MagicPacket) bool {
+	if !bytes.Equal(p.Target, target) {
+		return false
+	}
+
+	if len(a.Password) == 0 {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare(p.Password, a.Password) == 1
+}
+
+// log logs a message if a has a Logger.
+func (a *Agent) log(level slog.Level, msg string, args ...any) {
+	if a.Logger == nil {
+		return
+	}
+
+	a.Logger.Log(context.Background(), level, msg, args...)
+}
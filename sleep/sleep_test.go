@@ -0,0 +1,211 @@
+package sleep_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/sleep"
+)
+
+func TestAgentServe(t *testing.T) {
+	local := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	other := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+
+	var tests = []struct {
+		name     string
+		password []byte
+		send     func(c *wol.Client, addr string) error
+		ok       bool
+	}{
+		{
+			name: "wake packet",
+			send: func(c *wol.Client, addr string) error {
+				return c.Wake(addr, local)
+			},
+		},
+		{
+			name: "sleep other machine",
+			send: func(c *wol.Client, addr string) error {
+				return c.Sleep(addr, other)
+			},
+		},
+		{
+			name:     "bad password",
+			password: []byte{1, 2, 3, 4},
+			send: func(c *wol.Client, addr string) error {
+				return c.SleepPassword(addr, local, []byte{4, 3, 2, 1})
+			},
+		},
+		{
+			name: "OK",
+			send: func(c *wol.Client, addr string) error {
+				return c.Sleep(addr, local)
+			},
+			ok: true,
+		},
+		{
+			name:     "OK, password",
+			password: []byte{1, 2, 3, 4},
+			send: func(c *wol.Client, addr string) error {
+				return c.SleepPassword(addr, local, []byte{1, 2, 3, 4})
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := wol.Listen("127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+
+			c, err := wol.NewClient()
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer c.Close()
+
+			slept := make(chan struct{}, 1)
+			a := &sleep.Agent{
+				Interface: &net.Interface{HardwareAddr: local},
+				Password:  tt.password,
+				Hook: func() error {
+					slept <- struct{}{}
+					return nil
+				},
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- a.Serve(l) }()
+
+			if err := tt.send(c, l.Addr().String()); err != nil {
+				t.Fatalf("failed to send: %v", err)
+			}
+
+			select {
+			case <-slept:
+				if !tt.ok {
+					t.Fatal("hook ran for packet which should be ignored")
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.ok {
+					t.Fatal("hook did not run")
+				}
+			}
+
+			if err := l.Close(); err != nil {
+				t.Fatalf("failed to close listener: %v", err)
+			}
+			if err := <-done; err != nil {
+				t.Fatalf("failed to serve: %v", err)
+			}
+		})
+	}
+}
+
+func TestAgentServeHookError(t *testing.T) {
+	l, err := wol.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	c, err := wol.NewClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	local := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	// The hook always fails, but the agent keeps serving and runs it again
+	// for the next request.
+	ran := make(chan struct{}, 2)
+	a := &sleep.Agent{
+		Interface: &net.Interface{HardwareAddr: local},
+		Hook: func() error {
+			ran <- struct{}{}
+			return errors.New("hook failed")
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(l) }()
+
+	for i := 0; i < 2; i++ {
+		if err := c.Sleep(l.Addr().String(), local); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatalf("hook did not run for request %d", i)
+		}
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("failed to serve: %v", err)
+	}
+}
+
+func TestAgentServeCooldown(t *testing.T) {
+	l, err := wol.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	c, err := wol.NewClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	local := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	ran := make(chan struct{}, 3)
+	a := &sleep.Agent{
+		Interface: &net.Interface{HardwareAddr: local},
+		Cooldown:  time.Hour,
+		Hook: func() error {
+			ran <- struct{}{}
+			return nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(l) }()
+
+	// Senders commonly transmit several copies of a packet, but only the
+	// first may run the hook.
+	for i := 0; i < 3; i++ {
+		if err := c.Sleep(l.Addr().String(), local); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+	}
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("hook did not run")
+	}
+
+	select {
+	case <-ran:
+		t.Fatal("hook ran again during cooldown")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close listener: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("failed to serve: %v", err)
+	}
+}
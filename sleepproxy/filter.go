@@ -0,0 +1,45 @@
+package sleepproxy
+
+import (
+	"golang.org/x/net/bpf"
+)
+
+// EtherTypes and protocol values matched by the filter.
+const (
+	etherTypeARP  = 0x0806
+	etherTypeIPv4 = 0x0800
+	protocolTCP   = 6
+)
+
+// TCP flags matched by the filter.
+const (
+	tcpSYN = 0x02
+	tcpACK = 0x10
+)
+
+// filter is a BPF filter which passes only ARP frames and IPv4 frames
+// carrying unfragmented TCP segments which open a connection: those with SYN
+// set and ACK unset. All other traffic to and from the machine is dropped in
+// the kernel.
+var filter = []bpf.Instruction{
+	// EtherType: ARP is always passed, and anything but IPv4 dropped.
+	bpf.LoadAbsolute{Off: 12, Size: 2},
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: etherTypeARP, SkipTrue: 9},
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: etherTypeIPv4, SkipFalse: 9},
+
+	// IPv4 protocol must be TCP, and the packet must not be a fragment
+	// other than the first.
+	bpf.LoadAbsolute{Off: 14 + 9, Size: 1},
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: protocolTCP, SkipFalse: 7},
+	bpf.LoadAbsolute{Off: 14 + 6, Size: 2},
+	bpf.JumpIf{Cond: bpf.JumpBitsSet, Val: 0x1fff, SkipTrue: 5},
+
+	// Find the TCP header using the IPv4 header length, and check its flags.
+	bpf.LoadMemShift{Off: 14},
+	bpf.LoadIndirect{Off: 14 + 13, Size: 1},
+	bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: tcpSYN | tcpACK},
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: tcpSYN, SkipFalse: 1},
+
+	bpf.RetConstant{Val: 262144},
+	bpf.RetConstant{Val: 0},
+}
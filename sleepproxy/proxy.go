@@ -0,0 +1,351 @@
+// Package sleepproxy implements an ARP sleep proxy, which answers for sleeping
+// machines and wakes them when they are needed, in the manner of Apple's
+// Bonjour Sleep Proxy.
+//
+// Once a machine goes to sleep, it is registered with a Proxy. The Proxy
+// claims the machine's IPv4 address by answering ARP requests for it with
+// its own hardware address, so clients send their traffic to the Proxy
+// instead. When a client attempts to open a TCP connection to one of the
+// machine's configured ports, the Proxy wakes the machine with a magic packet
+// and stops proxying for it, announcing the machine's own hardware address so
+// the client's retransmissions reach the machine once it is awake.
+package sleepproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mdlayher/arp"
+	"github.com/mdlayher/ethernet"
+	"github.com/mdlayher/packet"
+	"github.com/mdlayher/wol"
+	"golang.org/x/net/bpf"
+	"golang.org/x/net/ipv4"
+)
+
+// ethPAll is the Linux ETH_P_ALL protocol, which receives frames of every
+// EtherType.
+const ethPAll = 0x0003
+
+// A Host is a sleeping machine registered with a Proxy.
+type Host struct {
+	// IP and MAC are the machine's IPv4 address and hardware address.
+	IP  net.IP
+	MAC net.HardwareAddr
+
+	// Ports optionally specifies the TCP ports which wake the machine when
+	// a client connects to them. If empty, a connection to any port wakes
+	// the machine.
+	Ports []int
+
+	// Password is the machine's optional SecureOn password.
+	Password []byte
+}
+
+// wakes reports whether a connection to port should wake h.
+func (h *Host) wakes(port int) bool {
+	if len(h.Ports) == 0 {
+		return true
+	}
+
+	for _, p := range h.Ports {
+		if p == port {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A Proxy answers ARP requests on behalf of sleeping Hosts on a network
+// interface, and wakes them when clients attempt to connect to them.
+type Proxy struct {
+	// Logger optionally logs Hosts which are woken or which wake on their
+	// own.
+	Logger *slog.Logger
+
+	// Observer is optionally notified of each magic packet sent to wake a
+	// Host. It must be set before calling Serve.
+	Observer wol.Observer
+
+	ifi *net.Interface
+	p   net.PacketConn
+	c   *wol.RawClient
+
+	closed atomic.Bool
+
+	mu    sync.Mutex
+	hosts map[netip.Addr]*Host
+}
+
+// Listen creates a Proxy on the specified network interface. The Proxy's
+// socket uses a BPF filter so only ARP frames and TCP connection attempts are
+// delivered to userspace.
+//
+// Listen requires elevated privileges, as with wol.NewRawClient, and is only
+// supported on Linux.
+func Listen(ifi *net.Interface) (*Proxy, error) {
+	prog, err := bpf.Assemble(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := packet.Listen(ifi, packet.Raw, ethPAll, &packet.Config{Filter: prog})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConn(ifi, p), nil
+}
+
+// NewConn creates a Proxy which uses ifi's hardware address and an existing
+// net.PacketConn. p must read and write complete Ethernet frames and accept
+// addresses of type *packet.Addr. NewConn is useful for testing, in
+// combination with package woltest.
+//
+// Closing the Proxy closes p.
+func NewConn(ifi *net.Interface, p net.PacketConn) *Proxy {
+	return &Proxy{
+		ifi:   ifi,
+		p:     p,
+		c:     wol.NewRawClientConn(ifi, p),
+		hosts: make(map[netip.Addr]*Host),
+	}
+}
+
+// Close closes the Proxy's socket, causing Serve to return.
+func (p *Proxy) Close() error {
+	p.closed.Store(true)
+	return p.p.Close()
+}
+
+// Register begins proxying for h, which should be asleep, and announces that
+// h's IP address is now reachable at the Proxy's hardware address.
+func (p *Proxy) Register(h *Host) error {
+	ip, ok := netip.AddrFromSlice(h.IP.To4())
+	if !ok {
+		return fmt.Errorf("sleepproxy: host IP %s is not an IPv4 address", h.IP)
+	}
+	if len(h.MAC) != 6 {
+		return fmt.Errorf("sleepproxy: host hardware address %s is not an Ethernet address", h.MAC)
+	}
+
+	p.mu.Lock()
+	p.hosts[ip] = h
+	p.mu.Unlock()
+
+	return p.announce(ip, p.ifi.HardwareAddr)
+}
+
+// Unregister stops proxying for the Host with the specified IP address, and
+// announces that the address is again reachable at the Host's own hardware
+// address. Unregister is a no-op if no Host is registered with ip.
+func (p *Proxy) Unregister(ip net.IP) error {
+	addr, ok := netip.AddrFromSlice(ip.To4())
+	if !ok {
+		return nil
+	}
+
+	h := p.remove(addr)
+	if h == nil {
+		return nil
+	}
+
+	return p.announce(addr, h.MAC)
+}
+
+// Hosts returns the Hosts the Proxy is proxying for.
+func (p *Proxy) Hosts() []*Host {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hs := make([]*Host, 0, len(p.hosts))
+	for _, h := range p.hosts {
+		hs = append(hs, h)
+	}
+
+	return hs
+}
+
+// Serve answers ARP requests and wakes Hosts until the Proxy is closed.
+func (p *Proxy) Serve() error {
+	p.c.Observer = p.Observer
+
+	b := make([]byte, p.ifi.MTU+14)
+	if p.ifi.MTU == 0 {
+		b = make([]byte, 1514)
+	}
+
+	for {
+		n, _, err := p.p.ReadFrom(b)
+		if err != nil {
+			// Packet sockets do not report net.ErrClosed, so check
+			// whether the Proxy was closed as well.
+			if p.closed.Load() || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		f := new(ethernet.Frame)
+		if err := f.UnmarshalBinary(b[:n]); err != nil {
+			continue
+		}
+
+		// Frames sent by the Proxy itself may be looped back.
+		if bytes.Equal(f.Source, p.ifi.HardwareAddr) {
+			continue
+		}
+
+		switch f.EtherType {
+		case ethernet.EtherTypeARP:
+			err = p.handleARP(f)
+		case ethernet.EtherTypeIPv4:
+			err = p.handleIPv4(f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handleARP answers ARP requests for registered Hosts, and stops proxying for
+// Hosts which send ARP packets themselves because they are awake.
+func (p *Proxy) handleARP(f *ethernet.Frame) error {
+	ap := new(arp.Packet)
+	if err := ap.UnmarshalBinary(f.Payload); err != nil {
+		return nil
+	}
+
+	if h := p.lookup(ap.SenderIP); h != nil && bytes.Equal(ap.SenderHardwareAddr, h.MAC) {
+		p.remove(ap.SenderIP)
+		p.log("host is awake", h)
+		return nil
+	}
+
+	if ap.Operation != arp.OperationRequest || p.lookup(ap.TargetIP) == nil {
+		return nil
+	}
+
+	// Address conflict probes have an unspecified sender address, and
+	// must not be answered on behalf of a sleeping Host.
+	if !ap.SenderIP.IsValid() || ap.SenderIP.IsUnspecified() {
+		return nil
+	}
+
+	reply, err := arp.NewPacket(arp.OperationReply,
+		p.ifi.HardwareAddr, ap.TargetIP, ap.SenderHardwareAddr, ap.SenderIP)
+	if err != nil {
+		return err
+	}
+
+	return p.write(ap.SenderHardwareAddr, reply)
+}
+
+// handleIPv4 wakes a registered Host when a client attempts to open a TCP
+// connection to it.
+func (p *Proxy) handleIPv4(f *ethernet.Frame) error {
+	if !bytes.Equal(f.Destination, p.ifi.HardwareAddr) {
+		return nil
+	}
+
+	hdr, err := ipv4.ParseHeader(f.Payload)
+	if err != nil || hdr.Protocol != protocolTCP || hdr.FragOff != 0 || len(f.Payload) < hdr.Len+14 {
+		return nil
+	}
+
+	dst, ok := netip.AddrFromSlice(hdr.Dst.To4())
+	if !ok {
+		return nil
+	}
+
+	h := p.lookup(dst)
+	if h == nil {
+		return nil
+	}
+
+	tcp := f.Payload[hdr.Len:]
+	port := int(binary.BigEndian.Uint16(tcp[2:4]))
+	if tcp[13]&(tcpSYN|tcpACK) != tcpSYN || !h.wakes(port) {
+		return nil
+	}
+
+	if err := p.c.WakePassword(h.MAC, h.Password); err != nil {
+		return err
+	}
+
+	p.log("woke host", h, "client", hdr.Src.String(), "port", port)
+	return p.Unregister(h.IP)
+}
+
+// announce broadcasts a gratuitous ARP reply which maps ip to mac.
+func (p *Proxy) announce(ip netip.Addr, mac net.HardwareAddr) error {
+	ap, err := arp.NewPacket(arp.OperationReply, mac, ip, ethernet.Broadcast, ip)
+	if err != nil {
+		return err
+	}
+
+	return p.write(ethernet.Broadcast, ap)
+}
+
+// write sends ap in an Ethernet frame addressed to dst.
+func (p *Proxy) write(dst net.HardwareAddr, ap *arp.Packet) error {
+	pb, err := ap.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	// The frame is always sent from the Proxy's hardware address, even when
+	// announcing a Host's own address, so switches do not learn the Host's
+	// address on the Proxy's port.
+	fb, err := (&ethernet.Frame{
+		Destination: dst,
+		Source:      p.ifi.HardwareAddr,
+		EtherType:   ethernet.EtherTypeARP,
+		Payload:     pb,
+	}).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	_, err = p.p.WriteTo(fb, &packet.Addr{HardwareAddr: dst})
+	return err
+}
+
+// lookup returns the Host registered with ip, or nil if none is.
+func (p *Proxy) lookup(ip netip.Addr) *Host {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.hosts[ip]
+}
+
+// remove stops proxying for the Host registered with ip and returns it, or
+// returns nil if none is.
+func (p *Proxy) remove(ip netip.Addr) *Host {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h := p.hosts[ip]
+	delete(p.hosts, ip)
+	return h
+}
+
+// log logs a message about h if p has a Logger.
+func (p *Proxy) log(msg string, h *Host, args ...any) {
+	if p.Logger == nil {
+		return
+	}
+
+	args = append([]any{"ip", h.IP.String(), "mac", h.MAC.String()}, args...)
+	p.Logger.Log(context.Background(), slog.LevelInfo, msg, args...)
+}
@@ -0,0 +1,128 @@
+//go:build linux
+
+package sleepproxy
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/arp"
+	"github.com/mdlayher/ethernet"
+	"github.com/mdlayher/packet"
+	"github.com/mdlayher/wol"
+	"golang.org/x/sys/unix"
+)
+
+func TestProxyNetworkNamespace(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("skipping, must be run as root to create a network namespace")
+	}
+	if _, err := exec.LookPath("ip"); err != nil {
+		t.Skip("skipping, ip command not found")
+	}
+
+	// Move this test's thread into a new network namespace. The thread is
+	// never unlocked, so it is destroyed rather than reused when the test
+	// completes.
+	runtime.LockOSThread()
+	if err := unix.Unshare(unix.CLONE_NEWNET); err != nil {
+		t.Skipf("skipping, failed to create network namespace: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"link", "add", "veth0", "type", "veth", "peer", "name", "veth1"},
+		{"link", "set", "veth0", "up"},
+		{"link", "set", "veth1", "up"},
+	} {
+		if out, err := exec.Command("ip", args...).CombinedOutput(); err != nil {
+			t.Skipf("skipping, failed to configure veth pair: %v: %s", err, out)
+		}
+	}
+
+	ifi, err := net.InterfaceByName("veth0")
+	if err != nil {
+		t.Fatalf("failed to get proxy interface: %v", err)
+	}
+	cifi, err := net.InterfaceByName("veth1")
+	if err != nil {
+		t.Fatalf("failed to get client interface: %v", err)
+	}
+
+	p, err := Listen(ifi)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- p.Serve() }()
+	defer func() {
+		_ = p.Close()
+		if err := <-done; err != nil {
+			t.Errorf("failed to serve: %v", err)
+		}
+	}()
+
+	client, err := packet.Listen(cifi, packet.Raw, ethPAll, nil)
+	if err != nil {
+		t.Fatalf("failed to listen on client interface: %v", err)
+	}
+	defer client.Close()
+
+	if err := p.Register(&Host{IP: hostIP.AsSlice(), MAC: hostMAC, Ports: []int{22}}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	write(t, client, arpFrame(t, arp.OperationRequest, cifi.HardwareAddr, clientIP, ethernet.Broadcast, hostIP))
+	if !waitFrame(t, client, func(f *ethernet.Frame) bool {
+		ap := new(arp.Packet)
+		return f.EtherType == ethernet.EtherTypeARP &&
+			ap.UnmarshalBinary(f.Payload) == nil &&
+			ap.Operation == arp.OperationReply &&
+			bytes.Equal(ap.TargetHardwareAddr, cifi.HardwareAddr) &&
+			bytes.Equal(ap.SenderHardwareAddr, ifi.HardwareAddr)
+	}) {
+		t.Fatal("timed out waiting for ARP reply")
+	}
+
+	write(t, client, tcpFrame(t, cifi.HardwareAddr, ifi.HardwareAddr, hostIP, 22, tcpSYN, 0))
+	if !waitFrame(t, client, func(f *ethernet.Frame) bool {
+		mp := new(wol.MagicPacket)
+		return f.EtherType == wol.EtherType &&
+			mp.UnmarshalBinary(f.Payload) == nil &&
+			bytes.Equal(mp.Target, hostMAC)
+	}) {
+		t.Fatal("timed out waiting for magic packet")
+	}
+}
+
+// waitFrame reads Ethernet frames from pc until ok reports true for one of
+// them, or a timeout elapses.
+func waitFrame(t *testing.T, pc net.PacketConn, ok func(f *ethernet.Frame) bool) bool {
+	t.Helper()
+
+	if err := pc.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+
+	b := make([]byte, 1514)
+	for {
+		n, _, err := pc.ReadFrom(b)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return false
+			}
+
+			t.Fatalf("failed to read frame: %v", err)
+		}
+
+		f := new(ethernet.Frame)
+		if f.UnmarshalBinary(b[:n]) == nil && ok(f) {
+			return true
+		}
+	}
+}
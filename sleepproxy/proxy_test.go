@@ -0,0 +1,346 @@
+package sleepproxy
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/arp"
+	"github.com/mdlayher/ethernet"
+	"github.com/mdlayher/packet"
+	"github.com/mdlayher/wol/woltest"
+	"golang.org/x/net/bpf"
+	"golang.org/x/net/ipv4"
+)
+
+var (
+	hostMAC   = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+	clientMAC = net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	hostIP   = netip.MustParseAddr("192.0.2.10")
+	clientIP = netip.MustParseAddr("192.0.2.1")
+)
+
+func TestFilter(t *testing.T) {
+	dst := net.HardwareAddr{0x02, 0x00, 0x5e, 0x00, 0x00, 0x01}
+
+	var tests = []struct {
+		name  string
+		frame []byte
+		ok    bool
+	}{
+		{
+			name:  "ARP request",
+			frame: arpFrame(t, arp.OperationRequest, clientMAC, clientIP, ethernet.Broadcast, hostIP),
+			ok:    true,
+		},
+		{
+			name:  "TCP SYN",
+			frame: tcpFrame(t, clientMAC, dst, hostIP, 22, tcpSYN, 0),
+			ok:    true,
+		},
+		{
+			name:  "TCP SYN with IPv4 options",
+			frame: tcpFrame(t, clientMAC, dst, hostIP, 22, tcpSYN, 8),
+			ok:    true,
+		},
+		{
+			name:  "TCP SYN-ACK",
+			frame: tcpFrame(t, clientMAC, dst, hostIP, 22, tcpSYN|tcpACK, 0),
+		},
+		{
+			name:  "TCP ACK",
+			frame: tcpFrame(t, clientMAC, dst, hostIP, 22, tcpACK, 0),
+		},
+		{
+			name:  "TCP fragment",
+			frame: withFragment(tcpFrame(t, clientMAC, dst, hostIP, 22, tcpSYN, 0)),
+		},
+		{
+			name:  "UDP",
+			frame: withProto(tcpFrame(t, clientMAC, dst, hostIP, 22, tcpSYN, 0), 17),
+		},
+		{
+			name:  "IPv6",
+			frame: frame(t, clientMAC, dst, ethernet.EtherTypeIPv6, make([]byte, 60)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vm, err := bpf.NewVM(filter)
+			if err != nil {
+				t.Fatalf("failed to load filter: %v", err)
+			}
+
+			n, err := vm.Run(tt.frame)
+			if err != nil {
+				t.Fatalf("failed to run filter: %v", err)
+			}
+
+			if (n > 0) != tt.ok {
+				t.Fatalf("unexpected filter result: accepted %d bytes, want match: %v", n, tt.ok)
+			}
+			if n > 0 && n < len(tt.frame) {
+				t.Fatalf("filter truncated frame: %d of %d bytes", n, len(tt.frame))
+			}
+		})
+	}
+}
+
+func TestProxy(t *testing.T) {
+	n := woltest.NewNetwork()
+	host := n.AddHost(hostMAC, nil)
+
+	ifi, pc := n.ListenRaw()
+	p := testProxy(t, ifi, pc)
+
+	cifi, client := n.ListenRaw()
+	defer client.Close()
+
+	if err := p.Register(&Host{IP: hostIP.AsSlice(), MAC: hostMAC, Ports: []int{22}}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	// Registering announces that the proxy now answers for the host.
+	if diff := cmp.Diff(ifi.HardwareAddr, readARP(t, client).SenderHardwareAddr); diff != "" {
+		t.Fatalf("unexpected announced hardware address (-want +got):\n%s", diff)
+	}
+
+	// Address conflict probes are ignored, and real requests are answered
+	// with the proxy's hardware address.
+	for _, src := range []netip.Addr{netip.IPv4Unspecified(), clientIP} {
+		write(t, client, arpFrame(t, arp.OperationRequest, cifi.HardwareAddr, src, ethernet.Broadcast, hostIP))
+	}
+
+	reply := readARP(t, client)
+	want := &arp.Packet{
+		HardwareType:       1,
+		ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+		HardwareAddrLength: 6,
+		IPLength:           4,
+		Operation:          arp.OperationReply,
+		SenderHardwareAddr: ifi.HardwareAddr,
+		SenderIP:           hostIP,
+		TargetHardwareAddr: cifi.HardwareAddr,
+		TargetIP:           clientIP,
+	}
+	if diff := cmp.Diff(want, reply, cmp.Comparer(func(x, y netip.Addr) bool { return x == y })); diff != "" {
+		t.Fatalf("unexpected ARP reply (-want +got):\n%s", diff)
+	}
+
+	// Only a connection attempt to a configured port wakes the host.
+	write(t, client, tcpFrame(t, cifi.HardwareAddr, ifi.HardwareAddr, hostIP, 80, tcpSYN, 0))
+	write(t, client, tcpFrame(t, cifi.HardwareAddr, ifi.HardwareAddr, hostIP, 22, tcpSYN, 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := host.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for host: %v", err)
+	}
+	if got := host.Packets(); got != 1 {
+		t.Fatalf("expected 1 magic packet, but got: %d", got)
+	}
+
+	// Once woken, the host's own hardware address is announced and the
+	// proxy no longer answers for it.
+	if diff := cmp.Diff(hostMAC, readARP(t, client).SenderHardwareAddr); diff != "" {
+		t.Fatalf("unexpected announced hardware address (-want +got):\n%s", diff)
+	}
+	if hs := p.Hosts(); len(hs) != 0 {
+		t.Fatalf("expected no proxied hosts, but got: %d", len(hs))
+	}
+}
+
+func TestProxyHostAwake(t *testing.T) {
+	n := woltest.NewNetwork()
+	ifi, pc := n.ListenRaw()
+	p := testProxy(t, ifi, pc)
+
+	_, client := n.ListenRaw()
+	defer client.Close()
+
+	if err := p.Register(&Host{IP: hostIP.AsSlice(), MAC: hostMAC}); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	_ = readARP(t, client)
+
+	// A host which sends ARP packets itself has woken on its own.
+	write(t, client, arpFrame(t, arp.OperationRequest, hostMAC, hostIP, ethernet.Broadcast, clientIP))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(p.Hosts()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for host to be unregistered")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProxyRegisterInvalid(t *testing.T) {
+	n := woltest.NewNetwork()
+	ifi, pc := n.ListenRaw()
+	p := NewConn(ifi, pc)
+	defer p.Close()
+
+	for _, h := range []*Host{
+		{IP: net.ParseIP("2001:db8::1"), MAC: hostMAC},
+		{IP: hostIP.AsSlice(), MAC: net.HardwareAddr{0x00, 0x11}},
+	} {
+		if err := p.Register(h); err == nil {
+			t.Fatalf("expected an error registering %s/%s", h.IP, h.MAC)
+		}
+	}
+}
+
+// testProxy starts a Proxy using ifi and pc, and closes it when the test
+// completes.
+func testProxy(t *testing.T, ifi *net.Interface, pc net.PacketConn) *Proxy {
+	t.Helper()
+
+	p := NewConn(ifi, pc)
+
+	done := make(chan error, 1)
+	go func() { done <- p.Serve() }()
+
+	t.Cleanup(func() {
+		_ = p.Close()
+		if err := <-done; err != nil {
+			t.Errorf("failed to serve: %v", err)
+		}
+	})
+
+	return p
+}
+
+// write sends an Ethernet frame on pc.
+func write(t *testing.T, pc net.PacketConn, b []byte) {
+	t.Helper()
+
+	f := new(ethernet.Frame)
+	if err := f.UnmarshalBinary(b); err != nil {
+		t.Fatalf("failed to unmarshal frame: %v", err)
+	}
+
+	if _, err := pc.WriteTo(b, &packet.Addr{HardwareAddr: f.Destination}); err != nil {
+		t.Fatalf("failed to write frame: %v", err)
+	}
+}
+
+// readARP reads Ethernet frames from pc until an ARP packet is received.
+func readARP(t *testing.T, pc net.PacketConn) *arp.Packet {
+	t.Helper()
+
+	if err := pc.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+
+	b := make([]byte, 1514)
+	for {
+		n, _, err := pc.ReadFrom(b)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Fatal("timed out waiting for ARP packet")
+			}
+
+			t.Fatalf("failed to read frame: %v", err)
+		}
+
+		f := new(ethernet.Frame)
+		if err := f.UnmarshalBinary(b[:n]); err != nil || f.EtherType != ethernet.EtherTypeARP {
+			continue
+		}
+
+		ap := new(arp.Packet)
+		if err := ap.UnmarshalBinary(f.Payload); err != nil {
+			t.Fatalf("failed to unmarshal ARP packet: %v", err)
+		}
+
+		return ap
+	}
+}
+
+// arpFrame builds an Ethernet frame carrying an ARP packet.
+func arpFrame(t *testing.T, op arp.Operation, srcMAC net.HardwareAddr, srcIP netip.Addr, dst net.HardwareAddr, targetIP netip.Addr) []byte {
+	t.Helper()
+
+	ap, err := arp.NewPacket(op, srcMAC, srcIP, ethernet.Broadcast, targetIP)
+	if err != nil {
+		t.Fatalf("failed to create ARP packet: %v", err)
+	}
+
+	pb, err := ap.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal ARP packet: %v", err)
+	}
+
+	return frame(t, srcMAC, dst, ethernet.EtherTypeARP, pb)
+}
+
+// tcpFrame builds an Ethernet frame carrying an IPv4 packet from clientIP
+// with a TCP header with the specified destination port and flags. The IPv4
+// header is padded with optlen bytes of options.
+func tcpFrame(t *testing.T, src, dst net.HardwareAddr, dstIP netip.Addr, port int, flags byte, optlen int) []byte {
+	t.Helper()
+
+	tcp := make([]byte, 20)
+	binary.BigEndian.PutUint16(tcp[0:2], 40000)
+	binary.BigEndian.PutUint16(tcp[2:4], uint16(port))
+	tcp[12] = 5 << 4
+	tcp[13] = flags
+
+	h := &ipv4.Header{
+		Version:  ipv4.Version,
+		Len:      ipv4.HeaderLen + optlen,
+		TotalLen: ipv4.HeaderLen + optlen + len(tcp),
+		TTL:      64,
+		Protocol: protocolTCP,
+		Src:      clientIP.AsSlice(),
+		Dst:      dstIP.AsSlice(),
+		Options:  make([]byte, optlen),
+	}
+
+	hb, err := h.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal IPv4 header: %v", err)
+	}
+
+	return frame(t, src, dst, ethernet.EtherTypeIPv4, append(hb, tcp...))
+}
+
+// frame builds an Ethernet frame with the specified EtherType and payload.
+func frame(t *testing.T, src, dst net.HardwareAddr, et ethernet.EtherType, payload []byte) []byte {
+	t.Helper()
+
+	b, err := (&ethernet.Frame{
+		Destination: dst,
+		Source:      src,
+		EtherType:   et,
+		Payload:     payload,
+	}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal frame: %v", err)
+	}
+
+	return b
+}
+
+// withProto overwrites the IPv4 protocol of an Ethernet frame.
+func withProto(b []byte, proto byte) []byte {
+	b[14+9] = proto
+	return b
+}
+
+// withFragment marks the IPv4 packet in an Ethernet frame as a later fragment.
+func withFragment(b []byte) []byte {
+	binary.BigEndian.PutUint16(b[14+6:14+8], 185)
+	return b
+}
@@ -0,0 +1,211 @@
+package sniff
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/mdlayher/ethernet"
+	"github.com/mdlayher/wol"
+	"golang.org/x/net/bpf"
+)
+
+// Offsets of fields within an Ethernet frame carrying an IPv4 or IPv6
+// packet, and well-known values matched by the filter.
+const (
+	offEtherType  = 12
+	offPayload    = 14
+	offIPv4Proto  = offPayload + 9
+	offIPv4Frag   = offPayload + 6
+	offIPv6Next   = offPayload + 6
+	ipv6HeaderLen = 40
+	udpHeaderLen  = 8
+	protoUDP      = 17
+
+	// ipv4FragOffset masks the fragment offset of an IPv4 packet. Only the
+	// first fragment of a packet contains its UDP header.
+	ipv4FragOffset = 0x1fff
+
+	// snapLen is the number of bytes of a matching frame delivered to
+	// userspace; effectively the whole frame.
+	snapLen = 0x40000
+)
+
+// Scratch memory slots used by the filter.
+const (
+	memMACHigh = iota
+	memMACLow
+	memOffset
+)
+
+// Filter returns a classic BPF program which accepts only Ethernet frames
+// carrying Wake-on-LAN magic packets: either directly, with EtherType 0x0842,
+// or at the start of an IPv4 or IPv6 UDP payload sent to any port.
+//
+// Classic BPF cannot loop, so the check that the target hardware address is
+// repeated 16 times is unrolled. If target is not nil, only magic packets for
+// target are accepted.
+//
+// Filter is used by Listen, and is exported so it can be used with other
+// packet capture mechanisms.
+func Filter(target net.HardwareAddr) []bpf.Instruction {
+	a := newAssembler()
+
+	// Find the start of the magic packet and store its offset in X.
+	a.add(bpf.LoadAbsolute{Off: offEtherType, Size: 2})
+	a.jumpIf(bpf.JumpEqual, wol.EtherType, "ethernet")
+	a.jumpIf(bpf.JumpEqual, uint32(ethernet.EtherTypeIPv4), "ipv4")
+	a.jumpIf(bpf.JumpEqual, uint32(ethernet.EtherTypeIPv6), "ipv6")
+	a.jump("reject")
+
+	a.label("ethernet")
+	a.add(bpf.LoadConstant{Dst: bpf.RegX, Val: offPayload})
+	a.jump("match")
+
+	a.label("ipv4")
+	a.add(bpf.LoadAbsolute{Off: offIPv4Proto, Size: 1})
+	a.jumpIf(bpf.JumpNotEqual, protoUDP, "reject")
+	a.add(bpf.LoadAbsolute{Off: offIPv4Frag, Size: 2})
+	a.jumpIf(bpf.JumpBitsSet, ipv4FragOffset, "reject")
+	// X = 4 * IHL, the variable length of the IPv4 header.
+	a.add(
+		bpf.LoadMemShift{Off: offPayload},
+		bpf.TXA{},
+		bpf.ALUOpConstant{Op: bpf.ALUOpAdd, Val: offPayload + udpHeaderLen},
+		bpf.TAX{},
+	)
+	a.jump("match")
+
+	a.label("ipv6")
+	a.add(bpf.LoadAbsolute{Off: offIPv6Next, Size: 1})
+	a.jumpIf(bpf.JumpNotEqual, protoUDP, "reject")
+	a.add(bpf.LoadConstant{Dst: bpf.RegX, Val: offPayload + ipv6HeaderLen + udpHeaderLen})
+
+	a.label("match")
+	a.add(bpf.StoreScratch{Src: bpf.RegX, N: memOffset})
+
+	// Synchronization stream.
+	a.add(bpf.LoadIndirect{Off: 0, Size: 4})
+	a.jumpIf(bpf.JumpNotEqual, 0xffffffff, "reject")
+	a.add(bpf.LoadIndirect{Off: 4, Size: 2})
+	a.jumpIf(bpf.JumpNotEqual, 0xffff, "reject")
+
+	// First copy of the target hardware address, which is optionally
+	// compared against target and stored for comparison with each repetition.
+	a.add(bpf.LoadIndirect{Off: 6, Size: 4})
+	if target != nil {
+		a.jumpIf(bpf.JumpNotEqual, binary.BigEndian.Uint32(target[0:4]), "reject")
+	}
+	a.add(
+		bpf.StoreScratch{Src: bpf.RegA, N: memMACHigh},
+		bpf.LoadIndirect{Off: 10, Size: 2},
+	)
+	if target != nil {
+		a.jumpIf(bpf.JumpNotEqual, uint32(binary.BigEndian.Uint16(target[4:6])), "reject")
+	}
+	a.add(bpf.StoreScratch{Src: bpf.RegA, N: memMACLow})
+
+	// Remaining 15 repetitions. X holds the offset for each load, then the
+	// stored value for each comparison.
+	for i := 1; i < 16; i++ {
+		off := uint32(6 + 6*i)
+
+		a.add(
+			bpf.LoadScratch{Dst: bpf.RegX, N: memOffset},
+			bpf.LoadIndirect{Off: off, Size: 4},
+			bpf.LoadScratch{Dst: bpf.RegX, N: memMACHigh},
+		)
+		a.jumpIfX(bpf.JumpNotEqual, "reject")
+
+		a.add(
+			bpf.LoadScratch{Dst: bpf.RegX, N: memOffset},
+			bpf.LoadIndirect{Off: off + 4, Size: 2},
+			bpf.LoadScratch{Dst: bpf.RegX, N: memMACLow},
+		)
+		a.jumpIfX(bpf.JumpNotEqual, "reject")
+	}
+
+	a.add(bpf.RetConstant{Val: snapLen})
+
+	a.label("reject")
+	a.add(bpf.RetConstant{Val: 0})
+
+	return a.program()
+}
+
+// An assembler builds a BPF program using named labels as jump targets.
+// Classic BPF only permits forward jumps.
+type assembler struct {
+	ins    []bpf.Instruction
+	labels map[string]int
+	fixups []fixup
+}
+
+// A fixup is a jump instruction whose target label is resolved once the
+// program is complete.
+type fixup struct {
+	index int
+	label string
+}
+
+func newAssembler() *assembler {
+	return &assembler{labels: make(map[string]int)}
+}
+
+// add appends instructions to the program.
+func (a *assembler) add(ins ...bpf.Instruction) {
+	a.ins = append(a.ins, ins...)
+}
+
+// label marks the next instruction as the target of label.
+func (a *assembler) label(label string) {
+	a.labels[label] = len(a.ins)
+}
+
+// jump unconditionally jumps to label.
+func (a *assembler) jump(label string) {
+	a.fixups = append(a.fixups, fixup{index: len(a.ins), label: label})
+	a.add(bpf.Jump{})
+}
+
+// jumpIf jumps to label if A satisfies cond with val, and otherwise
+// continues to the next instruction.
+func (a *assembler) jumpIf(cond bpf.JumpTest, val uint32, label string) {
+	a.fixups = append(a.fixups, fixup{index: len(a.ins), label: label})
+	a.add(bpf.JumpIf{Cond: cond, Val: val})
+}
+
+// jumpIfX jumps to label if A satisfies cond with X, and otherwise continues
+// to the next instruction.
+func (a *assembler) jumpIfX(cond bpf.JumpTest, label string) {
+	a.fixups = append(a.fixups, fixup{index: len(a.ins), label: label})
+	a.add(bpf.JumpIfX{Cond: cond})
+}
+
+// program resolves all jumps and returns the complete program.
+func (a *assembler) program() []bpf.Instruction {
+	for _, f := range a.fixups {
+		target, ok := a.labels[f.label]
+		if !ok || target <= f.index {
+			panic("sniff: invalid jump to label " + f.label)
+		}
+
+		skip := target - f.index - 1
+		if _, ok := a.ins[f.index].(bpf.Jump); !ok && skip > 0xff {
+			panic("sniff: conditional jump to label " + f.label + " is too far")
+		}
+
+		switch ins := a.ins[f.index].(type) {
+		case bpf.Jump:
+			ins.Skip = uint32(skip)
+			a.ins[f.index] = ins
+		case bpf.JumpIf:
+			ins.SkipTrue = uint8(skip)
+			a.ins[f.index] = ins
+		case bpf.JumpIfX:
+			ins.SkipTrue = uint8(skip)
+			a.ins[f.index] = ins
+		}
+	}
+
+	return a.ins
+}
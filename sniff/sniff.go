@@ -0,0 +1,187 @@
+// Package sniff implements a passive sniffer for Wake-on-LAN magic packets.
+//
+// A wol.Listener only receives magic packets sent to a single UDP port or
+// with EtherType 0x0842. A Sniffer instead observes every frame on a network
+// interface, and uses an in-kernel BPF filter so that only frames carrying
+// magic packets, whether sent directly over Ethernet or to any UDP port, are
+// delivered to userspace.
+package sniff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+
+	"github.com/mdlayher/ethernet"
+	"github.com/mdlayher/packet"
+	"github.com/mdlayher/wol"
+	"golang.org/x/net/bpf"
+)
+
+// ethPAll is the Linux ETH_P_ALL protocol, which receives frames of every
+// EtherType.
+const ethPAll = 0x0003
+
+// Config contains configuration for a Sniffer.
+type Config struct {
+	// Target optionally specifies a hardware address. If set, only magic
+	// packets for Target are captured.
+	Target net.HardwareAddr
+
+	// Promiscuous specifies whether the network interface should be placed
+	// in promiscuous mode, to capture magic packets unicast to other
+	// machines.
+	Promiscuous bool
+}
+
+// A Sniffer captures Wake-on-LAN magic packets on a network interface.
+type Sniffer struct {
+	p      net.PacketConn
+	target net.HardwareAddr
+}
+
+// Listen creates a Sniffer which captures magic packets on the specified
+// network interface. If cfg is nil, a default configuration is used.
+//
+// Listen requires elevated privileges, as with wol.NewRawClient, and is only
+// supported on Linux.
+func Listen(ifi *net.Interface, cfg *Config) (*Sniffer, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	prog, err := bpf.Assemble(Filter(cfg.Target))
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := packet.Listen(ifi, packet.Raw, ethPAll, &packet.Config{Filter: prog})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Promiscuous {
+		if err := c.SetPromiscuous(true); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+
+	return &Sniffer{
+		p:      c,
+		target: cfg.Target,
+	}, nil
+}
+
+// Close closes a Sniffer's socket.
+func (s *Sniffer) Close() error {
+	return s.p.Close()
+}
+
+// A Capture is a magic packet captured by a Sniffer.
+type Capture struct {
+	// Packet is the captured magic packet.
+	Packet *wol.MagicPacket
+
+	// Source and Destination are the addresses of the Ethernet frame which
+	// carried Packet.
+	Source, Destination net.HardwareAddr
+
+	// UDPSource and UDPDestination are the addresses of the UDP datagram
+	// which carried Packet. They are nil if Packet was sent directly over
+	// Ethernet.
+	UDPSource, UDPDestination *net.UDPAddr
+}
+
+// Capture blocks until a magic packet is captured.
+func (s *Sniffer) Capture() (*Capture, error) {
+	b := make([]byte, 1<<16)
+	for {
+		n, _, err := s.p.ReadFrom(b)
+		if err != nil {
+			return nil, err
+		}
+
+		// The BPF filter only delivers frames whose payload begins with a
+		// magic packet, but the packet must also be checked in full.
+		c, ok := parse(b[:n])
+		if !ok || (s.target != nil && !bytes.Equal(c.Packet.Target, s.target)) {
+			continue
+		}
+
+		return c, nil
+	}
+}
+
+// parse parses a magic packet from an Ethernet frame, carried either
+// directly or in a UDP datagram.
+func parse(b []byte) (*Capture, bool) {
+	var f ethernet.Frame
+	if err := f.UnmarshalBinary(b); err != nil {
+		return nil, false
+	}
+
+	c := &Capture{
+		Source:      f.Source,
+		Destination: f.Destination,
+	}
+
+	payload := f.Payload
+	switch f.EtherType {
+	case wol.EtherType:
+	case ethernet.EtherTypeIPv4:
+		b := f.Payload
+		if len(b) < 20 {
+			return nil, false
+		}
+
+		ihl := int(b[0]&0x0f) * 4
+		total := int(binary.BigEndian.Uint16(b[2:4]))
+		if b[9] != protoUDP || ihl < 20 || total < ihl || total > len(b) {
+			return nil, false
+		}
+
+		c.UDPSource = &net.UDPAddr{IP: net.IP(append([]byte(nil), b[12:16]...))}
+		c.UDPDestination = &net.UDPAddr{IP: net.IP(append([]byte(nil), b[16:20]...))}
+		payload = b[ihl:total]
+	case ethernet.EtherTypeIPv6:
+		b := f.Payload
+		if len(b) < ipv6HeaderLen {
+			return nil, false
+		}
+
+		total := ipv6HeaderLen + int(binary.BigEndian.Uint16(b[4:6]))
+		if b[6] != protoUDP || total > len(b) {
+			return nil, false
+		}
+
+		c.UDPSource = &net.UDPAddr{IP: net.IP(append([]byte(nil), b[8:24]...))}
+		c.UDPDestination = &net.UDPAddr{IP: net.IP(append([]byte(nil), b[24:40]...))}
+		payload = b[ipv6HeaderLen:total]
+	default:
+		return nil, false
+	}
+
+	if c.UDPSource != nil {
+		if len(payload) < udpHeaderLen {
+			return nil, false
+		}
+
+		ulen := int(binary.BigEndian.Uint16(payload[4:6]))
+		if ulen < udpHeaderLen || ulen > len(payload) {
+			return nil, false
+		}
+
+		c.UDPSource.Port = int(binary.BigEndian.Uint16(payload[0:2]))
+		c.UDPDestination.Port = int(binary.BigEndian.Uint16(payload[2:4]))
+		payload = payload[udpHeaderLen:ulen]
+	}
+
+	p := new(wol.MagicPacket)
+	if err := p.UnmarshalBinary(payload); err != nil {
+		return nil, false
+	}
+	c.Packet = p
+
+	return c, true
+}
@@ -0,0 +1,64 @@
+//go:build linux
+
+package sniff
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/wol"
+)
+
+func TestSnifferLoopback(t *testing.T) {
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skipf("skipping, no loopback interface: %v", err)
+	}
+
+	s, err := Listen(lo, &Config{Target: target})
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			t.Skip("skipping, permission denied (try setting CAP_NET_RAW capability)")
+		}
+
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer s.Close()
+
+	// Bind a socket so the magic packets are not rejected with ICMP.
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	c, err := wol.NewClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	// The first magic packet should be filtered out.
+	for _, mac := range []net.HardwareAddr{other, target} {
+		if err := c.Wake(pc.LocalAddr().String(), mac); err != nil {
+			t.Fatalf("failed to wake: %v", err)
+		}
+	}
+
+	got, err := s.Capture()
+	if err != nil {
+		t.Fatalf("failed to capture: %v", err)
+	}
+
+	want := &wol.MagicPacket{Target: target, Password: []byte{}}
+	if diff := cmp.Diff(want, got.Packet); diff != "" {
+		t.Fatalf("unexpected magic packet (-want +got):\n%s", diff)
+	}
+
+	if got.UDPDestination.String() != pc.LocalAddr().String() {
+		t.Fatalf("unexpected destination: %s", got.UDPDestination)
+	}
+}
@@ -0,0 +1,218 @@
+package sniff
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/ethernet"
+	"github.com/mdlayher/wol"
+	"golang.org/x/net/bpf"
+)
+
+var (
+	target = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+	other  = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x41}
+
+	srcMAC = net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+)
+
+func TestFilterAndParse(t *testing.T) {
+	magic := mustMagic(t, target, nil)
+	withPassword := mustMagic(t, target, []byte{1, 2, 3, 4})
+
+	// A magic packet whose 16th repetition of the target is corrupted.
+	corrupt := mustMagic(t, target, nil)
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	var (
+		src4 = net.IPv4(192, 0, 2, 1).To4()
+		dst4 = net.IPv4(192, 0, 2, 255).To4()
+		src6 = net.ParseIP("2001:db8::1")
+		dst6 = net.ParseIP("ff02::1")
+	)
+
+	var tests = []struct {
+		name   string
+		frame  []byte
+		target net.HardwareAddr
+		want   *Capture
+	}{
+		{
+			name:  "EtherType",
+			frame: mustFrame(t, wol.EtherType, magic),
+			want: &Capture{
+				Packet: &wol.MagicPacket{Target: target, Password: []byte{}},
+			},
+		},
+		{
+			name:  "IPv4 UDP",
+			frame: mustFrame(t, ethernet.EtherTypeIPv4, ipv4(src4, dst4, 0, udp(40000, 9, withPassword))),
+			want: &Capture{
+				Packet:         &wol.MagicPacket{Target: target, Password: []byte{1, 2, 3, 4}},
+				UDPSource:      &net.UDPAddr{IP: src4, Port: 40000},
+				UDPDestination: &net.UDPAddr{IP: dst4, Port: 9},
+			},
+		},
+		{
+			name:  "IPv4 UDP with options, arbitrary port",
+			frame: mustFrame(t, ethernet.EtherTypeIPv4, ipv4(src4, dst4, 8, udp(40000, 12345, magic))),
+			want: &Capture{
+				Packet:         &wol.MagicPacket{Target: target, Password: []byte{}},
+				UDPSource:      &net.UDPAddr{IP: src4, Port: 40000},
+				UDPDestination: &net.UDPAddr{IP: dst4, Port: 12345},
+			},
+		},
+		{
+			name:  "IPv6 UDP",
+			frame: mustFrame(t, ethernet.EtherTypeIPv6, ipv6(src6, dst6, udp(40000, 7, magic))),
+			want: &Capture{
+				Packet:         &wol.MagicPacket{Target: target, Password: []byte{}},
+				UDPSource:      &net.UDPAddr{IP: src6, Port: 40000},
+				UDPDestination: &net.UDPAddr{IP: dst6, Port: 7},
+			},
+		},
+		{
+			name:   "matching target",
+			frame:  mustFrame(t, wol.EtherType, magic),
+			target: target,
+			want: &Capture{
+				Packet: &wol.MagicPacket{Target: target, Password: []byte{}},
+			},
+		},
+		{
+			name:   "other target",
+			frame:  mustFrame(t, wol.EtherType, magic),
+			target: other,
+		},
+		{
+			name:  "corrupt repetition",
+			frame: mustFrame(t, wol.EtherType, corrupt),
+		},
+		{
+			name:  "IPv4 TCP",
+			frame: mustFrame(t, ethernet.EtherTypeIPv4, withProto(ipv4(src4, dst4, 0, udp(40000, 9, magic)), 6)),
+		},
+		{
+			name:  "IPv4 fragment",
+			frame: mustFrame(t, ethernet.EtherTypeIPv4, withFragment(ipv4(src4, dst4, 0, udp(40000, 9, magic)))),
+		},
+		{
+			name:  "ARP",
+			frame: mustFrame(t, ethernet.EtherTypeARP, magic),
+		},
+		{
+			name:  "short",
+			frame: mustFrame(t, wol.EtherType, magic[:50]),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vm, err := bpf.NewVM(Filter(tt.target))
+			if err != nil {
+				t.Fatalf("failed to load filter: %v", err)
+			}
+
+			n, err := vm.Run(tt.frame)
+			if err != nil {
+				t.Fatalf("failed to run filter: %v", err)
+			}
+
+			if want := tt.want != nil; (n > 0) != want {
+				t.Fatalf("unexpected filter result: accepted %d bytes, want match: %v", n, want)
+			}
+			if n == 0 {
+				return
+			}
+			if n < len(tt.frame) {
+				t.Fatalf("filter truncated frame: %d of %d bytes", n, len(tt.frame))
+			}
+
+			got, ok := parse(tt.frame)
+			if !ok {
+				t.Fatal("failed to parse frame accepted by filter")
+			}
+
+			tt.want.Source = srcMAC
+			tt.want.Destination = ethernet.Broadcast
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected capture (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func mustMagic(t *testing.T, target net.HardwareAddr, password []byte) []byte {
+	t.Helper()
+
+	b, err := (&wol.MagicPacket{Target: target, Password: password}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal magic packet: %v", err)
+	}
+
+	return b
+}
+
+func mustFrame(t *testing.T, et ethernet.EtherType, payload []byte) []byte {
+	t.Helper()
+
+	b, err := (&ethernet.Frame{
+		Destination: ethernet.Broadcast,
+		Source:      srcMAC,
+		EtherType:   et,
+		Payload:     payload,
+	}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal frame: %v", err)
+	}
+
+	return b
+}
+
+func udp(src, dst uint16, payload []byte) []byte {
+	b := make([]byte, udpHeaderLen, udpHeaderLen+len(payload))
+	binary.BigEndian.PutUint16(b[0:2], src)
+	binary.BigEndian.PutUint16(b[2:4], dst)
+	binary.BigEndian.PutUint16(b[4:6], uint16(udpHeaderLen+len(payload)))
+
+	return append(b, payload...)
+}
+
+// ipv4 builds an IPv4 packet carrying a UDP datagram, with nopts bytes of
+// options. Checksums are not computed.
+func ipv4(src, dst net.IP, nopts int, payload []byte) []byte {
+	hl := 20 + nopts
+	b := make([]byte, hl, hl+len(payload))
+	b[0] = 0x40 | byte(hl/4)
+	binary.BigEndian.PutUint16(b[2:4], uint16(hl+len(payload)))
+	b[8] = 64
+	b[9] = protoUDP
+	copy(b[12:16], src)
+	copy(b[16:20], dst)
+
+	return append(b, payload...)
+}
+
+func ipv6(src, dst net.IP, payload []byte) []byte {
+	b := make([]byte, ipv6HeaderLen, ipv6HeaderLen+len(payload))
+	b[0] = 0x60
+	binary.BigEndian.PutUint16(b[4:6], uint16(len(payload)))
+	b[6] = protoUDP
+	b[7] = 64
+	copy(b[8:24], src)
+	copy(b[24:40], dst)
+
+	return append(b, payload...)
+}
+
+func withProto(b []byte, proto byte) []byte {
+	b[9] = proto
+	return b
+}
+
+func withFragment(b []byte) []byte {
+	binary.BigEndian.PutUint16(b[6:8], 185)
+	return b
+}
@@ -0,0 +1,104 @@
+package tracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// A leaseRequest is the body of a request to acquire a Lease.
+type leaseRequest struct {
+	Host   string `json:"host"`
+	Holder string `json:"holder"`
+
+	// TTL is a duration string such as "1h", or empty to hold the Lease
+	// until it is released.
+	TTL string `json:"ttl"`
+}
+
+// Handler returns an http.Handler which serves t's state and lease API as
+// JSON:
+//
+//   - GET /status: the HostStatus of each host
+//   - GET /leases: the active Leases
+//   - POST /leases: acquire a Lease, with a body such as
+//     {"host": "nas", "holder": "backup", "ttl": "1h"}
+//   - DELETE /leases/<id>: release a Lease
+func (t *Tracker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, t.Status())
+	})
+
+	mux.HandleFunc("/leases", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, t.Leases())
+		case http.MethodPost:
+			t.acquire(w, r)
+		default:
+			httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+
+	mux.HandleFunc("/leases/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		if !t.Release(strings.TrimPrefix(r.URL.Path, "/leases/")) {
+			httpError(w, http.StatusNotFound, "no such lease")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+// acquire handles a request to acquire a Lease.
+func (t *Tracker) acquire(w http.ResponseWriter, r *http.Request) {
+	var req leaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, http.StatusBadRequest, "invalid lease request: "+err.Error())
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 {
+			httpError(w, http.StatusBadRequest, "invalid lease TTL: "+req.TTL)
+			return
+		}
+		ttl = d
+	}
+
+	l, err := t.Acquire(req.Host, req.Holder, ttl)
+	if err != nil {
+		httpError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, l)
+}
+
+// writeJSON writes v as a JSON response with the specified status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// httpError writes a JSON error response.
+func httpError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
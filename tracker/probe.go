@@ -0,0 +1,186 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mdlayher/arp"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// defaultProbePort is the TCP port probed when a host does not specify a
+// probe.
+const defaultProbePort = "22"
+
+// DefaultProbe returns a wol.Check for h according to its probe option in
+// the inventory, or nil if h has no IP address. If h does not specify a probe,
+// TCPProbe is used with port 22.
+func DefaultProbe(h *inventory.Host) wol.Check {
+	if h.IP == nil {
+		return nil
+	}
+
+	switch {
+	case h.Probe == "icmp":
+		return ICMPProbe(h.IP)
+	case h.Probe == "arp":
+		return func(ctx context.Context) error {
+			if h.Interface == "" {
+				return errors.New("ARP probe requires a network interface")
+			}
+
+			ifi, err := net.InterfaceByName(h.Interface)
+			if err != nil {
+				return err
+			}
+
+			return ARPProbe(ifi, h.IP)(ctx)
+		}
+	case strings.HasPrefix(h.Probe, "tcp:"):
+		return TCPProbe(net.JoinHostPort(h.IP.String(), strings.TrimPrefix(h.Probe, "tcp:")))
+	default:
+		return TCPProbe(net.JoinHostPort(h.IP.String(), defaultProbePort))
+	}
+}
+
+// TCPProbe returns a wol.Check which reports a host alive if it responds to
+// a TCP connection attempt to addr, even by refusing it: either way, the
+// host's network stack is up.
+func TCPProbe(addr string) wol.Check {
+	check := wol.DialCheck("tcp", addr)
+	return func(ctx context.Context) error {
+		if err := check(ctx); err != nil && !errors.Is(err, syscall.ECONNREFUSED) {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// ICMPProbe returns a wol.Check which reports a host alive if it replies to
+// an ICMP echo request sent to ip.
+//
+// Unprivileged ICMP sockets are used where available, such as on Linux when
+// permitted by the net.ipv4.ping_group_range sysctl. Otherwise, raw ICMP
+// sockets are used, which typically require elevated privileges.
+func ICMPProbe(ip net.IP) wol.Check {
+	return func(ctx context.Context) error {
+		var (
+			v4                 = ip.To4() != nil
+			udp, raw           = "udp6", "ip6:ipv6-icmp"
+			proto              = 58
+			req, rep icmp.Type = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		)
+		if v4 {
+			udp, raw = "udp4", "ip4:icmp"
+			proto = 1
+			req, rep = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+		}
+
+		var dst net.Addr = &net.UDPAddr{IP: ip}
+		c, err := icmp.ListenPacket(udp, "")
+		if err != nil {
+			c, err = icmp.ListenPacket(raw, "")
+			if err != nil {
+				return err
+			}
+			dst = &net.IPAddr{IP: ip}
+		}
+		defer c.Close()
+
+		stop := unblockOnDone(ctx, c)
+		defer stop()
+
+		// With unprivileged sockets, the kernel rewrites the echo ID, so
+		// match replies by sequence number and payload.
+		echo := &icmp.Echo{
+			ID:   rand.Intn(0xffff),
+			Seq:  rand.Intn(0xffff),
+			Data: []byte("wol tracker probe"),
+		}
+
+		b, err := (&icmp.Message{Type: req, Body: echo}).Marshal(nil)
+		if err != nil {
+			return err
+		}
+		if _, err := c.WriteTo(b, dst); err != nil {
+			return err
+		}
+
+		buf := make([]byte, 1500)
+		for {
+			n, _, err := c.ReadFrom(buf)
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+
+				return err
+			}
+
+			m, err := icmp.ParseMessage(proto, buf[:n])
+			if err != nil || m.Type != rep {
+				continue
+			}
+
+			if e, ok := m.Body.(*icmp.Echo); ok && e.Seq == echo.Seq && string(e.Data) == string(echo.Data) {
+				return nil
+			}
+		}
+	}
+}
+
+// ARPProbe returns a wol.Check which reports a host alive if it replies to an
+// ARP request for the IPv4 address ip sent on ifi. ARP requests are sent
+// using raw Ethernet sockets, which typically require elevated privileges.
+func ARPProbe(ifi *net.Interface, ip net.IP) wol.Check {
+	return func(ctx context.Context) error {
+		addr, ok := netip.AddrFromSlice(ip.To4())
+		if !ok {
+			return fmt.Errorf("ARP probe requires an IPv4 address, but got %s", ip)
+		}
+
+		c, err := arp.Dial(ifi)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		stop := unblockOnDone(ctx, c)
+		defer stop()
+
+		if _, err := c.Resolve(addr); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+
+			return err
+		}
+
+		return nil
+	}
+}
+
+// A deadliner is a connection which supports deadlines.
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// unblockOnDone unblocks any pending operations on c when ctx is done, by
+// setting a deadline in the past. The returned function stops waiting for ctx.
+func unblockOnDone(ctx context.Context, c deadliner) func() bool {
+	return context.AfterFunc(ctx, func() {
+		_ = c.SetDeadline(time.Unix(1, 0))
+	})
+}
@@ -0,0 +1,465 @@
+// Package tracker implements a long-running tracker of the power state of
+// machines in an inventory, with "keep awake" leases.
+//
+// A Tracker periodically probes each host using TCP, ICMP, or ARP, and
+// reports whether it is up, down, or waking. Consumers which need a host to
+// stay awake, such as a backup job, acquire a Lease on it. While a host has
+// an active Lease, the Tracker wakes it whenever it is found to be down.
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"github.com/mdlayher/wol/metrics"
+)
+
+// Defaults used when Config fields are unset.
+const (
+	defaultInterval     = 10 * time.Second
+	defaultProbeTimeout = 5 * time.Second
+	defaultWakeTimeout  = 2 * time.Minute
+)
+
+// A State is the power state of a host.
+type State int
+
+// Possible State values.
+const (
+	// Unknown indicates that a host has not been probed, or cannot be
+	// probed because it has no IP address.
+	Unknown State = iota
+
+	// Up indicates that a host passed its most recent probe.
+	Up
+
+	// Down indicates that a host failed its most recent probe.
+	Down
+
+	// Waking indicates that a host was sent a magic packet after it was
+	// found to be down, and has not yet passed a probe.
+	Waking
+)
+
+// String returns the name of a State.
+func (s State) String() string {
+	switch s {
+	case Unknown:
+		return "unknown"
+	case Up:
+		return "up"
+	case Down:
+		return "down"
+	case Waking:
+		return "waking"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *State) UnmarshalText(b []byte) error {
+	for _, v := range []State{Unknown, Up, Down, Waking} {
+		if string(b) == v.String() {
+			*s = v
+			return nil
+		}
+	}
+
+	return fmt.Errorf("tracker: unknown state %q", b)
+}
+
+// A WakeFunc wakes a single host.
+type WakeFunc func(h *inventory.Host) error
+
+// A ProbeFunc returns a wol.Check which reports whether a host is alive. It
+// returns nil if the host cannot be probed.
+type ProbeFunc func(h *inventory.Host) wol.Check
+
+// Config contains configuration for a Tracker.
+type Config struct {
+	// Inventory specifies the hosts to track. Inventory must be set.
+	Inventory *inventory.Inventory
+
+	// Wake optionally wakes a host. If nil, inventory.Host.Wake is used.
+	Wake WakeFunc
+
+	// Observer is optionally notified of each magic packet sent when Wake
+	// is nil. A custom Wake function must notify its own Observer.
+	Observer wol.Observer
+
+	// Probe optionally specifies how to check whether a host is alive. If
+	// nil, DefaultProbe is used.
+	Probe ProbeFunc
+
+	// Interval specifies how often hosts are probed. If zero, a default of
+	// 10 seconds is used.
+	Interval time.Duration
+
+	// ProbeTimeout bounds each probe. If zero, a default of 5 seconds is
+	// used.
+	ProbeTimeout time.Duration
+
+	// WakeTimeout specifies how long a host may be waking before it is
+	// considered down again, and woken again if it has an active Lease. If
+	// zero, a default of 2 minutes is used.
+	WakeTimeout time.Duration
+
+	// Metrics optionally records host liveness, and the time from waking a
+	// host until it is up.
+	Metrics *metrics.Metrics
+}
+
+// A HostStatus is the tracked state of a single host.
+type HostStatus struct {
+	Name  string `json:"name"`
+	MAC   string `json:"mac"`
+	State State  `json:"state"`
+
+	// Since is the time at which the host entered State.
+	Since time.Time `json:"since"`
+
+	// LastProbe and LastWake are the times at which the host was most
+	// recently probed and woken by the Tracker.
+	LastProbe time.Time `json:"last_probe"`
+	LastWake  time.Time `json:"last_wake"`
+
+	// Error describes why the most recent probe or wake failed, if it did.
+	Error string `json:"error,omitempty"`
+
+	// Leases is the number of active Leases on the host.
+	Leases int `json:"leases"`
+}
+
+// A Lease is a request to keep a host awake, held by a consumer until it is
+// released or expires.
+type Lease struct {
+	ID     string `json:"id"`
+	Host   string `json:"host"`
+	Holder string `json:"holder,omitempty"`
+
+	// Expires is the time at which the Lease expires, or zero if it is held
+	// until released.
+	Expires time.Time `json:"expires"`
+
+	t   *Tracker
+	seq uint64
+}
+
+// Release releases the Lease. Release is a no-op if the Lease was already
+// released or has expired.
+func (l *Lease) Release() {
+	l.t.Release(l.ID)
+}
+
+// A Tracker tracks the power state of hosts and keeps leased hosts awake.
+type Tracker struct {
+	hosts []*hostState
+	wake  WakeFunc
+	mm    *metrics.Metrics
+
+	interval, probeTimeout, wakeTimeout time.Duration
+
+	mu     sync.Mutex
+	leases map[string]*Lease
+	nextID uint64
+}
+
+// A hostState is the mutable state of a single host. Fields other than h and
+// check are protected by the Tracker's mutex.
+type hostState struct {
+	h     *inventory.Host
+	check wol.Check
+
+	state               State
+	since, probed, woke time.Time
+	err                 error
+}
+
+// New creates a Tracker. Hosts are not probed until Run or Probe is called.
+func New(cfg *Config) (*Tracker, error) {
+	if cfg == nil || cfg.Inventory == nil {
+		return nil, errors.New("tracker: no inventory")
+	}
+
+	probe := cfg.Probe
+	if probe == nil {
+		probe = DefaultProbe
+	}
+
+	t := &Tracker{
+		wake:         cfg.Wake,
+		mm:           cfg.Metrics,
+		interval:     cfg.Interval,
+		probeTimeout: cfg.ProbeTimeout,
+		wakeTimeout:  cfg.WakeTimeout,
+		leases:       make(map[string]*Lease),
+	}
+	if t.wake == nil {
+		obs := cfg.Observer
+		t.wake = func(h *inventory.Host) error {
+			return h.Wake(context.Background(), obs)
+		}
+	}
+	if t.interval == 0 {
+		t.interval = defaultInterval
+	}
+	if t.probeTimeout == 0 {
+		t.probeTimeout = defaultProbeTimeout
+	}
+	if t.wakeTimeout == 0 {
+		t.wakeTimeout = defaultWakeTimeout
+	}
+
+	for _, h := range cfg.Inventory.Hosts {
+		t.hosts = append(t.hosts, &hostState{
+			h:     h,
+			check: probe(h),
+		})
+	}
+
+	return t, nil
+}
+
+// Run probes hosts periodically until ctx is canceled.
+func (t *Tracker) Run(ctx context.Context) error {
+	tick := time.NewTicker(t.interval)
+	defer tick.Stop()
+
+	for {
+		t.Probe(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+// Probe probes each host once, concurrently, and wakes any leased hosts
+// which are down.
+func (t *Tracker) Probe(ctx context.Context) {
+	errs := make([]error, len(t.hosts))
+
+	var wg sync.WaitGroup
+	for i, hs := range t.hosts {
+		if hs.check == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, hs *hostState) {
+			defer wg.Done()
+
+			pctx, cancel := context.WithTimeout(ctx, t.probeTimeout)
+			defer cancel()
+			errs[i] = hs.check(pctx)
+		}(i, hs)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	var wake []*hostState
+
+	t.mu.Lock()
+	t.expire(now)
+	for i, hs := range t.hosts {
+		if hs.check != nil && t.update(hs, now, errs[i]) {
+			wake = append(wake, hs)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, hs := range wake {
+		t.wakeHost(hs)
+	}
+}
+
+// Status returns the tracked state of each host, in inventory order.
+func (t *Tracker) Status() []HostStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(time.Now())
+
+	ss := make([]HostStatus, 0, len(t.hosts))
+	for _, hs := range t.hosts {
+		s := HostStatus{
+			Name:      hs.h.Name,
+			MAC:       hs.h.MAC.String(),
+			State:     hs.state,
+			Since:     hs.since,
+			LastProbe: hs.probed,
+			LastWake:  hs.woke,
+			Leases:    t.leased(hs.h),
+		}
+		if hs.err != nil {
+			s.Error = hs.err.Error()
+		}
+
+		ss = append(ss, s)
+	}
+
+	return ss
+}
+
+// Acquire acquires a Lease which keeps the host target awake, on behalf of
+// holder. target is the name or hardware address of a host in the inventory.
+// If ttl is zero, the Lease is held until it is released. If the host is
+// known to be down, it is woken immediately.
+func (t *Tracker) Acquire(target, holder string, ttl time.Duration) (*Lease, error) {
+	hs, err := t.lookup(target)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.nextID++
+	l := &Lease{
+		ID:     strconv.FormatUint(t.nextID, 10),
+		Host:   hs.h.Name,
+		Holder: holder,
+		t:      t,
+		seq:    t.nextID,
+	}
+	if ttl > 0 {
+		l.Expires = time.Now().Add(ttl)
+	}
+	t.leases[l.ID] = l
+
+	down := hs.state == Down
+	t.mu.Unlock()
+
+	if down {
+		t.wakeHost(hs)
+	}
+
+	return l, nil
+}
+
+// Release releases the Lease with the specified ID, and reports whether it
+// was active.
+func (t *Tracker) Release(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(time.Now())
+
+	_, ok := t.leases[id]
+	delete(t.leases, id)
+	return ok
+}
+
+// Leases returns the active Leases, in the order they were acquired.
+func (t *Tracker) Leases() []*Lease {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(time.Now())
+
+	ls := make([]*Lease, 0, len(t.leases))
+	for _, l := range t.leases {
+		ls = append(ls, l)
+	}
+	sort.Slice(ls, func(i, j int) bool {
+		return ls[i].seq < ls[j].seq
+	})
+
+	return ls
+}
+
+// update records the result of probing a host, and reports whether the
+// host should be woken. t.mu must be held.
+func (t *Tracker) update(hs *hostState, now time.Time, err error) bool {
+	hs.probed, hs.err = now, err
+
+	next := Up
+	if err != nil {
+		next = Down
+		if hs.state == Waking && now.Sub(hs.woke) < t.wakeTimeout {
+			next = Waking
+		}
+	}
+
+	t.mm.SetAlive(hs.h.Name, next == Up)
+	if hs.state == Waking && next == Up {
+		t.mm.WakeLatency(hs.h.Name, now.Sub(hs.woke))
+	}
+
+	if next != hs.state {
+		hs.state, hs.since = next, now
+	}
+
+	return next == Down && t.leased(hs.h) > 0
+}
+
+// wakeHost wakes the host described by hs, and marks it waking on success.
+func (t *Tracker) wakeHost(hs *hostState) {
+	err := t.wake(hs.h)
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		hs.err = fmt.Errorf("failed to wake: %w", err)
+		return
+	}
+
+	hs.woke = now
+	if hs.state != Up {
+		hs.state, hs.since = Waking, now
+	}
+}
+
+// leased returns the number of active Leases on h. t.mu must be held.
+func (t *Tracker) leased(h *inventory.Host) int {
+	var n int
+	for _, l := range t.leases {
+		if l.Host == h.Name {
+			n++
+		}
+	}
+
+	return n
+}
+
+// expire removes expired Leases. t.mu must be held.
+func (t *Tracker) expire(now time.Time) {
+	for id, l := range t.leases {
+		if !l.Expires.IsZero() && !now.Before(l.Expires) {
+			delete(t.leases, id)
+		}
+	}
+}
+
+// lookup finds the host target by name or hardware address.
+func (t *Tracker) lookup(target string) (*hostState, error) {
+	mac, _ := net.ParseMAC(target)
+	for _, hs := range t.hosts {
+		if (mac != nil && hs.h.MAC.String() == mac.String()) || (mac == nil && strings.EqualFold(hs.h.Name, target)) {
+			return hs, nil
+		}
+	}
+
+	return nil, fmt.Errorf("tracker: unknown host %q", target)
+}
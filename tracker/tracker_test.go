@@ -0,0 +1,264 @@
+package tracker_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"github.com/mdlayher/wol/tracker"
+)
+
+func TestTrackerLeases(t *testing.T) {
+	tt := newTestTracker(t, time.Hour)
+	ctx := context.Background()
+
+	// The host is down, but nothing needs it awake.
+	tt.Probe(ctx)
+	tt.check(t, tracker.Down, 0, 0)
+
+	// Acquiring a lease on a down host wakes it immediately.
+	l, err := tt.Acquire("desktop", "backup", 0)
+	if err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+	tt.check(t, tracker.Waking, 1, 1)
+
+	// The host has not yet had time to wake, so it is not woken again.
+	tt.Probe(ctx)
+	tt.check(t, tracker.Waking, 1, 1)
+
+	tt.alive.Store(true)
+	tt.Probe(ctx)
+	tt.check(t, tracker.Up, 1, 1)
+
+	// Once the lease is released, the host may sleep.
+	l.Release()
+	tt.alive.Store(false)
+	tt.Probe(ctx)
+	tt.check(t, tracker.Down, 1, 0)
+}
+
+func TestTrackerRewake(t *testing.T) {
+	// With no wake timeout, a leased host which is still down is woken on
+	// every probe.
+	tt := newTestTracker(t, time.Nanosecond)
+	ctx := context.Background()
+
+	tt.Probe(ctx)
+	if _, err := tt.Acquire("00:12:7f:eb:6b:40", "", 0); err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+
+	tt.Probe(ctx)
+	tt.Probe(ctx)
+	tt.check(t, tracker.Waking, 3, 1)
+}
+
+func TestTrackerAcquireErrors(t *testing.T) {
+	tt := newTestTracker(t, time.Hour)
+
+	if _, err := tt.Acquire("laptop", "", 0); err == nil {
+		t.Fatal("expected an error for an unknown host, but none occurred")
+	}
+
+	if _, err := tt.Acquire("desktop", "", time.Nanosecond); err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if ls := tt.Leases(); len(ls) != 0 {
+		t.Fatalf("expected expired lease to be removed, but got %d leases", len(ls))
+	}
+}
+
+func TestTrackerHandler(t *testing.T) {
+	tt := newTestTracker(t, time.Hour)
+	tt.Probe(context.Background())
+
+	srv := httptest.NewServer(tt.Handler())
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/leases", "application/json",
+		strings.NewReader(`{"host": "desktop", "holder": "backup", "ttl": "1h"}`))
+	if err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected status code: %d", res.StatusCode)
+	}
+
+	var l tracker.Lease
+	if err := json.NewDecoder(res.Body).Decode(&l); err != nil {
+		t.Fatalf("failed to decode lease: %v", err)
+	}
+	if l.ID == "" || l.Host != "desktop" || l.Holder != "backup" || l.Expires.IsZero() {
+		t.Fatalf("unexpected lease: %+v", l)
+	}
+
+	var ss []tracker.HostStatus
+	getJSON(t, srv.URL+"/status", &ss)
+
+	got := make(map[string]tracker.State)
+	for _, s := range ss {
+		got[s.Name] = s.State
+	}
+
+	want := map[string]tracker.State{
+		"desktop": tracker.Waking,
+		"nas":     tracker.Unknown,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected states (-want +got):\n%s", diff)
+	}
+
+	for _, code := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req, err := http.NewRequest(http.MethodDelete, srv.URL+"/leases/"+l.ID, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to release lease: %v", err)
+		}
+		res.Body.Close()
+
+		if diff := cmp.Diff(code, res.StatusCode); diff != "" {
+			t.Fatalf("unexpected status code (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestICMPProbe(t *testing.T) {
+	check := tracker.ICMPProbe(net.IPv4(127, 0, 0, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := check(ctx); err != nil {
+		var serr *net.OpError
+		if errors.As(err, &serr) && serr.Op == "listen" {
+			t.Skipf("skipping, cannot open ICMP socket: %v", err)
+		}
+
+		t.Fatalf("failed to probe loopback: %v", err)
+	}
+}
+
+func TestTCPProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := l.Addr().String()
+
+	ctx := context.Background()
+	if err := tracker.TCPProbe(addr)(ctx); err != nil {
+		t.Fatalf("failed to probe listener: %v", err)
+	}
+
+	// A refused connection still means the host is alive.
+	_ = l.Close()
+	if err := tracker.TCPProbe(addr)(ctx); err != nil {
+		t.Fatalf("failed to probe closed port: %v", err)
+	}
+}
+
+// A testTracker is a tracker.Tracker for a host whose liveness is controlled
+// by a test.
+type testTracker struct {
+	*tracker.Tracker
+	alive atomic.Bool
+
+	mu    sync.Mutex
+	wakes int
+}
+
+func newTestTracker(t *testing.T, wakeTimeout time.Duration) *testTracker {
+	t.Helper()
+
+	inv, err := inventory.Parse(strings.NewReader(strings.Join([]string{
+		"00:12:7f:eb:6b:40 desktop ip=192.0.2.10",
+		"00:12:7f:eb:6b:41 nas",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("failed to parse inventory: %v", err)
+	}
+
+	tt := &testTracker{}
+	tt.Tracker, err = tracker.New(&tracker.Config{
+		Inventory:   inv,
+		WakeTimeout: wakeTimeout,
+		Wake: func(_ *inventory.Host) error {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.wakes++
+			return nil
+		},
+		Probe: func(h *inventory.Host) wol.Check {
+			if h.IP == nil {
+				return nil
+			}
+
+			return func(_ context.Context) error {
+				if !tt.alive.Load() {
+					return errors.New("offline")
+				}
+
+				return nil
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+
+	return tt
+}
+
+// check verifies the state, number of wakes, and number of leases of the
+// test host.
+func (tt *testTracker) check(t *testing.T, state tracker.State, wakes, leases int) {
+	t.Helper()
+
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	s := tt.Status()[0]
+	got := []int{int(s.State), tt.wakes, s.Leases}
+	want := []int{int(state), wakes, leases}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected state, wakes, and leases (-want +got):\n%s", diff)
+	}
+}
+
+func getJSON(t *testing.T, url string, v interface{}) {
+	t.Helper()
+
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", url, err)
+	}
+	defer res.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(res.Body); err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), v); err != nil {
+		t.Fatalf("failed to decode %s: %v", buf.String(), err)
+	}
+}
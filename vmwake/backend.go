@@ -0,0 +1,148 @@
+package vmwake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// DefaultDockerSocket is the conventional path of the Docker Engine API's
+// Unix socket.
+const DefaultDockerSocket = "/var/run/docker.sock"
+
+// A Backend starts virtual workloads. Implementations must be safe for
+// concurrent use.
+type Backend interface {
+	// Start starts the named workload. Start should return nil if the
+	// workload is already running.
+	Start(ctx context.Context, name string) error
+}
+
+// BackendFunc adapts an ordinary function to a Backend.
+type BackendFunc func(ctx context.Context, name string) error
+
+// Start implements Backend.
+func (fn BackendFunc) Start(ctx context.Context, name string) error {
+	return fn(ctx, name)
+}
+
+// Command returns a Backend which runs the named program with the specified
+// arguments followed by the workload's name, such as:
+//
+//	vmwake.Command("VBoxManage", "startvm", "--type", "headless")
+func Command(name string, arg ...string) Backend {
+	return BackendFunc(func(ctx context.Context, workload string) error {
+		// Don't append to arg's backing array, which is shared by
+		// concurrent calls.
+		_, err := run(ctx, name, append(arg[:len(arg):len(arg)], workload)...)
+		return err
+	})
+}
+
+// Libvirt returns a Backend which starts libvirt domains using virsh. uri
+// optionally specifies the libvirt connection URI, such as "qemu:///system".
+func Libvirt(uri string) Backend {
+	return BackendFunc(func(ctx context.Context, domain string) error {
+		var args []string
+		if uri != "" {
+			args = append(args, "-c", uri)
+		}
+
+		out, err := run(ctx, "virsh", append(args, "start", domain)...)
+		if err != nil && strings.Contains(out, "already active") {
+			return nil
+		}
+
+		return err
+	})
+}
+
+// Docker returns a Backend which starts containers using the Docker Engine
+// API served on the Unix socket at path, or DefaultDockerSocket if path is
+// empty. Podman's compatible API may also be used.
+func Docker(path string) Backend {
+	if path == "" {
+		path = DefaultDockerSocket
+	}
+
+	return &docker{
+		c: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+		// The host is ignored when dialing the Unix socket.
+		base: "http://docker",
+	}
+}
+
+var _ Backend = &docker{}
+
+// A docker is a Backend which uses the Docker Engine API.
+type docker struct {
+	c    *http.Client
+	base string
+}
+
+// Start implements Backend.
+func (d *docker) Start(ctx context.Context, name string) error {
+	u := d.base + "/containers/" + url.PathEscape(name) + "/start"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := d.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		// Started, or already running.
+		return nil
+	}
+
+	// Errors carry a JSON message, but fall back to the status if it is
+	// missing or malformed.
+	var body struct {
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(io.LimitReader(res.Body, 64*1024)).Decode(&body)
+	if body.Message == "" {
+		body.Message = res.Status
+	}
+
+	return fmt.Errorf("vmwake: failed to start container %q: %s", name, body.Message)
+}
+
+// run runs the named program and returns its combined output. If the program
+// fails, its output is included in the error.
+func run(ctx context.Context, name string, arg ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, arg...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		s := strings.TrimSpace(out.String())
+		if s == "" {
+			return "", fmt.Errorf("vmwake: %s: %w", name, err)
+		}
+
+		return s, fmt.Errorf("vmwake: %s: %w: %s", name, err, s)
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,167 @@
+package vmwake_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/wol/vmwake"
+)
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("skipping, sh not found")
+	}
+
+	// The workload name is passed to the script as $0.
+	out := filepath.Join(t.TempDir(), "out")
+	b := vmwake.Command("sh", "-c", `echo "$0" > `+out)
+
+	if err := b.Start(context.Background(), "vm"); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	if diff := cmp.Diff("vm\n", string(got)); diff != "" {
+		t.Fatalf("unexpected workload name (-want +got):\n%s", diff)
+	}
+}
+
+func TestLibvirt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping, fake virsh requires a Unix shell")
+	}
+
+	// Replace virsh with a script which records its arguments and emulates
+	// its output.
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	script := `#!/bin/sh
+echo "$@" >> ` + args + `
+case "$4" in
+running) echo "error: Requested operation is not valid: domain is already active" >&2; exit 1 ;;
+missing) echo "error: failed to get domain 'missing'" >&2; exit 1 ;;
+esac
+echo "Domain '$4' started"
+`
+	if err := os.WriteFile(filepath.Join(dir, "virsh"), []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake virsh: %v", err)
+	}
+	t.Setenv("PATH", dir)
+
+	b := vmwake.Libvirt("qemu:///system")
+
+	var tests = []struct {
+		name string
+		ok   bool
+	}{
+		{name: "stopped", ok: true},
+		{name: "running", ok: true},
+		{name: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.Start(context.Background(), tt.name)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to start: %v", err)
+			}
+			if !tt.ok {
+				if err == nil || !strings.Contains(err.Error(), "failed to get domain") {
+					t.Fatalf("expected an error with virsh output, but got: %v", err)
+				}
+			}
+		})
+	}
+
+	got, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatalf("failed to read arguments: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"-c qemu:///system start stopped",
+		"-c qemu:///system start running",
+		"-c qemu:///system start missing",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Fatalf("unexpected virsh arguments (-want +got):\n%s", diff)
+	}
+}
+
+func TestDocker(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping, Unix sockets are not supported")
+	}
+
+	// Serve a fake Docker Engine API on a Unix socket.
+	sock := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/containers/stopped/start":
+			w.WriteHeader(http.StatusNoContent)
+		case "/containers/running/start":
+			w.WriteHeader(http.StatusNotModified)
+		case "/containers/broken/start":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such container: missing"}`))
+		}
+	}))
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	b := vmwake.Docker(sock)
+
+	var tests = []struct {
+		name string
+		err  string
+	}{
+		{name: "stopped"},
+		{name: "running"},
+		{name: "missing", err: "No such container: missing"},
+		{name: "broken", err: "500 Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.Start(context.Background(), tt.name)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("failed to start: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, but got: %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+// Package vmwake implements a bridge which starts virtual machines and
+// containers when Wake-on-LAN magic packets are sent to them.
+//
+// Virtual workloads have no network interface which can listen for magic
+// packets while they are stopped. A Bridge listens for magic packets on their
+// behalf, maps each packet's target hardware address to a Workload, and
+// starts the Workload using a Backend such as a command, libvirt, or a
+// container runtime, so existing Wake-on-LAN tooling can power them on.
+package vmwake
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/wol"
+)
+
+// Defaults used when a Bridge's fields are unset.
+const (
+	defaultCooldown = 30 * time.Second
+	defaultTimeout  = 2 * time.Minute
+)
+
+// A Workload is a virtual machine or container which is started when a magic
+// packet targets its hardware address.
+type Workload struct {
+	// Name identifies the workload to its Backend, such as a libvirt domain
+	// or container name.
+	Name string
+
+	// MAC is the hardware address which magic packets must target to start
+	// the workload. It is typically the address of the workload's virtual
+	// network interface.
+	MAC net.HardwareAddr
+
+	// Password optionally specifies a SecureOn password which must be
+	// present in a magic packet for it to start the workload.
+	Password []byte
+
+	// Backend optionally overrides the Bridge's Backend for this workload.
+	Backend Backend
+}
+
+// A Bridge starts Workloads when magic packets targeting them are received.
+type Bridge struct {
+	// Workloads specifies the workloads which may be started.
+	Workloads []*Workload
+
+	// Backend starts workloads which do not specify their own Backend.
+	Backend Backend
+
+	// Cooldown specifies the minimum time between starts of a single
+	// workload, since senders commonly transmit several copies of a magic
+	// packet. If zero, a default of 30 seconds is used.
+	Cooldown time.Duration
+
+	// Timeout bounds each call to a Backend. If zero, a default of 2
+	// minutes is used.
+	Timeout time.Duration
+
+	// Observer is optionally notified of each magic packet which starts a
+	// Workload, such as for an audit log. Its events name the Workload as
+	// the Destination and the packet's sender as the Identity, and report
+	// any error returned by the Backend.
+	Observer wol.Observer
+
+	// Logger optionally logs workloads which are started or fail to start.
+	Logger *slog.Logger
+
+	mu      sync.Mutex
+	started map[*Workload]time.Time
+}
+
+// Serve receives magic packets from each Listener in ls and starts the
+// Workloads they target until ctx is canceled or a Listener is closed. Serve
+// closes each Listener and waits for pending starts before it returns.
+//
+// Errors returned by Backends are logged rather than returned, so a single
+// misconfigured workload does not stop the Bridge.
+func (b *Bridge) Serve(ctx context.Context, ls ...*wol.Listener) error {
+	if len(ls) == 0 {
+		return errors.New("vmwake: bridge has no listeners")
+	}
+
+	for _, w := range b.Workloads {
+		if w.Backend == nil && b.Backend == nil {
+			return fmt.Errorf("vmwake: workload %q has no backend", w.Name)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var starts sync.WaitGroup
+	defer starts.Wait()
+
+	errC := make(chan error, len(ls))
+	for _, l := range ls {
+		go func(l *wol.Listener) {
+			errC <- b.receive(ctx, l, &starts)
+		}(l)
+	}
+
+	// Stop on cancelation or the first Listener failure, then wait for
+	// the remaining Listeners to stop.
+	var (
+		err     error
+		pending = len(ls)
+	)
+	select {
+	case <-ctx.Done():
+	case err = <-errC:
+		pending--
+	}
+
+	cancel()
+	for _, l := range ls {
+		_ = l.Close()
+	}
+
+	for ; pending > 0; pending-- {
+		if lerr := <-errC; err == nil {
+			err = lerr
+		}
+	}
+
+	return err
+}
+
+// receive starts Workloads targeted by magic packets received on l until l
+// is closed.
+func (b *Bridge) receive(ctx context.Context, l *wol.Listener, starts *sync.WaitGroup) error {
+	for {
+		p, from, err := l.Receive()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		w := b.lookup(p)
+		if w == nil || !b.claim(w) {
+			continue
+		}
+
+		// Workloads may take some time to start, so don't block the
+		// Listener while doing so.
+		starts.Add(1)
+		go func() {
+			defer starts.Done()
+			err := b.start(ctx, w)
+			b.observe(l, from, w, err)
+		}()
+	}
+}
+
+// lookup returns the Workload targeted by p, or nil if none is or p does not
+// carry the Workload's password.
+func (b *Bridge) lookup(p *wol.MagicPacket) *Workload {
+	for _, w := range b.Workloads {
+		if !bytes.Equal(p.Target, w.MAC) {
+			continue
+		}
+
+		if len(w.Password) > 0 && subtle.ConstantTimeCompare(p.Password, w.Password) != 1 {
+			return nil
+		}
+
+		return w
+	}
+
+	return nil
+}
+
+// claim reports whether w may be started now, and if so, records the start
+// so further packets within the cooldown are ignored.
+func (b *Bridge) claim(w *Workload) bool {
+	cooldown := b.Cooldown
+	if cooldown == 0 {
+		cooldown = defaultCooldown
+	}
+
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t, ok := b.started[w]; ok && now.Sub(t) < cooldown {
+		return false
+	}
+	if b.started == nil {
+		b.started = make(map[*Workload]time.Time)
+	}
+	b.started[w] = now
+
+	return true
+}
+
+// start starts w using its Backend.
+func (b *Bridge) start(ctx context.Context, w *Workload) error {
+	timeout := b.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backend := w.Backend
+	if backend == nil {
+		backend = b.Backend
+	}
+
+	if err := backend.Start(ctx, w.Name); err != nil {
+		b.log(slog.LevelWarn, "failed to start workload", "workload", w.Name, "mac", w.MAC.String(), "err", err)
+
+		// Allow the next magic packet to try again.
+		b.mu.Lock()
+		delete(b.started, w)
+		b.mu.Unlock()
+		return err
+	}
+
+	b.log(slog.LevelInfo, "started workload", "workload", w.Name, "mac", w.MAC.String())
+	return nil
+}
+
+// observe notifies b's Observer, if any, that a magic packet from the sender
+// at from, received by l, started w.
+func (b *Bridge) observe(l *wol.Listener, from net.Addr, w *Workload, err error) {
+	if b.Observer == nil {
+		return
+	}
+
+	transport := wol.TransportRaw
+	if _, ok := from.(*net.UDPAddr); ok {
+		transport = wol.TransportUDP
+	}
+
+	b.Observer.ObserveSend(&wol.SendEvent{
+		Time:        time.Now(),
+		Transport:   transport,
+		Source:      l.Addr().String(),
+		Destination: w.Name,
+		Target:      w.MAC,
+		Password:    len(w.Password) > 0,
+		Identity:    from.String(),
+		Err:         err,
+	})
+}
+
+// log logs a message if b has a Logger.
+func (b *Bridge) log(level slog.Level, msg string, args ...any) {
+	if b.Logger == nil {
+		return
+	}
+
+	b.Logger.Log(context.Background(), level, msg, args...)
+}
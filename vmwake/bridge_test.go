@@ -0,0 +1,243 @@
+package vmwake_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/vmwake"
+	"github.com/mdlayher/wol/woltest"
+)
+
+var (
+	vmMAC        = net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x34, 0x56}
+	containerMAC = net.HardwareAddr{0x02, 0x42, 0xac, 0x11, 0x00, 0x02}
+	otherMAC     = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+)
+
+func TestBridgeServe(t *testing.T) {
+	var tests = []struct {
+		name string
+		send func(c *wol.Client, addr string) error
+		want []string
+	}{
+		{
+			name: "other target",
+			send: func(c *wol.Client, addr string) error {
+				return c.Wake(addr, otherMAC)
+			},
+		},
+		{
+			name: "sleep packet",
+			send: func(c *wol.Client, addr string) error {
+				return c.Sleep(addr, vmMAC)
+			},
+		},
+		{
+			name: "bad password",
+			send: func(c *wol.Client, addr string) error {
+				return c.WakePassword(addr, containerMAC, []byte{4, 3, 2, 1})
+			},
+		},
+		{
+			name: "OK, repeated",
+			send: func(c *wol.Client, addr string) error {
+				for i := 0; i < 3; i++ {
+					if err := c.Wake(addr, vmMAC); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+			want: []string{"vm"},
+		},
+		{
+			name: "OK, password",
+			send: func(c *wol.Client, addr string) error {
+				return c.WakePassword(addr, containerMAC, []byte{1, 2, 3, 4})
+			},
+			want: []string{"container"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fb := newFakeBackend(nil)
+			b := &vmwake.Bridge{
+				Workloads: []*vmwake.Workload{
+					{Name: "vm", MAC: vmMAC},
+					{Name: "container", MAC: containerMAC, Password: []byte{1, 2, 3, 4}},
+				},
+				Backend: fb,
+			}
+
+			c, addr := testBridge(t, b)
+			if err := tt.send(c, addr); err != nil {
+				t.Fatalf("failed to send: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, fb.wait(len(tt.want))); diff != "" {
+				t.Fatalf("unexpected started workloads (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBridgeServeRetry(t *testing.T) {
+	// The first start fails, so the next packet tries again despite the
+	// cooldown.
+	fb := newFakeBackend(errors.New("domain not found"))
+	b := &vmwake.Bridge{
+		Workloads: []*vmwake.Workload{{Name: "vm", MAC: vmMAC}},
+		Backend:   fb,
+	}
+
+	c, addr := testBridge(t, b)
+	for i := 0; i < 2; i++ {
+		if err := c.Wake(addr, vmMAC); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+
+		if diff := cmp.Diff([]string{"vm"}, fb.wait(1)); diff != "" {
+			t.Fatalf("unexpected started workloads (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestBridgeObserver(t *testing.T) {
+	events := make(chan *wol.SendEvent, 1)
+	b := &vmwake.Bridge{
+		Workloads: []*vmwake.Workload{{Name: "vm", MAC: vmMAC}},
+		Backend:   newFakeBackend(errors.New("domain not found")),
+		Observer:  observerFunc(func(e *wol.SendEvent) { events <- e }),
+	}
+
+	c, addr := testBridge(t, b)
+	if err := c.Wake(addr, vmMAC); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	var e *wol.SendEvent
+	select {
+	case e = <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for send event")
+	}
+
+	want := &wol.SendEvent{
+		Transport:   wol.TransportUDP,
+		Source:      addr,
+		Destination: "vm",
+		Target:      vmMAC,
+	}
+	opts := cmpopts.IgnoreFields(wol.SendEvent{}, "Time", "Identity", "Err")
+	if diff := cmp.Diff(want, e, opts); diff != "" {
+		t.Fatalf("unexpected send event (-want +got):\n%s", diff)
+	}
+	if e.Identity == "" || e.Err == nil {
+		t.Fatalf("expected sender identity and backend error, but got: %q, %v", e.Identity, e.Err)
+	}
+}
+
+func TestBridgeServeNoBackend(t *testing.T) {
+	n := woltest.NewNetwork()
+	pc, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	l := wol.NewListenerConn(pc)
+	defer l.Close()
+
+	b := &vmwake.Bridge{
+		Workloads: []*vmwake.Workload{{Name: "vm", MAC: vmMAC}},
+	}
+	if err := b.Serve(context.Background(), l); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+// testBridge starts b on a woltest.Network, and returns a Client and the
+// address b listens on. b is stopped when the test completes.
+func testBridge(t *testing.T, b *vmwake.Bridge) (*wol.Client, string) {
+	t.Helper()
+
+	n := woltest.NewNetwork()
+	lpc, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	l := wol.NewListenerConn(lpc)
+
+	cpc, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	c := wol.NewClientConn(cpc)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Serve(ctx, l) }()
+
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("failed to serve: %v", err)
+		}
+	})
+
+	return c, l.Addr().String()
+}
+
+// A fakeBackend is a vmwake.Backend which records started workloads.
+type fakeBackend struct {
+	err     error
+	started chan string
+}
+
+func newFakeBackend(err error) *fakeBackend {
+	return &fakeBackend{
+		err:     err,
+		started: make(chan string, 16),
+	}
+}
+
+func (fb *fakeBackend) Start(_ context.Context, name string) error {
+	fb.started <- name
+	return fb.err
+}
+
+// wait waits for n workloads to be started, then briefly waits for any
+// further starts, and returns the names of all started workloads.
+func (fb *fakeBackend) wait(n int) []string {
+	var names []string
+	timeout := time.After(5 * time.Second)
+	for len(names) < n {
+		select {
+		case name := <-fb.started:
+			names = append(names, name)
+		case <-timeout:
+			return names
+		}
+	}
+
+	for {
+		select {
+		case name := <-fb.started:
+			names = append(names, name)
+		case <-time.After(100 * time.Millisecond):
+			return names
+		}
+	}
+}
+
+// An observerFunc adapts a function to wol.Observer.
+type observerFunc func(e *wol.SendEvent)
+
+func (fn observerFunc) ObserveSend(e *wol.SendEvent) { fn(e) }
@@ -0,0 +1,41 @@
+package wol
+
+import (
+	"context"
+	"net"
+)
+
+// A Waker is a type which can wake a machine identified by its hardware
+// address.
+//
+// ctx bounds the attempt to wake the machine, such as the requests made to a
+// baseboard management controller. Wakers which send a single magic packet
+// only check that ctx is not done before sending it.
+//
+// RawClient implements Waker directly. A Client must be bound to a network
+// address using its Waker method.
+type Waker interface {
+	WakeContext(ctx context.Context, target net.HardwareAddr) error
+}
+
+var _ Waker = &RawClient{}
+
+// Waker returns a Waker which sends Wake-on-LAN magic packets to addr using
+// c. The returned Waker shares c's socket, so it must not be used after c is
+// closed.
+func (c *Client) Waker(addr string) Waker {
+	return &clientWaker{
+		c:    c,
+		addr: addr,
+	}
+}
+
+// A clientWaker binds a Client to a fixed network address.
+type clientWaker struct {
+	c    *Client
+	addr string
+}
+
+func (w *clientWaker) WakeContext(ctx context.Context, target net.HardwareAddr) error {
+	return w.c.WakeContext(ctx, w.addr, target)
+}
@@ -85,6 +85,17 @@ func (p *MagicPacket) MarshalBinary() ([]byte, error) {
 	return b, nil
 }
 
+// SleepTarget returns the hardware address placed in a Sleep-on-LAN packet
+// for target: the target's hardware address with its bytes reversed.
+func SleepTarget(target net.HardwareAddr) net.HardwareAddr {
+	r := make(net.HardwareAddr, len(target))
+	for i := range target {
+		r[len(r)-1-i] = target[i]
+	}
+
+	return r
+}
+
 // UnmarshalBinary unmarshals a byte slice into a MagicPacket.
 //
 // If the byte slice does not contain enough data to unmarshal a valid
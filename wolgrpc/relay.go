@@ -0,0 +1,100 @@
+package wolgrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/mdlayher/wol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// relayTimeout bounds each request made by a relay Destination.
+const relayTimeout = 10 * time.Second
+
+func init() {
+	wol.RegisterScheme("relay", openRelay)
+}
+
+// openRelay opens a wol.Destination for the relay scheme, which asks a Server
+// to wake machines on its network:
+//
+//	relay://host:port[?transport=udp|raw][&address=addr][&interface=iface]
+//
+// The optional parameters are passed to the Server in each WakeRequest. The
+// connection is not encrypted, so relays should only be used on trusted
+// networks.
+func openRelay(u *url.URL) (wol.Destination, error) {
+	if u.Port() == "" {
+		return nil, fmt.Errorf("wolgrpc: relay destination %q must specify a port", u.Host)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("wolgrpc: relay destination %q must not specify a path", u.Host)
+	}
+
+	req := &WakeRequest{}
+	for k, vs := range u.Query() {
+		v := vs[len(vs)-1]
+
+		switch k {
+		case "transport":
+			switch v {
+			case "udp":
+				req.Transport = Transport_TRANSPORT_UDP
+			case "raw":
+				req.Transport = Transport_TRANSPORT_RAW
+			default:
+				return nil, fmt.Errorf("wolgrpc: unknown relay transport %q", v)
+			}
+		case "address":
+			req.Address = v
+		case "interface":
+			req.Interface = v
+		default:
+			return nil, fmt.Errorf("wolgrpc: unknown parameter %q for scheme %q", k, u.Scheme)
+		}
+	}
+
+	conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &relay{
+		conn: conn,
+		c:    NewWakeServiceClient(conn),
+		req:  req,
+	}, nil
+}
+
+var _ wol.Destination = &relay{}
+
+// A relay is a wol.Destination which wakes machines using a Server.
+type relay struct {
+	conn *grpc.ClientConn
+	c    WakeServiceClient
+	req  *WakeRequest
+}
+
+func (r *relay) WakeContext(ctx context.Context, target net.HardwareAddr) error {
+	return r.WakePasswordContext(ctx, target, nil)
+}
+
+func (r *relay) WakePasswordContext(ctx context.Context, target net.HardwareAddr, password []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, relayTimeout)
+	defer cancel()
+
+	_, err := r.c.Wake(ctx, &WakeRequest{
+		Target:    target.String(),
+		Password:  password,
+		Transport: r.req.Transport,
+		Address:   r.req.Address,
+		Interface: r.req.Interface,
+	})
+	return err
+}
+
+func (r *relay) Close() error { return r.conn.Close() }
@@ -0,0 +1,69 @@
+package wolgrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/wolgrpc"
+	"github.com/mdlayher/wol/woltest"
+	"google.golang.org/grpc"
+)
+
+func TestRelayDestination(t *testing.T) {
+	n := woltest.NewNetwork()
+	host := n.AddHost(desktopMAC, []byte("abcd"))
+
+	pc, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	gs := grpc.NewServer()
+	wolgrpc.RegisterWakeServiceServer(gs, wolgrpc.NewServer(&wolgrpc.Config{
+		Client: wol.NewClientConn(pc),
+		Addr:   (&net.UDPAddr{IP: n.Broadcast(), Port: 9}).String(),
+	}))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go func() { _ = gs.Serve(l) }()
+	defer gs.Stop()
+
+	d, err := wol.OpenDestination("relay://" + l.Addr().String() + "?transport=udp")
+	if err != nil {
+		t.Fatalf("failed to open destination: %v", err)
+	}
+	defer d.Close()
+
+	if err := d.WakePasswordContext(context.Background(), desktopMAC, []byte("abcd")); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := host.Wait(ctx); err != nil {
+		t.Fatalf("host did not wake: %v", err)
+	}
+}
+
+func TestRelayDestinationErrors(t *testing.T) {
+	for _, dest := range []string{
+		"relay://agent",
+		"relay://agent:4000?transport=carrier-pigeon",
+		"relay://agent:4000?vlan=20",
+	} {
+		t.Run(dest, func(t *testing.T) {
+			if d, err := wol.OpenDestination(dest); err == nil {
+				_ = d.Close()
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+}
@@ -0,0 +1,549 @@
+// Package wolgrpc implements a gRPC service which wakes machines using
+// Wake-on-LAN, and provides a generated client for the service.
+//
+// The service is defined in wol.proto. A Server delegates to a wol.Client
+// and wol.RawClients to send magic packets, and can stream magic packets
+// observed by wol.Listeners to clients. To regenerate the Go code after
+// changing wol.proto, run "go generate".
+package wolgrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative wol.proto
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/packet"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"github.com/mdlayher/wol/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// watchBuffer is the number of events buffered for each watcher before
+	// further events are dropped.
+	watchBuffer = 64
+)
+
+// Config contains configuration for a Server.
+type Config struct {
+	// Inventory optionally specifies hosts which can be woken by name.
+	// Hosts with a destination URL are woken using wol.OpenDestination
+	// rather than Client or RawClients, unless a request specifies a
+	// transport.
+	Inventory *inventory.Inventory
+
+	// Client sends magic packets over UDP. If nil, UDP is unavailable.
+	Client *wol.Client
+
+	// Addr is the default UDP address for magic packets. If empty, the
+	// limited broadcast address "255.255.255.255:9" is used.
+	Addr string
+
+	// RawClients optionally maps network interface names to RawClients
+	// which send magic packets over raw Ethernet sockets on those
+	// interfaces.
+	RawClients map[string]*wol.RawClient
+
+	// Observer is optionally notified of each attempt to send a magic
+	// packet, with the identity of the requesting client, such as for an
+	// audit log. To avoid duplicate events, Observer should not also be set
+	// on Client or RawClients.
+	Observer wol.Observer
+
+	// Identify optionally returns the identity of the client making a
+	// request, for events sent to Observer. If nil, the client's network
+	// address is used.
+	Identify func(ctx context.Context) string
+
+	// Metrics optionally counts magic packets received by Serve, and wake
+	// requests which are relayed or rejected. To also count send errors,
+	// set Metrics as the Observer of Client and RawClients.
+	Metrics *metrics.Metrics
+}
+
+var _ WakeServiceServer = &Server{}
+
+// A Server implements WakeServiceServer. Use RegisterWakeServiceServer to
+// register a Server with a *grpc.Server.
+type Server struct {
+	UnimplementedWakeServiceServer
+
+	inv  *inventory.Inventory
+	c    *wol.Client
+	addr string
+	raw  map[string]*wol.RawClient
+	obs  wol.Observer
+	id   func(ctx context.Context) string
+	mm   *metrics.Metrics
+
+	mu       sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+// NewServer creates a Server. If cfg is nil, a default configuration is
+// used, which cannot send magic packets.
+func NewServer(cfg *Config) *Server {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	s := &Server{
+		inv:      cfg.Inventory,
+		c:        cfg.Client,
+		addr:     cfg.Addr,
+		raw:      cfg.RawClients,
+		obs:      cfg.Observer,
+		id:       cfg.Identify,
+		mm:       cfg.Metrics,
+		watchers: make(map[*watcher]struct{}),
+	}
+	if s.inv == nil {
+		s.inv = &inventory.Inventory{}
+	}
+	if s.addr == "" {
+		s.addr = inventory.DefaultAddr
+	}
+	if s.id == nil {
+		s.id = peerAddr
+	}
+
+	return s
+}
+
+// Serve receives magic packets from l and streams them to clients watching
+// wake events, until l is closed.
+func (s *Server) Serve(l *wol.Listener) error {
+	transport, mt := Transport_TRANSPORT_UDP, wol.TransportUDP
+	if _, ok := l.Addr().(*packet.Addr); ok {
+		transport, mt = Transport_TRANSPORT_RAW, wol.TransportRaw
+	}
+
+	for {
+		p, addr, err := l.Receive()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		s.mm.Received(mt, "")
+		s.publish(&WakeEvent{
+			Time:      timestamppb.Now(),
+			Kind:      WakeEventKind_WAKE_EVENT_KIND_RECEIVED,
+			Transport: transport,
+			Mac:       p.Target.String(),
+			Name:      s.name(p.Target),
+			Address:   addr.String(),
+			Password:  len(p.Password) > 0,
+		})
+	}
+}
+
+// Wake implements WakeServiceServer.
+func (s *Server) Wake(ctx context.Context, req *WakeRequest) (*WakeResponse, error) {
+	return s.wake(ctx, req)
+}
+
+// WakeBatch implements WakeServiceServer.
+func (s *Server) WakeBatch(ctx context.Context, req *WakeBatchRequest) (*WakeBatchResponse, error) {
+	res := &WakeBatchResponse{
+		Results: make([]*WakeResult, 0, len(req.GetRequests())),
+	}
+
+	for _, r := range req.GetRequests() {
+		wr, err := s.wake(ctx, r)
+
+		result := &WakeResult{
+			Target:   r.GetTarget(),
+			Response: wr,
+		}
+		if err != nil {
+			result.Error = status.Convert(err).Message()
+		}
+
+		res.Results = append(res.Results, result)
+	}
+
+	return res, nil
+}
+
+// ListHosts implements WakeServiceServer.
+func (s *Server) ListHosts(_ context.Context, _ *ListHostsRequest) (*ListHostsResponse, error) {
+	res := &ListHostsResponse{
+		Hosts: make([]*Host, 0, len(s.inv.Hosts)),
+	}
+
+	for _, h := range s.inv.Hosts {
+		host := &Host{
+			Name:        h.Name,
+			Mac:         h.MAC.String(),
+			Address:     h.Addr,
+			Interface:   h.Interface,
+			HasPassword: len(h.Password) > 0,
+		}
+		if h.IP != nil {
+			host.Ip = h.IP.String()
+		}
+
+		res.Hosts = append(res.Hosts, host)
+	}
+
+	return res, nil
+}
+
+// WatchWakeEvents implements WakeServiceServer.
+func (s *Server) WatchWakeEvents(req *WatchWakeEventsRequest, stream grpc.ServerStreamingServer[WakeEvent]) error {
+	w := &watcher{
+		events: make(chan *WakeEvent, watchBuffer),
+	}
+	if m := req.GetMac(); m != "" {
+		mac, err := net.ParseMAC(m)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid hardware address %q", m)
+		}
+
+		w.mac = mac.String()
+	}
+
+	s.mu.Lock()
+	s.watchers[w] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, w)
+		s.mu.Unlock()
+	}()
+
+	// Send headers so clients know the watch is established before any
+	// events occur.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-w.events:
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// wake handles a single wake request and records its outcome in s's
+// metrics.
+func (s *Server) wake(ctx context.Context, req *WakeRequest) (*WakeResponse, error) {
+	res, err := s.tryWake(ctx, req)
+	if err == nil {
+		ifi := ""
+		if res.Transport == Transport_TRANSPORT_RAW && !isDestination(res.Via) {
+			ifi = res.Via
+		}
+
+		s.mm.Relayed(transportName(res.Transport, res.Via), ifi)
+		return res, nil
+	}
+
+	// Failures to send are counted by the clients' observers, if any.
+	if reason, ok := rejectReason(status.Code(err)); ok {
+		s.mm.Rejected("", "", reason)
+	}
+
+	return nil, err
+}
+
+// tryWake attempts to handle a single wake request.
+func (s *Server) tryWake(ctx context.Context, req *WakeRequest) (*WakeResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	target := req.GetTarget()
+	if target == "" {
+		return nil, status.Error(codes.InvalidArgument, "no target")
+	}
+
+	// Destination URLs may only come from the inventory: otherwise, callers
+	// could bypass the server's configured clients, or make it dial any
+	// relay they name.
+	if isDestination(req.GetAddress()) || isDestination(req.GetInterface()) {
+		return nil, status.Error(codes.InvalidArgument, "address and interface must not be destination URLs")
+	}
+
+	h, err := s.resolve(target)
+	if err != nil {
+		return nil, err
+	}
+
+	password := h.Password
+	if p := req.GetPassword(); len(p) > 0 {
+		password = p
+	}
+	if l := len(password); l != 0 && l != 4 && l != 6 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid password length %d", l)
+	}
+
+	res := &WakeResponse{
+		Mac:  h.MAC.String(),
+		Name: h.Name,
+	}
+
+	// Requests take precedence over the inventory's wake method.
+	var dest bool
+	switch {
+	case req.GetTransport() == Transport_TRANSPORT_UDP:
+		res.Transport, res.Via = Transport_TRANSPORT_UDP, req.GetAddress()
+	case req.GetTransport() == Transport_TRANSPORT_RAW:
+		res.Transport, res.Via = Transport_TRANSPORT_RAW, req.GetInterface()
+	default:
+		switch m, via := h.Method(); m {
+		case inventory.MethodDest:
+			res.Transport, res.Via = destTransport(via), via
+			dest = true
+		case inventory.MethodAddr:
+			res.Transport, res.Via = Transport_TRANSPORT_UDP, via
+		case inventory.MethodInterface:
+			res.Transport, res.Via = Transport_TRANSPORT_RAW, via
+		default:
+			// The server's own address replaces DefaultAddr.
+			res.Transport = Transport_TRANSPORT_UDP
+		}
+	}
+
+	switch {
+	case dest:
+		err = wakeDest(ctx, res.Via, h.MAC, password)
+	case res.Transport == Transport_TRANSPORT_UDP:
+		err = s.wakeUDP(res, h.MAC, password)
+	default:
+		err = s.wakeRaw(res, h.MAC, password)
+	}
+
+	if s.obs != nil {
+		s.obs.ObserveSend(&wol.SendEvent{
+			Time:        time.Now(),
+			Transport:   transportName(res.Transport, res.Via),
+			Destination: res.Via,
+			Target:      h.MAC,
+			Password:    len(password) > 0,
+			Identity:    s.id(ctx),
+			Err:         err,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(&WakeEvent{
+		Time:      timestamppb.Now(),
+		Kind:      WakeEventKind_WAKE_EVENT_KIND_SENT,
+		Transport: res.Transport,
+		Mac:       res.Mac,
+		Name:      res.Name,
+		Address:   res.Via,
+		Password:  len(password) > 0,
+	})
+
+	return res, nil
+}
+
+// wakeUDP sends a magic packet over UDP, filling in the address used in res.
+func (s *Server) wakeUDP(res *WakeResponse, target net.HardwareAddr, password []byte) error {
+	if s.c == nil {
+		return status.Error(codes.FailedPrecondition, "server cannot send magic packets over UDP")
+	}
+	if res.Via == "" {
+		res.Via = s.addr
+	}
+
+	if err := s.c.WakePassword(res.Via, target, password); err != nil {
+		return status.Errorf(codes.Unavailable, "failed to send magic packet to %s: %v", res.Via, err)
+	}
+
+	return nil
+}
+
+// wakeRaw sends a magic packet over a raw Ethernet socket, filling in the
+// network interface used in res.
+func (s *Server) wakeRaw(res *WakeResponse, target net.HardwareAddr, password []byte) error {
+	if res.Via == "" {
+		// Only infer the network interface if there is no ambiguity.
+		if len(s.raw) != 1 {
+			return status.Error(codes.InvalidArgument, "must specify a network interface")
+		}
+
+		for name := range s.raw {
+			res.Via = name
+		}
+	}
+
+	c, ok := s.raw[res.Via]
+	if !ok {
+		return status.Errorf(codes.FailedPrecondition, "server cannot send magic packets on interface %q", res.Via)
+	}
+
+	if err := c.WakePassword(target, password); err != nil {
+		return status.Errorf(codes.Unavailable, "failed to send magic packet on %s: %v", res.Via, err)
+	}
+
+	return nil
+}
+
+// wakeDest sends a magic packet using the destination URL dest.
+func wakeDest(ctx context.Context, dest string, target net.HardwareAddr, password []byte) error {
+	d, err := wol.OpenDestination(dest)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "server cannot open destination %q: %v", dest, err)
+	}
+	defer d.Close()
+
+	if err := d.WakePasswordContext(ctx, target, password); err != nil {
+		return status.Errorf(codes.Unavailable, "failed to send magic packet to %s: %v", dest, err)
+	}
+
+	return nil
+}
+
+// resolve resolves target as either a hardware address or the name of a
+// host in the inventory.
+func (s *Server) resolve(target string) (*inventory.Host, error) {
+	if mac, err := net.ParseMAC(target); err == nil {
+		if len(mac) != 6 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid hardware address %q", target)
+		}
+
+		if h, ok := s.inv.LookupMAC(mac); ok {
+			return h, nil
+		}
+
+		return &inventory.Host{MAC: mac}, nil
+	}
+
+	if h, ok := s.inv.Lookup(target); ok {
+		return h, nil
+	}
+
+	return nil, status.Errorf(codes.NotFound, "%q is neither a hardware address nor a known host", target)
+}
+
+// name returns the inventory name of the host with hardware address mac, if
+// any.
+func (s *Server) name(mac net.HardwareAddr) string {
+	if h, ok := s.inv.LookupMAC(mac); ok {
+		return h.Name
+	}
+
+	return ""
+}
+
+// transportName returns the wol package's name for transport t. If via is a
+// destination URL whose scheme has no Transport, its scheme is returned.
+func transportName(t Transport, via string) string {
+	switch t {
+	case Transport_TRANSPORT_UDP:
+		return wol.TransportUDP
+	case Transport_TRANSPORT_RAW:
+		return wol.TransportRaw
+	}
+
+	if u, err := wol.ParseDestination(via); err == nil {
+		return u.Scheme
+	}
+
+	return ""
+}
+
+// destTransport returns the Transport used by the destination URL dest, or
+// Transport_TRANSPORT_UNSPECIFIED if its scheme uses neither UDP nor raw
+// Ethernet sockets directly.
+func destTransport(dest string) Transport {
+	u, err := wol.ParseDestination(dest)
+	if err != nil {
+		return Transport_TRANSPORT_UNSPECIFIED
+	}
+
+	switch u.Scheme {
+	case "udp", "udp6":
+		return Transport_TRANSPORT_UDP
+	case "raw":
+		return Transport_TRANSPORT_RAW
+	default:
+		return Transport_TRANSPORT_UNSPECIFIED
+	}
+}
+
+// isDestination reports whether via is a destination URL rather than a UDP
+// address or network interface name.
+func isDestination(via string) bool {
+	return strings.Contains(via, "://")
+}
+
+// rejectReason returns the reason label for metrics of a request rejected
+// with code c, or false if c does not indicate a rejected request.
+func rejectReason(c codes.Code) (string, bool) {
+	switch c {
+	case codes.InvalidArgument:
+		return "invalid_request", true
+	case codes.NotFound:
+		return "unknown_host", true
+	case codes.FailedPrecondition:
+		return "unsupported_transport", true
+	case codes.Canceled, codes.DeadlineExceeded:
+		return "canceled", true
+	default:
+		return "", false
+	}
+}
+
+// peerAddr returns the network address of the client making a request.
+func peerAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	return p.Addr.String()
+}
+
+// A watcher is a client watching wake events.
+type watcher struct {
+	// mac is the hardware address of interest in canonical form, or empty
+	// for all events.
+	mac    string
+	events chan *WakeEvent
+}
+
+// publish sends e to all interested watchers. Events are dropped for
+// watchers which are not keeping up.
+func (s *Server) publish(e *WakeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for w := range s.watchers {
+		if w.mac != "" && w.mac != e.Mac {
+			continue
+		}
+
+		select {
+		case w.events <- e:
+		default:
+		}
+	}
+}
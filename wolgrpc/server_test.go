@@ -0,0 +1,523 @@
+package wolgrpc_test
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/inventory"
+	"github.com/mdlayher/wol/metrics"
+	"github.com/mdlayher/wol/wolgrpc"
+	"github.com/mdlayher/wol/woltest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+var (
+	desktopMAC = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40}
+	nasMAC     = net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x41}
+)
+
+func TestServerWake(t *testing.T) {
+	c, n := testServer(t)
+	desktop := n.AddHost(desktopMAC, nil)
+	nas := n.AddHost(nasMAC, []byte("abcd"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := c.Wake(ctx, &wolgrpc.WakeRequest{Target: "desktop"})
+	if err != nil {
+		t.Fatalf("failed to wake desktop: %v", err)
+	}
+
+	want := &wolgrpc.WakeResponse{
+		Mac:       desktopMAC.String(),
+		Name:      "desktop",
+		Transport: wolgrpc.Transport_TRANSPORT_UDP,
+		Via:       "192.0.2.255:9",
+	}
+	if diff := cmp.Diff(want, res, protocmp.Transform()); diff != "" {
+		t.Fatalf("unexpected response (-want +got):\n%s", diff)
+	}
+
+	// The inventory specifies a raw Ethernet socket and password for nas.
+	res, err = c.Wake(ctx, &wolgrpc.WakeRequest{Target: nasMAC.String()})
+	if err != nil {
+		t.Fatalf("failed to wake nas: %v", err)
+	}
+
+	want = &wolgrpc.WakeResponse{
+		Mac:       nasMAC.String(),
+		Name:      "nas",
+		Transport: wolgrpc.Transport_TRANSPORT_RAW,
+		Via:       "woltest1",
+	}
+	if diff := cmp.Diff(want, res, protocmp.Transform()); diff != "" {
+		t.Fatalf("unexpected response (-want +got):\n%s", diff)
+	}
+
+	for _, h := range []*woltest.Host{desktop, nas} {
+		if err := h.Wait(ctx); err != nil {
+			t.Fatalf("host %s did not wake: %v", h.MAC, err)
+		}
+	}
+}
+
+func TestServerWakeErrors(t *testing.T) {
+	c, _ := testServer(t)
+
+	var tests = []struct {
+		name string
+		req  *wolgrpc.WakeRequest
+		code codes.Code
+	}{
+		{
+			name: "no target",
+			req:  &wolgrpc.WakeRequest{},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "unknown host",
+			req:  &wolgrpc.WakeRequest{Target: "bogus"},
+			code: codes.NotFound,
+		},
+		{
+			name: "bad password",
+			req:  &wolgrpc.WakeRequest{Target: "desktop", Password: []byte{1, 2, 3}},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "unknown interface",
+			req: &wolgrpc.WakeRequest{
+				Target:    "desktop",
+				Transport: wolgrpc.Transport_TRANSPORT_RAW,
+				Interface: "eth0",
+			},
+			code: codes.FailedPrecondition,
+		},
+		{
+			name: "raw destination address",
+			req: &wolgrpc.WakeRequest{
+				Target:    "desktop",
+				Transport: wolgrpc.Transport_TRANSPORT_UDP,
+				Address:   "raw://eth3",
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "relay destination interface",
+			req: &wolgrpc.WakeRequest{
+				Target:    "desktop",
+				Transport: wolgrpc.Transport_TRANSPORT_RAW,
+				Interface: "relay://attacker:4000",
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.Wake(context.Background(), tt.req)
+			if got := status.Code(err); got != tt.code {
+				t.Fatalf("unexpected code: %v (%v)", got, err)
+			}
+		})
+	}
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := c.Wake(ctx, &wolgrpc.WakeRequest{Target: "desktop"})
+		if got := status.Code(err); got != codes.Canceled {
+			t.Fatalf("unexpected code: %v (%v)", got, err)
+		}
+	})
+}
+
+func TestServerWakeBatch(t *testing.T) {
+	c, n := testServer(t)
+	desktop := n.AddHost(desktopMAC, nil)
+
+	res, err := c.WakeBatch(context.Background(), &wolgrpc.WakeBatchRequest{
+		Requests: []*wolgrpc.WakeRequest{
+			{Target: "desktop"},
+			{Target: "bogus"},
+			{
+				Target:    nasMAC.String(),
+				Transport: wolgrpc.Transport_TRANSPORT_UDP,
+				Address:   "192.0.2.1:7",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to wake batch: %v", err)
+	}
+
+	want := &wolgrpc.WakeBatchResponse{
+		Results: []*wolgrpc.WakeResult{
+			{
+				Target: "desktop",
+				Response: &wolgrpc.WakeResponse{
+					Mac:       desktopMAC.String(),
+					Name:      "desktop",
+					Transport: wolgrpc.Transport_TRANSPORT_UDP,
+					Via:       "192.0.2.255:9",
+				},
+			},
+			{
+				Target: "bogus",
+				Error:  `"bogus" is neither a hardware address nor a known host`,
+			},
+			{
+				Target: nasMAC.String(),
+				Response: &wolgrpc.WakeResponse{
+					Mac:       nasMAC.String(),
+					Name:      "nas",
+					Transport: wolgrpc.Transport_TRANSPORT_UDP,
+					Via:       "192.0.2.1:7",
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, res, protocmp.Transform()); diff != "" {
+		t.Fatalf("unexpected response (-want +got):\n%s", diff)
+	}
+
+	if !desktop.Awake() {
+		t.Fatal("desktop did not wake")
+	}
+}
+
+func TestServerListHosts(t *testing.T) {
+	c, _ := testServer(t)
+
+	res, err := c.ListHosts(context.Background(), &wolgrpc.ListHostsRequest{})
+	if err != nil {
+		t.Fatalf("failed to list hosts: %v", err)
+	}
+
+	want := &wolgrpc.ListHostsResponse{
+		Hosts: []*wolgrpc.Host{
+			{
+				Name: "desktop",
+				Mac:  desktopMAC.String(),
+				Ip:   "192.0.2.10",
+			},
+			{
+				Name:        "nas",
+				Mac:         nasMAC.String(),
+				Interface:   "woltest1",
+				HasPassword: true,
+			},
+		},
+	}
+	if diff := cmp.Diff(want, res, protocmp.Transform()); diff != "" {
+		t.Fatalf("unexpected response (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerWatchWakeEvents(t *testing.T) {
+	c, n := testServer(t)
+	n.AddHost(desktopMAC, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := c.WatchWakeEvents(ctx, &wolgrpc.WatchWakeEventsRequest{
+		Mac: desktopMAC.String(),
+	})
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+
+	// Wait for the watch to be established.
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("failed to receive header: %v", err)
+	}
+
+	// Events for other machines are filtered.
+	for _, target := range []string{nasMAC.String(), "desktop"} {
+		if _, err := c.Wake(ctx, &wolgrpc.WakeRequest{Target: target}); err != nil {
+			t.Fatalf("failed to wake %s: %v", target, err)
+		}
+	}
+
+	// The server both sends the magic packet and receives it using its
+	// listener, in either order.
+	var got []*wolgrpc.WakeEvent
+	for i := 0; i < 2; i++ {
+		e, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("failed to receive event: %v", err)
+		}
+		if e.GetTime() == nil {
+			t.Fatal("event has no time")
+		}
+
+		got = append(got, e)
+	}
+	sort.Slice(got, func(i, j int) bool {
+		return got[i].GetKind() < got[j].GetKind()
+	})
+
+	want := []*wolgrpc.WakeEvent{
+		{
+			Kind:      wolgrpc.WakeEventKind_WAKE_EVENT_KIND_SENT,
+			Transport: wolgrpc.Transport_TRANSPORT_UDP,
+			Mac:       desktopMAC.String(),
+			Name:      "desktop",
+			Address:   "192.0.2.255:9",
+		},
+		{
+			Kind:      wolgrpc.WakeEventKind_WAKE_EVENT_KIND_RECEIVED,
+			Transport: wolgrpc.Transport_TRANSPORT_UDP,
+			Mac:       desktopMAC.String(),
+			Name:      "desktop",
+			Address:   "192.0.2.1:49152",
+		},
+	}
+
+	opts := []cmp.Option{
+		protocmp.Transform(),
+		protocmp.IgnoreFields(&wolgrpc.WakeEvent{}, "time"),
+	}
+	if diff := cmp.Diff(want, got, opts...); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerObserver(t *testing.T) {
+	events := make(chan *wol.SendEvent, 1)
+	c, _ := testServer(t, func(cfg *wolgrpc.Config) {
+		cfg.Observer = observerFunc(func(e *wol.SendEvent) { events <- e })
+		cfg.Identify = func(_ context.Context) string { return "alice" }
+	})
+
+	if _, err := c.Wake(context.Background(), &wolgrpc.WakeRequest{Target: "desktop"}); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+
+	got := <-events
+	if got.Time.IsZero() {
+		t.Fatal("event has no time")
+	}
+	got.Time = time.Time{}
+
+	want := &wol.SendEvent{
+		Transport:   wol.TransportUDP,
+		Destination: "192.0.2.255:9",
+		Target:      desktopMAC,
+		Identity:    "alice",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected event (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	c, _ := testServer(t, func(cfg *wolgrpc.Config) {
+		cfg.Metrics = metrics.New(reg)
+	})
+
+	ctx := context.Background()
+	if _, err := c.Wake(ctx, &wolgrpc.WakeRequest{Target: "desktop"}); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+	if _, err := c.Wake(ctx, &wolgrpc.WakeRequest{Target: "laptop"}); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	want := `
+# HELP wol_packets_rejected_total Number of magic packets or wake requests rejected, by reason.
+# TYPE wol_packets_rejected_total counter
+wol_packets_rejected_total{interface="",reason="unknown_host",transport=""} 1
+# HELP wol_packets_relayed_total Number of magic packets sent on behalf of remote requests.
+# TYPE wol_packets_relayed_total counter
+wol_packets_relayed_total{interface="",transport="udp"} 1
+`
+
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"wol_packets_rejected_total", "wol_packets_relayed_total"); err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+}
+
+func TestServerWakeDestination(t *testing.T) {
+	// Destinations with the test scheme record their targets. Schemes can
+	// only be registered once per process, even if the test runs again.
+	targets := make(chan string, 1)
+	registerOnce.Do(func() {
+		wol.RegisterScheme("wolgrpc-test", func(u *url.URL) (wol.Destination, error) {
+			return &fakeDestination{
+				wake: func(target net.HardwareAddr) {
+					destTargets <- u.Host + "/" + target.String()
+				},
+			}, nil
+		})
+	})
+	destTargets = targets
+
+	inv, err := inventory.Parse(strings.NewReader(desktopMAC.String() + " desktop dest=wolgrpc-test://lab"))
+	if err != nil {
+		t.Fatalf("failed to parse inventory: %v", err)
+	}
+
+	c, _ := testServer(t, func(cfg *wolgrpc.Config) {
+		cfg.Inventory = inv
+	})
+
+	res, err := c.Wake(context.Background(), &wolgrpc.WakeRequest{Target: "desktop"})
+	if err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+
+	want := &wolgrpc.WakeResponse{
+		Mac:  desktopMAC.String(),
+		Name: "desktop",
+		Via:  "wolgrpc-test://lab",
+	}
+	if diff := cmp.Diff(want, res, protocmp.Transform()); diff != "" {
+		t.Fatalf("unexpected response (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff("lab/"+desktopMAC.String(), <-targets); diff != "" {
+		t.Fatalf("unexpected destination target (-want +got):\n%s", diff)
+	}
+
+	// Callers may not name destination URLs themselves.
+	for _, req := range []*wolgrpc.WakeRequest{
+		{
+			Target:    "desktop",
+			Transport: wolgrpc.Transport_TRANSPORT_UDP,
+			Address:   "wolgrpc-test://attacker",
+		},
+		{
+			Target:    "desktop",
+			Transport: wolgrpc.Transport_TRANSPORT_RAW,
+			Interface: "wolgrpc-test://attacker",
+		},
+	} {
+		_, err := c.Wake(context.Background(), req)
+		if got := status.Code(err); got != codes.InvalidArgument {
+			t.Fatalf("unexpected code: %v (%v)", got, err)
+		}
+	}
+
+	select {
+	case target := <-targets:
+		t.Fatalf("unexpected destination target: %s", target)
+	default:
+	}
+}
+
+var (
+	registerOnce sync.Once
+	destTargets  chan string
+)
+
+// A fakeDestination is a wol.Destination which calls wake for each target.
+type fakeDestination struct {
+	wake func(target net.HardwareAddr)
+}
+
+func (d *fakeDestination) WakeContext(ctx context.Context, target net.HardwareAddr) error {
+	return d.WakePasswordContext(ctx, target, nil)
+}
+
+func (d *fakeDestination) WakePasswordContext(_ context.Context, target net.HardwareAddr, _ []byte) error {
+	d.wake(target)
+	return nil
+}
+
+func (d *fakeDestination) Close() error { return nil }
+
+type observerFunc func(e *wol.SendEvent)
+
+func (fn observerFunc) ObserveSend(e *wol.SendEvent) { fn(e) }
+
+// testServer starts a Server on an in-memory network and returns a client
+// connected to it. Each of fns may modify the Server's configuration.
+func testServer(t *testing.T, fns ...func(cfg *wolgrpc.Config)) (wolgrpc.WakeServiceClient, *woltest.Network) {
+	t.Helper()
+
+	inv, err := inventory.Parse(strings.NewReader(strings.Join([]string{
+		desktopMAC.String() + " desktop ip=192.0.2.10",
+		nasMAC.String() + " nas iface=woltest1 password=abcd",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("failed to parse inventory: %v", err)
+	}
+
+	n := woltest.NewNetwork()
+
+	pc, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	lc, err := n.ListenUDP(&net.UDPAddr{Port: 9})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ifi, rc := n.ListenRaw()
+
+	cfg := &wolgrpc.Config{
+		Inventory: inv,
+		Client:    wol.NewClientConn(pc),
+		Addr:      (&net.UDPAddr{IP: n.Broadcast(), Port: 9}).String(),
+		RawClients: map[string]*wol.RawClient{
+			ifi.Name: wol.NewRawClientConn(ifi, rc),
+		},
+	}
+	for _, fn := range fns {
+		fn(cfg)
+	}
+
+	srv := wolgrpc.NewServer(cfg)
+
+	l := wol.NewListenerConn(lc)
+	go func() { _ = srv.Serve(l) }()
+
+	gs := grpc.NewServer()
+	wolgrpc.RegisterWakeServiceServer(gs, srv)
+
+	bl := bufconn.Listen(1 << 16)
+	go func() { _ = gs.Serve(bl) }()
+
+	conn, err := grpc.NewClient("passthrough:///woltest",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return bl.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		gs.Stop()
+		_ = l.Close()
+		_ = pc.Close()
+		_ = rc.Close()
+	})
+
+	return wolgrpc.NewWakeServiceClient(conn), n
+}
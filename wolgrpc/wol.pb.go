@@ -0,0 +1,1077 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: wol.proto
+
+// Package wol.v1 defines a service which wakes machines using Wake-on-LAN.
+
+package wolgrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Transport is the method used to carry a magic packet.
+type Transport int32
+
+const (
+	// The server chooses a transport using its inventory and defaults.
+	Transport_TRANSPORT_UNSPECIFIED Transport = 0
+	// A UDP datagram.
+	Transport_TRANSPORT_UDP Transport = 1
+	// A raw Ethernet frame with EtherType 0x0842.
+	Transport_TRANSPORT_RAW Transport = 2
+)
+
+// Enum value maps for Transport.
+var (
+	Transport_name = map[int32]string{
+		0: "TRANSPORT_UNSPECIFIED",
+		1: "TRANSPORT_UDP",
+		2: "TRANSPORT_RAW",
+	}
+	Transport_value = map[string]int32{
+		"TRANSPORT_UNSPECIFIED": 0,
+		"TRANSPORT_UDP":         1,
+		"TRANSPORT_RAW":         2,
+	}
+)
+
+func (x Transport) Enum() *Transport {
+	p := new(Transport)
+	*p = x
+	return p
+}
+
+func (x Transport) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Transport) Descriptor() protoreflect.EnumDescriptor {
+	return file_wol_proto_enumTypes[0].Descriptor()
+}
+
+func (Transport) Type() protoreflect.EnumType {
+	return &file_wol_proto_enumTypes[0]
+}
+
+func (x Transport) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Transport.Descriptor instead.
+func (Transport) EnumDescriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{0}
+}
+
+// WakeEventKind describes how a server observed a magic packet.
+type WakeEventKind int32
+
+const (
+	WakeEventKind_WAKE_EVENT_KIND_UNSPECIFIED WakeEventKind = 0
+	// The server sent a magic packet in response to a request.
+	WakeEventKind_WAKE_EVENT_KIND_SENT WakeEventKind = 1
+	// The server received a magic packet using a listener.
+	WakeEventKind_WAKE_EVENT_KIND_RECEIVED WakeEventKind = 2
+)
+
+// Enum value maps for WakeEventKind.
+var (
+	WakeEventKind_name = map[int32]string{
+		0: "WAKE_EVENT_KIND_UNSPECIFIED",
+		1: "WAKE_EVENT_KIND_SENT",
+		2: "WAKE_EVENT_KIND_RECEIVED",
+	}
+	WakeEventKind_value = map[string]int32{
+		"WAKE_EVENT_KIND_UNSPECIFIED": 0,
+		"WAKE_EVENT_KIND_SENT":        1,
+		"WAKE_EVENT_KIND_RECEIVED":    2,
+	}
+)
+
+func (x WakeEventKind) Enum() *WakeEventKind {
+	p := new(WakeEventKind)
+	*p = x
+	return p
+}
+
+func (x WakeEventKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WakeEventKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_wol_proto_enumTypes[1].Descriptor()
+}
+
+func (WakeEventKind) Type() protoreflect.EnumType {
+	return &file_wol_proto_enumTypes[1]
+}
+
+func (x WakeEventKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WakeEventKind.Descriptor instead.
+func (WakeEventKind) EnumDescriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{1}
+}
+
+type WakeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The hardware address or inventory host name of the machine to wake.
+	Target string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	// An optional SecureOn password of 0, 4, or 6 bytes, overriding any
+	// password in the server's inventory.
+	Password []byte `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	// The transport to use. If set, address or interface may also be set.
+	Transport Transport `protobuf:"varint,3,opt,name=transport,proto3,enum=wol.v1.Transport" json:"transport,omitempty"`
+	// The UDP address used with TRANSPORT_UDP, such as "192.168.1.255:9".
+	Address string `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	// The network interface used with TRANSPORT_RAW, such as "eth0".
+	Interface string `protobuf:"bytes,5,opt,name=interface,proto3" json:"interface,omitempty"`
+}
+
+func (x *WakeRequest) Reset() {
+	*x = WakeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WakeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WakeRequest) ProtoMessage() {}
+
+func (x *WakeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WakeRequest.ProtoReflect.Descriptor instead.
+func (*WakeRequest) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WakeRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *WakeRequest) GetPassword() []byte {
+	if x != nil {
+		return x.Password
+	}
+	return nil
+}
+
+func (x *WakeRequest) GetTransport() Transport {
+	if x != nil {
+		return x.Transport
+	}
+	return Transport_TRANSPORT_UNSPECIFIED
+}
+
+func (x *WakeRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *WakeRequest) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+type WakeResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The hardware address of the machine, and its inventory name, if any.
+	Mac  string `protobuf:"bytes,1,opt,name=mac,proto3" json:"mac,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// The transport used to send the magic packet, and the UDP address or
+	// network interface it was sent from.
+	Transport Transport `protobuf:"varint,3,opt,name=transport,proto3,enum=wol.v1.Transport" json:"transport,omitempty"`
+	Via       string    `protobuf:"bytes,4,opt,name=via,proto3" json:"via,omitempty"`
+}
+
+func (x *WakeResponse) Reset() {
+	*x = WakeResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WakeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WakeResponse) ProtoMessage() {}
+
+func (x *WakeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WakeResponse.ProtoReflect.Descriptor instead.
+func (*WakeResponse) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WakeResponse) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *WakeResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WakeResponse) GetTransport() Transport {
+	if x != nil {
+		return x.Transport
+	}
+	return Transport_TRANSPORT_UNSPECIFIED
+}
+
+func (x *WakeResponse) GetVia() string {
+	if x != nil {
+		return x.Via
+	}
+	return ""
+}
+
+type WakeBatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Requests []*WakeRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+}
+
+func (x *WakeBatchRequest) Reset() {
+	*x = WakeBatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WakeBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WakeBatchRequest) ProtoMessage() {}
+
+func (x *WakeBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WakeBatchRequest.ProtoReflect.Descriptor instead.
+func (*WakeBatchRequest) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WakeBatchRequest) GetRequests() []*WakeRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type WakeBatchResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Results for each request, in the same order.
+	Results []*WakeResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *WakeBatchResponse) Reset() {
+	*x = WakeBatchResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WakeBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WakeBatchResponse) ProtoMessage() {}
+
+func (x *WakeBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WakeBatchResponse.ProtoReflect.Descriptor instead.
+func (*WakeBatchResponse) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WakeBatchResponse) GetResults() []*WakeResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type WakeResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The target of the corresponding request.
+	Target string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	// The response if the magic packet was sent, or otherwise, an error.
+	Response *WakeResponse `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	Error    string        `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *WakeResult) Reset() {
+	*x = WakeResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WakeResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WakeResult) ProtoMessage() {}
+
+func (x *WakeResult) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WakeResult.ProtoReflect.Descriptor instead.
+func (*WakeResult) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WakeResult) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *WakeResult) GetResponse() *WakeResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *WakeResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ListHostsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListHostsRequest) Reset() {
+	*x = ListHostsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListHostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHostsRequest) ProtoMessage() {}
+
+func (x *ListHostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHostsRequest.ProtoReflect.Descriptor instead.
+func (*ListHostsRequest) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{5}
+}
+
+type ListHostsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hosts []*Host `protobuf:"bytes,1,rep,name=hosts,proto3" json:"hosts,omitempty"`
+}
+
+func (x *ListHostsResponse) Reset() {
+	*x = ListHostsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListHostsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHostsResponse) ProtoMessage() {}
+
+func (x *ListHostsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHostsResponse.ProtoReflect.Descriptor instead.
+func (*ListHostsResponse) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListHostsResponse) GetHosts() []*Host {
+	if x != nil {
+		return x.Hosts
+	}
+	return nil
+}
+
+// Host is a machine in the server's inventory.
+type Host struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Mac         string `protobuf:"bytes,2,opt,name=mac,proto3" json:"mac,omitempty"`
+	Address     string `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Interface   string `protobuf:"bytes,4,opt,name=interface,proto3" json:"interface,omitempty"`
+	Ip          string `protobuf:"bytes,5,opt,name=ip,proto3" json:"ip,omitempty"`
+	HasPassword bool   `protobuf:"varint,6,opt,name=has_password,json=hasPassword,proto3" json:"has_password,omitempty"`
+}
+
+func (x *Host) Reset() {
+	*x = Host{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Host) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Host) ProtoMessage() {}
+
+func (x *Host) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Host.ProtoReflect.Descriptor instead.
+func (*Host) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Host) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Host) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *Host) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Host) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *Host) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Host) GetHasPassword() bool {
+	if x != nil {
+		return x.HasPassword
+	}
+	return false
+}
+
+type WatchWakeEventsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// If set, only events for this hardware address are streamed.
+	Mac string `protobuf:"bytes,1,opt,name=mac,proto3" json:"mac,omitempty"`
+}
+
+func (x *WatchWakeEventsRequest) Reset() {
+	*x = WatchWakeEventsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchWakeEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchWakeEventsRequest) ProtoMessage() {}
+
+func (x *WatchWakeEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchWakeEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchWakeEventsRequest) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchWakeEventsRequest) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+type WakeEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Time      *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Kind      WakeEventKind          `protobuf:"varint,2,opt,name=kind,proto3,enum=wol.v1.WakeEventKind" json:"kind,omitempty"`
+	Transport Transport              `protobuf:"varint,3,opt,name=transport,proto3,enum=wol.v1.Transport" json:"transport,omitempty"`
+	// The hardware address of the target machine, and its inventory name,
+	// if any.
+	Mac  string `protobuf:"bytes,4,opt,name=mac,proto3" json:"mac,omitempty"`
+	Name string `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	// For sent packets, the UDP address or network interface used. For
+	// received packets, the address of the sender.
+	Address string `protobuf:"bytes,6,opt,name=address,proto3" json:"address,omitempty"`
+	// Whether the magic packet carried a SecureOn password.
+	Password bool `protobuf:"varint,7,opt,name=password,proto3" json:"password,omitempty"`
+}
+
+func (x *WakeEvent) Reset() {
+	*x = WakeEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wol_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WakeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WakeEvent) ProtoMessage() {}
+
+func (x *WakeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_wol_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WakeEvent.ProtoReflect.Descriptor instead.
+func (*WakeEvent) Descriptor() ([]byte, []int) {
+	return file_wol_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WakeEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *WakeEvent) GetKind() WakeEventKind {
+	if x != nil {
+		return x.Kind
+	}
+	return WakeEventKind_WAKE_EVENT_KIND_UNSPECIFIED
+}
+
+func (x *WakeEvent) GetTransport() Transport {
+	if x != nil {
+		return x.Transport
+	}
+	return Transport_TRANSPORT_UNSPECIFIED
+}
+
+func (x *WakeEvent) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *WakeEvent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WakeEvent) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *WakeEvent) GetPassword() bool {
+	if x != nil {
+		return x.Password
+	}
+	return false
+}
+
+var File_wol_proto protoreflect.FileDescriptor
+
+var file_wol_proto_rawDesc = []byte{
+	0x0a, 0x09, 0x77, 0x6f, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x77, 0x6f, 0x6c,
+	0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0xaa, 0x01, 0x0a, 0x0b, 0x57, 0x61, 0x6b, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08,
+	0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x2f, 0x0a, 0x09, 0x74, 0x72, 0x61, 0x6e,
+	0x73, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x77, 0x6f,
+	0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x09,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63,
+	0x65, 0x22, 0x77, 0x0a, 0x0c, 0x57, 0x61, 0x6b, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6d, 0x61, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2f, 0x0a, 0x09, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x77, 0x6f, 0x6c,
+	0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x09, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x76, 0x69, 0x61, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x76, 0x69, 0x61, 0x22, 0x43, 0x0a, 0x10, 0x57, 0x61,
+	0x6b, 0x65, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2f,
+	0x0a, 0x08, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x13, 0x2e, 0x77, 0x6f, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x6b, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x08, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x22,
+	0x41, 0x0a, 0x11, 0x57, 0x61, 0x6b, 0x65, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x77, 0x6f, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x57,
+	0x61, 0x6b, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x73, 0x22, 0x6c, 0x0a, 0x0a, 0x57, 0x61, 0x6b, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x30, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x77, 0x6f, 0x6c,
+	0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x6b, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x22, 0x12, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0x37, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x05, 0x68, 0x6f, 0x73,
+	0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x77, 0x6f, 0x6c, 0x2e, 0x76,
+	0x31, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x52, 0x05, 0x68, 0x6f, 0x73, 0x74, 0x73, 0x22, 0x97, 0x01,
+	0x0a, 0x04, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61,
+	0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x61, 0x63, 0x12, 0x18, 0x0a, 0x07,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66,
+	0x61, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x66, 0x61, 0x63, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x69, 0x70, 0x12, 0x21, 0x0a, 0x0c, 0x68, 0x61, 0x73, 0x5f, 0x70, 0x61, 0x73, 0x73,
+	0x77, 0x6f, 0x72, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x68, 0x61, 0x73, 0x50,
+	0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x22, 0x2a, 0x0a, 0x16, 0x57, 0x61, 0x74, 0x63, 0x68,
+	0x57, 0x61, 0x6b, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6d, 0x61, 0x63, 0x22, 0xf3, 0x01, 0x0a, 0x09, 0x57, 0x61, 0x6b, 0x65, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x74, 0x69, 0x6d,
+	0x65, 0x12, 0x29, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x15, 0x2e, 0x77, 0x6f, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x6b, 0x65, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x2f, 0x0a, 0x09,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x11, 0x2e, 0x77, 0x6f, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f,
+	0x72, 0x74, 0x52, 0x09, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x10, 0x0a,
+	0x03, 0x6d, 0x61, 0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x61, 0x63, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1a, 0x0a,
+	0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x2a, 0x4c, 0x0a, 0x09, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x19, 0x0a, 0x15, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x50,
+	0x4f, 0x52, 0x54, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10,
+	0x00, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x50, 0x4f, 0x52, 0x54, 0x5f, 0x55,
+	0x44, 0x50, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x50, 0x4f, 0x52,
+	0x54, 0x5f, 0x52, 0x41, 0x57, 0x10, 0x02, 0x2a, 0x68, 0x0a, 0x0d, 0x57, 0x61, 0x6b, 0x65, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x1f, 0x0a, 0x1b, 0x57, 0x41, 0x4b, 0x45,
+	0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55, 0x4e, 0x53, 0x50,
+	0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x57, 0x41, 0x4b,
+	0x45, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x53, 0x45, 0x4e,
+	0x54, 0x10, 0x01, 0x12, 0x1c, 0x0a, 0x18, 0x57, 0x41, 0x4b, 0x45, 0x5f, 0x45, 0x56, 0x45, 0x4e,
+	0x54, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44, 0x10,
+	0x02, 0x32, 0x8c, 0x02, 0x0a, 0x0b, 0x57, 0x61, 0x6b, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x12, 0x31, 0x0a, 0x04, 0x57, 0x61, 0x6b, 0x65, 0x12, 0x13, 0x2e, 0x77, 0x6f, 0x6c, 0x2e,
+	0x76, 0x31, 0x2e, 0x57, 0x61, 0x6b, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14,
+	0x2e, 0x77, 0x6f, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x6b, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x09, 0x57, 0x61, 0x6b, 0x65, 0x42, 0x61, 0x74, 0x63,
+	0x68, 0x12, 0x18, 0x2e, 0x77, 0x6f, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x6b, 0x65, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x77, 0x6f,
+	0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x6b, 0x65, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x6f,
+	0x73, 0x74, 0x73, 0x12, 0x18, 0x2e, 0x77, 0x6f, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e,
+	0x77, 0x6f, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x0f, 0x57, 0x61, 0x74, 0x63,
+	0x68, 0x57, 0x61, 0x6b, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x1e, 0x2e, 0x77, 0x6f,
+	0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x57, 0x61, 0x6b, 0x65, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x77, 0x6f,
+	0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x6b, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01,
+	0x42, 0x21, 0x5a, 0x1f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d,
+	0x64, 0x6c, 0x61, 0x79, 0x68, 0x65, 0x72, 0x2f, 0x77, 0x6f, 0x6c, 0x2f, 0x77, 0x6f, 0x6c, 0x67,
+	0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_wol_proto_rawDescOnce sync.Once
+	file_wol_proto_rawDescData = file_wol_proto_rawDesc
+)
+
+func file_wol_proto_rawDescGZIP() []byte {
+	file_wol_proto_rawDescOnce.Do(func() {
+		file_wol_proto_rawDescData = protoimpl.X.CompressGZIP(file_wol_proto_rawDescData)
+	})
+	return file_wol_proto_rawDescData
+}
+
+var file_wol_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_wol_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_wol_proto_goTypes = []any{
+	(Transport)(0),                 // 0: wol.v1.Transport
+	(WakeEventKind)(0),             // 1: wol.v1.WakeEventKind
+	(*WakeRequest)(nil),            // 2: wol.v1.WakeRequest
+	(*WakeResponse)(nil),           // 3: wol.v1.WakeResponse
+	(*WakeBatchRequest)(nil),       // 4: wol.v1.WakeBatchRequest
+	(*WakeBatchResponse)(nil),      // 5: wol.v1.WakeBatchResponse
+	(*WakeResult)(nil),             // 6: wol.v1.WakeResult
+	(*ListHostsRequest)(nil),       // 7: wol.v1.ListHostsRequest
+	(*ListHostsResponse)(nil),      // 8: wol.v1.ListHostsResponse
+	(*Host)(nil),                   // 9: wol.v1.Host
+	(*WatchWakeEventsRequest)(nil), // 10: wol.v1.WatchWakeEventsRequest
+	(*WakeEvent)(nil),              // 11: wol.v1.WakeEvent
+	(*timestamppb.Timestamp)(nil),  // 12: google.protobuf.Timestamp
+}
+var file_wol_proto_depIdxs = []int32{
+	0,  // 0: wol.v1.WakeRequest.transport:type_name -> wol.v1.Transport
+	0,  // 1: wol.v1.WakeResponse.transport:type_name -> wol.v1.Transport
+	2,  // 2: wol.v1.WakeBatchRequest.requests:type_name -> wol.v1.WakeRequest
+	6,  // 3: wol.v1.WakeBatchResponse.results:type_name -> wol.v1.WakeResult
+	3,  // 4: wol.v1.WakeResult.response:type_name -> wol.v1.WakeResponse
+	9,  // 5: wol.v1.ListHostsResponse.hosts:type_name -> wol.v1.Host
+	12, // 6: wol.v1.WakeEvent.time:type_name -> google.protobuf.Timestamp
+	1,  // 7: wol.v1.WakeEvent.kind:type_name -> wol.v1.WakeEventKind
+	0,  // 8: wol.v1.WakeEvent.transport:type_name -> wol.v1.Transport
+	2,  // 9: wol.v1.WakeService.Wake:input_type -> wol.v1.WakeRequest
+	4,  // 10: wol.v1.WakeService.WakeBatch:input_type -> wol.v1.WakeBatchRequest
+	7,  // 11: wol.v1.WakeService.ListHosts:input_type -> wol.v1.ListHostsRequest
+	10, // 12: wol.v1.WakeService.WatchWakeEvents:input_type -> wol.v1.WatchWakeEventsRequest
+	3,  // 13: wol.v1.WakeService.Wake:output_type -> wol.v1.WakeResponse
+	5,  // 14: wol.v1.WakeService.WakeBatch:output_type -> wol.v1.WakeBatchResponse
+	8,  // 15: wol.v1.WakeService.ListHosts:output_type -> wol.v1.ListHostsResponse
+	11, // 16: wol.v1.WakeService.WatchWakeEvents:output_type -> wol.v1.WakeEvent
+	13, // [13:17] is the sub-list for method output_type
+	9,  // [9:13] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_wol_proto_init() }
+func file_wol_proto_init() {
+	if File_wol_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_wol_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*WakeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wol_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*WakeResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wol_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*WakeBatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wol_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*WakeBatchResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wol_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*WakeResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wol_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ListHostsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wol_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ListHostsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wol_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*Host); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wol_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*WatchWakeEventsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_wol_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*WakeEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_wol_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wol_proto_goTypes,
+		DependencyIndexes: file_wol_proto_depIdxs,
+		EnumInfos:         file_wol_proto_enumTypes,
+		MessageInfos:      file_wol_proto_msgTypes,
+	}.Build()
+	File_wol_proto = out.File
+	file_wol_proto_rawDesc = nil
+	file_wol_proto_goTypes = nil
+	file_wol_proto_depIdxs = nil
+}
@@ -0,0 +1,257 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: wol.proto
+
+// Package wol.v1 defines a service which wakes machines using Wake-on-LAN.
+
+package wolgrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	WakeService_Wake_FullMethodName            = "/wol.v1.WakeService/Wake"
+	WakeService_WakeBatch_FullMethodName       = "/wol.v1.WakeService/WakeBatch"
+	WakeService_ListHosts_FullMethodName       = "/wol.v1.WakeService/ListHosts"
+	WakeService_WatchWakeEvents_FullMethodName = "/wol.v1.WakeService/WatchWakeEvents"
+)
+
+// WakeServiceClient is the client API for WakeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// WakeService wakes machines using Wake-on-LAN magic packets.
+type WakeServiceClient interface {
+	// Wake sends a magic packet to wake a single machine.
+	Wake(ctx context.Context, in *WakeRequest, opts ...grpc.CallOption) (*WakeResponse, error)
+	// WakeBatch sends magic packets to wake several machines. Each request
+	// succeeds or fails independently.
+	WakeBatch(ctx context.Context, in *WakeBatchRequest, opts ...grpc.CallOption) (*WakeBatchResponse, error)
+	// ListHosts lists the machines known to the server by name.
+	ListHosts(ctx context.Context, in *ListHostsRequest, opts ...grpc.CallOption) (*ListHostsResponse, error)
+	// WatchWakeEvents streams magic packets sent by the server or received by
+	// its listeners, until the client cancels the call.
+	WatchWakeEvents(ctx context.Context, in *WatchWakeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WakeEvent], error)
+}
+
+type wakeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWakeServiceClient(cc grpc.ClientConnInterface) WakeServiceClient {
+	return &wakeServiceClient{cc}
+}
+
+func (c *wakeServiceClient) Wake(ctx context.Context, in *WakeRequest, opts ...grpc.CallOption) (*WakeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WakeResponse)
+	err := c.cc.Invoke(ctx, WakeService_Wake_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wakeServiceClient) WakeBatch(ctx context.Context, in *WakeBatchRequest, opts ...grpc.CallOption) (*WakeBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WakeBatchResponse)
+	err := c.cc.Invoke(ctx, WakeService_WakeBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wakeServiceClient) ListHosts(ctx context.Context, in *ListHostsRequest, opts ...grpc.CallOption) (*ListHostsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListHostsResponse)
+	err := c.cc.Invoke(ctx, WakeService_ListHosts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wakeServiceClient) WatchWakeEvents(ctx context.Context, in *WatchWakeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WakeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WakeService_ServiceDesc.Streams[0], WakeService_WatchWakeEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchWakeEventsRequest, WakeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WakeService_WatchWakeEventsClient = grpc.ServerStreamingClient[WakeEvent]
+
+// WakeServiceServer is the server API for WakeService service.
+// All implementations must embed UnimplementedWakeServiceServer
+// for forward compatibility.
+//
+// WakeService wakes machines using Wake-on-LAN magic packets.
+type WakeServiceServer interface {
+	// Wake sends a magic packet to wake a single machine.
+	Wake(context.Context, *WakeRequest) (*WakeResponse, error)
+	// WakeBatch sends magic packets to wake several machines. Each request
+	// succeeds or fails independently.
+	WakeBatch(context.Context, *WakeBatchRequest) (*WakeBatchResponse, error)
+	// ListHosts lists the machines known to the server by name.
+	ListHosts(context.Context, *ListHostsRequest) (*ListHostsResponse, error)
+	// WatchWakeEvents streams magic packets sent by the server or received by
+	// its listeners, until the client cancels the call.
+	WatchWakeEvents(*WatchWakeEventsRequest, grpc.ServerStreamingServer[WakeEvent]) error
+	mustEmbedUnimplementedWakeServiceServer()
+}
+
+// UnimplementedWakeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWakeServiceServer struct{}
+
+func (UnimplementedWakeServiceServer) Wake(context.Context, *WakeRequest) (*WakeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Wake not implemented")
+}
+func (UnimplementedWakeServiceServer) WakeBatch(context.Context, *WakeBatchRequest) (*WakeBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WakeBatch not implemented")
+}
+func (UnimplementedWakeServiceServer) ListHosts(context.Context, *ListHostsRequest) (*ListHostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListHosts not implemented")
+}
+func (UnimplementedWakeServiceServer) WatchWakeEvents(*WatchWakeEventsRequest, grpc.ServerStreamingServer[WakeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchWakeEvents not implemented")
+}
+func (UnimplementedWakeServiceServer) mustEmbedUnimplementedWakeServiceServer() {}
+func (UnimplementedWakeServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeWakeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WakeServiceServer will
+// result in compilation errors.
+type UnsafeWakeServiceServer interface {
+	mustEmbedUnimplementedWakeServiceServer()
+}
+
+func RegisterWakeServiceServer(s grpc.ServiceRegistrar, srv WakeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedWakeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&WakeService_ServiceDesc, srv)
+}
+
+func _WakeService_Wake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WakeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WakeServiceServer).Wake(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WakeService_Wake_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WakeServiceServer).Wake(ctx, req.(*WakeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WakeService_WakeBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WakeBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WakeServiceServer).WakeBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WakeService_WakeBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WakeServiceServer).WakeBatch(ctx, req.(*WakeBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WakeService_ListHosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListHostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WakeServiceServer).ListHosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WakeService_ListHosts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WakeServiceServer).ListHosts(ctx, req.(*ListHostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WakeService_WatchWakeEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchWakeEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WakeServiceServer).WatchWakeEvents(m, &grpc.GenericServerStream[WatchWakeEventsRequest, WakeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WakeService_WatchWakeEventsServer = grpc.ServerStreamingServer[WakeEvent]
+
+// WakeService_ServiceDesc is the grpc.ServiceDesc for WakeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WakeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wol.v1.WakeService",
+	HandlerType: (*WakeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Wake",
+			Handler:    _WakeService_Wake_Handler,
+		},
+		{
+			MethodName: "WakeBatch",
+			Handler:    _WakeService_WakeBatch_Handler,
+		},
+		{
+			MethodName: "ListHosts",
+			Handler:    _WakeService_ListHosts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchWakeEvents",
+			Handler:       _WakeService_WatchWakeEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "wol.proto",
+}
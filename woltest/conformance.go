@@ -0,0 +1,98 @@
+package woltest
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/wol"
+)
+
+// A MakeWaker creates a wol.Waker which wakes Hosts on n. Any cleanup should
+// be registered using t.Cleanup.
+type MakeWaker func(t *testing.T, n *Network) wol.Waker
+
+// TestWaker runs a conformance suite against the wol.Wakers created by fn.
+// Each test creates a new Network with sleeping Hosts and verifies that the
+// Waker wakes only its intended target.
+func TestWaker(t *testing.T, fn MakeWaker) {
+	t.Helper()
+
+	var (
+		targetMAC = net.HardwareAddr{0x02, 0xde, 0xad, 0xbe, 0xef, 0x01}
+		otherMAC  = net.HardwareAddr{0x02, 0xde, 0xad, 0xbe, 0xef, 0x02}
+	)
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, w wol.Waker, target, other *Host)
+	}{
+		{
+			name: "wakes target",
+			fn: func(t *testing.T, w wol.Waker, target, _ *Host) {
+				if err := w.WakeContext(context.Background(), target.MAC); err != nil {
+					t.Fatalf("failed to wake: %v", err)
+				}
+
+				waitAwake(t, target)
+			},
+		},
+		{
+			name: "other hosts stay asleep",
+			fn: func(t *testing.T, w wol.Waker, target, other *Host) {
+				if err := w.WakeContext(context.Background(), target.MAC); err != nil {
+					t.Fatalf("failed to wake: %v", err)
+				}
+
+				waitAwake(t, target)
+				if other.Awake() {
+					t.Fatal("woke host which was not the target")
+				}
+			},
+		},
+		{
+			name: "wakes repeatedly",
+			fn: func(t *testing.T, w wol.Waker, target, _ *Host) {
+				for i := 0; i < 3; i++ {
+					if err := w.WakeContext(context.Background(), target.MAC); err != nil {
+						t.Fatalf("failed to wake: %v", err)
+					}
+
+					waitAwake(t, target)
+					target.Sleep()
+				}
+			},
+		},
+		{
+			name: "invalid target",
+			fn: func(t *testing.T, w wol.Waker, _, _ *Host) {
+				if err := w.WakeContext(context.Background(), net.HardwareAddr{0xde, 0xad, 0xbe, 0xef}); err == nil {
+					t.Fatal("expected an error for invalid target, but none occurred")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNetwork()
+			target := n.AddHost(targetMAC, nil)
+			other := n.AddHost(otherMAC, nil)
+
+			tt.fn(t, fn(t, n), target, other)
+		})
+	}
+}
+
+// waitAwake waits a short time for h to wake.
+func waitAwake(t *testing.T, h *Host) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Wait(ctx); err != nil {
+		t.Fatalf("host %s did not wake: %v", h.MAC, err)
+	}
+}
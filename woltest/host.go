@@ -0,0 +1,110 @@
+package woltest
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/mdlayher/wol"
+)
+
+// A Host is a simulated machine on a Network. A Host begins asleep, and
+// wakes only when it receives a valid magic packet for its hardware address
+// which carries its SecureOn password, if it has one.
+type Host struct {
+	// MAC is the Host's hardware address.
+	MAC net.HardwareAddr
+
+	// IP is the Host's IPv4 address on its Network.
+	IP net.IP
+
+	// Password is the Host's optional SecureOn password.
+	Password []byte
+
+	mu      sync.Mutex
+	awake   bool
+	woken   chan struct{}
+	packets int
+}
+
+// Awake reports whether h is awake.
+func (h *Host) Awake() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.awake
+}
+
+// Packets returns the number of valid magic packets addressed to h which h
+// has received, including those which did not wake it due to an incorrect
+// password.
+func (h *Host) Packets() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.packets
+}
+
+// Sleep puts h to sleep, so it can be woken again.
+func (h *Host) Sleep() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.awake {
+		h.awake = false
+		h.woken = make(chan struct{})
+	}
+}
+
+// Wait blocks until h is awake or ctx is canceled.
+func (h *Host) Wait(ctx context.Context) error {
+	h.mu.Lock()
+	woken := h.woken
+	h.mu.Unlock()
+
+	select {
+	case <-woken:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check returns a wol.Check which reports whether h is awake, for use with
+// a wol.Chain.
+func (h *Host) Check() wol.Check {
+	return func(_ context.Context) error {
+		if !h.Awake() {
+			return errors.New("woltest: host is asleep")
+		}
+
+		return nil
+	}
+}
+
+// receive processes a packet payload received by h.
+func (h *Host) receive(b []byte) {
+	p := new(wol.MagicPacket)
+	if err := p.UnmarshalBinary(b); err != nil {
+		return
+	}
+	if !bytes.Equal(p.Target, h.MAC) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.packets++
+	if len(h.Password) > 0 && subtle.ConstantTimeCompare(p.Password, h.Password) != 1 {
+		return
+	}
+
+	if !h.awake {
+		h.awake = true
+		close(h.woken)
+	}
+}
@@ -0,0 +1,358 @@
+// Package woltest provides utilities for testing code which sends
+// Wake-on-LAN magic packets, without elevated privileges or a real network.
+//
+// A Network is an in-memory broadcast domain. It provides net.PacketConns
+// which can be used with wol.NewClientConn and wol.NewRawClientConn, and
+// simulated Hosts which wake only when they receive a valid magic packet.
+package woltest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/ethernet"
+	"github.com/mdlayher/packet"
+)
+
+// queueLen is the number of packets buffered by each connection before
+// further packets are dropped, as with a real socket receive buffer.
+const queueLen = 64
+
+// A Network is an in-memory broadcast domain containing simulated Hosts and
+// connections which can send packets to them.
+//
+// A Network uses the IPv4 subnet 192.0.2.0/24. UDP packets sent to the
+// subnet's broadcast address, 192.0.2.255, or to the limited broadcast
+// address, 255.255.255.255, are delivered to every Host, and to every UDP
+// connection bound to the destination port.
+type Network struct {
+	mu     sync.Mutex
+	hosts  []*Host
+	conns  []*conn
+	nextIP byte
+	port   int
+	mac    byte
+}
+
+// NewNetwork creates an empty Network.
+func NewNetwork() *Network {
+	return &Network{
+		nextIP: 1,
+		port:   49152,
+	}
+}
+
+// Broadcast returns the directed broadcast address of n's subnet.
+func (n *Network) Broadcast() net.IP {
+	return net.IPv4(192, 0, 2, 255)
+}
+
+// AddHost adds a sleeping Host with the specified hardware address to n.
+// If password is not empty, the Host only wakes when a magic packet carries
+// the same SecureOn password.
+func (n *Network) AddHost(mac net.HardwareAddr, password []byte) *Host {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	h := &Host{
+		MAC:      mac,
+		IP:       n.allocIP(),
+		Password: password,
+		woken:    make(chan struct{}),
+	}
+	n.hosts = append(n.hosts, h)
+
+	return h
+}
+
+// ListenUDP creates a net.PacketConn which sends and receives UDP payloads
+// on n. If addr is nil, or its IP or port are unspecified, they are assigned
+// automatically.
+func (n *Network) ListenUDP(addr *net.UDPAddr) (net.PacketConn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	local := &net.UDPAddr{}
+	if addr != nil {
+		*local = *addr
+	}
+	if local.IP == nil || local.IP.IsUnspecified() {
+		local.IP = n.allocIP()
+	}
+	if local.Port == 0 {
+		local.Port = n.port
+		n.port++
+	}
+
+	for _, c := range n.conns {
+		if a, ok := c.local.(*net.UDPAddr); ok && a.IP.Equal(local.IP) && a.Port == local.Port {
+			return nil, fmt.Errorf("woltest: address %s already in use", local)
+		}
+	}
+
+	return n.newConn(local), nil
+}
+
+// ListenRaw creates a net.PacketConn which sends and receives complete
+// Ethernet frames on n, like a raw socket bound to a network interface. It
+// is suitable for use with wol.NewRawClientConn.
+//
+// The returned net.Interface describes the simulated network interface, and
+// has a unique, automatically assigned hardware address.
+func (n *Network) ListenRaw() (*net.Interface, net.PacketConn) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.mac++
+	ifi := &net.Interface{
+		Index:        int(n.mac),
+		Name:         fmt.Sprintf("woltest%d", n.mac),
+		MTU:          1500,
+		HardwareAddr: net.HardwareAddr{0x02, 0x00, 0x5e, 0x00, 0x00, n.mac},
+		Flags:        net.FlagUp | net.FlagBroadcast,
+	}
+
+	return ifi, n.newConn(&packet.Addr{HardwareAddr: ifi.HardwareAddr})
+}
+
+// allocIP allocates the next IPv4 address in n's subnet. n.mu must be held.
+func (n *Network) allocIP() net.IP {
+	ip := net.IPv4(192, 0, 2, n.nextIP)
+	n.nextIP++
+	return ip
+}
+
+// newConn creates and registers a conn. n.mu must be held.
+func (n *Network) newConn(local net.Addr) *conn {
+	c := &conn{
+		n:      n,
+		local:  local,
+		queue:  make(chan datagram, queueLen),
+		closed: make(chan struct{}),
+		notify: make(chan struct{}, 1),
+	}
+	n.conns = append(n.conns, c)
+
+	return c
+}
+
+// remove unregisters c from n.
+func (n *Network) remove(c *conn) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i, v := range n.conns {
+		if v == c {
+			n.conns = append(n.conns[:i], n.conns[i+1:]...)
+			return
+		}
+	}
+}
+
+// isBroadcast reports whether ip is a broadcast address on n.
+func (n *Network) isBroadcast(ip net.IP) bool {
+	return ip.Equal(net.IPv4bcast) || ip.Equal(n.Broadcast())
+}
+
+// sendUDP delivers a UDP payload from src to dst.
+func (n *Network) sendUDP(src *net.UDPAddr, dst *net.UDPAddr, b []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	bcast := n.isBroadcast(dst.IP)
+	for _, h := range n.hosts {
+		// A sleeping Host does not answer ARP, so only broadcasts reach it.
+		if bcast {
+			h.receive(b)
+		}
+	}
+
+	for _, c := range n.conns {
+		a, ok := c.local.(*net.UDPAddr)
+		if !ok || c.local == src || a.Port != dst.Port {
+			continue
+		}
+
+		if bcast || a.IP.Equal(dst.IP) {
+			c.deliver(b, src)
+		}
+	}
+}
+
+// sendRaw delivers an Ethernet frame from src.
+func (n *Network) sendRaw(src *packet.Addr, b []byte) error {
+	f := new(ethernet.Frame)
+	if err := f.UnmarshalBinary(b); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	bcast := bytes.Equal(f.Destination, ethernet.Broadcast)
+	for _, h := range n.hosts {
+		if bcast || bytes.Equal(f.Destination, h.MAC) {
+			h.receive(f.Payload)
+		}
+	}
+
+	for _, c := range n.conns {
+		a, ok := c.local.(*packet.Addr)
+		if !ok || c.local == src {
+			continue
+		}
+
+		if bcast || bytes.Equal(f.Destination, a.HardwareAddr) {
+			c.deliver(b, &packet.Addr{HardwareAddr: f.Source})
+		}
+	}
+
+	return nil
+}
+
+var _ net.PacketConn = &conn{}
+
+// A datagram is a packet queued for a conn.
+type datagram struct {
+	b    []byte
+	addr net.Addr
+}
+
+// A conn is a net.PacketConn attached to a Network.
+type conn struct {
+	n     *Network
+	local net.Addr
+
+	queue  chan datagram
+	closed chan struct{}
+	once   sync.Once
+
+	mu       sync.Mutex
+	deadline time.Time
+	notify   chan struct{}
+}
+
+// deliver queues a copy of b for c, dropping it if c's queue is full.
+func (c *conn) deliver(b []byte, from net.Addr) {
+	select {
+	case <-c.closed:
+		return
+	default:
+	}
+
+	select {
+	case c.queue <- datagram{b: append([]byte(nil), b...), addr: from}:
+	default:
+	}
+}
+
+func (c *conn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		c.mu.Lock()
+		deadline := c.deadline
+		c.mu.Unlock()
+
+		var (
+			t       *time.Timer
+			timeout <-chan time.Time
+		)
+		if !deadline.IsZero() {
+			d := time.Until(deadline)
+			if d <= 0 {
+				return 0, nil, os.ErrDeadlineExceeded
+			}
+
+			t = time.NewTimer(d)
+			timeout = t.C
+		}
+
+		n, addr, done, err := c.wait(b, timeout)
+		if t != nil {
+			t.Stop()
+		}
+		if done {
+			return n, addr, err
+		}
+
+		// The deadline changed while waiting; recompute it.
+	}
+}
+
+// wait waits for a datagram, c to be closed, or timeout to fire. done is false
+// if c's read deadline was changed while waiting.
+func (c *conn) wait(b []byte, timeout <-chan time.Time) (n int, addr net.Addr, done bool, err error) {
+	select {
+	case <-c.closed:
+		return 0, nil, true, net.ErrClosed
+	case d := <-c.queue:
+		return copy(b, d.b), d.addr, true, nil
+	case <-timeout:
+		return 0, nil, true, os.ErrDeadlineExceeded
+	case <-c.notify:
+		return 0, nil, false, nil
+	}
+}
+
+func (c *conn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+
+	switch src := c.local.(type) {
+	case *net.UDPAddr:
+		dst, ok := addr.(*net.UDPAddr)
+		if !ok {
+			return 0, fmt.Errorf("woltest: UDP connection cannot send to %T", addr)
+		}
+		if dst.IP.To4() == nil {
+			return 0, errors.New("woltest: network only supports IPv4")
+		}
+
+		c.n.sendUDP(src, dst, b)
+	case *packet.Addr:
+		if _, ok := addr.(*packet.Addr); !ok {
+			return 0, fmt.Errorf("woltest: raw connection cannot send to %T", addr)
+		}
+
+		if err := c.n.sendRaw(src, b); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+func (c *conn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+		c.n.remove(c)
+	})
+
+	return nil
+}
+
+func (c *conn) LocalAddr() net.Addr { return c.local }
+
+func (c *conn) SetDeadline(t time.Time) error { return c.SetReadDeadline(t) }
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (c *conn) SetWriteDeadline(_ time.Time) error { return nil }
@@ -0,0 +1,155 @@
+package woltest_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/wol"
+	"github.com/mdlayher/wol/woltest"
+)
+
+func TestClientConformance(t *testing.T) {
+	woltest.TestWaker(t, func(t *testing.T, n *woltest.Network) wol.Waker {
+		p, err := n.ListenUDP(nil)
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+
+		c := wol.NewClientConn(p)
+		t.Cleanup(func() { _ = c.Close() })
+
+		return c.Waker(n.Broadcast().String() + ":9")
+	})
+}
+
+func TestRawClientConformance(t *testing.T) {
+	woltest.TestWaker(t, func(t *testing.T, n *woltest.Network) wol.Waker {
+		ifi, p := n.ListenRaw()
+
+		c := wol.NewRawClientConn(ifi, p)
+		t.Cleanup(func() { _ = c.Close() })
+
+		return c
+	})
+}
+
+func TestHostPassword(t *testing.T) {
+	n := woltest.NewNetwork()
+	h := n.AddHost(net.HardwareAddr{0x02, 0, 0, 0, 0, 1}, []byte{1, 2, 3, 4})
+
+	p, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	c := wol.NewClientConn(p)
+	defer c.Close()
+
+	addr := n.Broadcast().String() + ":9"
+	if err := c.WakePassword(addr, h.MAC, []byte{4, 3, 2, 1}); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+	if h.Awake() || h.Packets() != 1 {
+		t.Fatal("host woke with incorrect password")
+	}
+
+	if err := c.WakePassword(addr, h.MAC, []byte{1, 2, 3, 4}); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+	if !h.Awake() {
+		t.Fatal("host did not wake with correct password")
+	}
+}
+
+func TestHostUnicastNotDelivered(t *testing.T) {
+	n := woltest.NewNetwork()
+	h := n.AddHost(net.HardwareAddr{0x02, 0, 0, 0, 0, 1}, nil)
+
+	p, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	c := wol.NewClientConn(p)
+	defer c.Close()
+
+	// A sleeping host cannot answer ARP, so unicast packets never reach it.
+	if err := c.Wake(h.IP.String()+":9", h.MAC); err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+	if h.Awake() {
+		t.Fatal("host woke from unicast packet")
+	}
+}
+
+func TestNetworkUDPDelivery(t *testing.T) {
+	n := woltest.NewNetwork()
+
+	l, err := n.ListenUDP(&net.UDPAddr{Port: 9})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	p, err := n.ListenUDP(nil)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer p.Close()
+
+	dst := &net.UDPAddr{IP: n.Broadcast(), Port: 9}
+	if _, err := p.WriteTo([]byte("hello"), dst); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	b := make([]byte, 16)
+	nb, addr, err := l.ReadFrom(b)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if string(b[:nb]) != "hello" || addr.String() != p.LocalAddr().String() {
+		t.Fatalf("unexpected datagram %q from %s", b[:nb], addr)
+	}
+
+	// No more data is available, so the read must time out.
+	if err := l.SetReadDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+	if _, _, err := l.ReadFrom(b); !isTimeout(err) {
+		t.Fatalf("expected timeout, but got: %v", err)
+	}
+}
+
+func TestHostCheck(t *testing.T) {
+	n := woltest.NewNetwork()
+	h := n.AddHost(net.HardwareAddr{0x02, 0, 0, 0, 0, 1}, nil)
+
+	ifi, p := n.ListenRaw()
+	c := wol.NewRawClientConn(ifi, p)
+	defer c.Close()
+
+	chain := &wol.Chain{
+		Steps: []wol.Step{{
+			Name:    "raw",
+			Waker:   c,
+			Timeout: time.Second,
+		}},
+		Check:    h.Check(),
+		Interval: time.Millisecond,
+	}
+
+	res, err := chain.Wake(context.Background(), h.MAC)
+	if err != nil {
+		t.Fatalf("failed to wake: %v", err)
+	}
+	if res.Step != "raw" {
+		t.Fatalf("unexpected step: %q", res.Step)
+	}
+}
+
+func isTimeout(err error) bool {
+	nerr, ok := err.(net.Error)
+	return ok && nerr.Timeout()
+}